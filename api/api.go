@@ -1,7 +1,10 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path"
@@ -12,6 +15,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/chaos-io/depth2STL/barcode"
+	"github.com/chaos-io/depth2STL/dem"
+	"github.com/chaos-io/depth2STL/depth"
+	"github.com/chaos-io/depth2STL/stl"
+	"github.com/chaos-io/depth2STL/util"
 	"github.com/gin-gonic/gin"
 	"github.com/segmentio/ksuid"
 )
@@ -59,6 +67,205 @@ func parseBoolForm(c *gin.Context, key string, defaultValue bool) (bool, error)
 	return strconv.ParseBool(value)
 }
 
+// parseDEMOptions 读取地形输入参数，提供 minElevation 时裁剪低于它的高程
+func parseDEMOptions(c *gin.Context) (dem.Options, error) {
+	opts := dem.DefaultOptions()
+
+	var err error
+	if opts.Exaggeration, err = parseFloat64Form(c, "exaggeration", opts.Exaggeration); err != nil {
+		return opts, fmt.Errorf("invalid exaggeration")
+	}
+	if value := strings.TrimSpace(c.PostForm("minElevation")); value != "" {
+		if opts.MinElevation, err = strconv.ParseFloat(value, 64); err != nil {
+			return opts, fmt.Errorf("invalid minElevation")
+		}
+		opts.ClampMin = true
+	}
+	if opts.RawWidth, err = parseIntForm(c, "rawWidth", opts.RawWidth); err != nil {
+		return opts, fmt.Errorf("invalid rawWidth")
+	}
+	if opts.RawCellSize, err = parseFloat64Form(c, "rawCellSize", opts.RawCellSize); err != nil {
+		return opts, fmt.Errorf("invalid rawCellSize")
+	}
+	if opts.RawBigEndian, err = parseBoolForm(c, "rawBigEndian", opts.RawBigEndian); err != nil {
+		return opts, fmt.Errorf("invalid rawBigEndian")
+	}
+	return opts, opts.Validate()
+}
+
+// parseTextOptions 读取文字输入的排版参数，engrave 未提供时与 invert 一致
+func parseTextOptions(c *gin.Context, invert bool) (depth.TextOptions, error) {
+	opts := depth.DefaultTextOptions()
+
+	var err error
+	floats := []struct {
+		key string
+		dst *float64
+	}{
+		{"fontSize", &opts.Size},
+		{"lineSpacing", &opts.LineSpacing},
+		{"margin", &opts.Margin},
+		{"bevel", &opts.Bevel},
+	}
+	for _, f := range floats {
+		if *f.dst, err = parseFloat64Form(c, f.key, *f.dst); err != nil {
+			return opts, fmt.Errorf("invalid %s", f.key)
+		}
+	}
+	if opts.Engrave, err = parseBoolForm(c, "engrave", invert); err != nil {
+		return opts, fmt.Errorf("invalid engrave")
+	}
+	if font := strings.ToLower(strings.TrimSpace(c.PostForm("font"))); font != "" {
+		opts.Font = font
+	}
+	if align := strings.ToLower(strings.TrimSpace(c.PostForm("align"))); align != "" {
+		opts.Align = align
+	}
+	return opts, opts.Validate()
+}
+
+// parseCodeOptions 读取二维码、条码输入的参数，静区默认取码制规范的最小值
+func parseCodeOptions(c *gin.Context, input string) (barcode.Options, error) {
+	if !isCodeInput(input) {
+		return barcode.Options{}, nil
+	}
+	opts := barcode.DefaultOptions(input)
+
+	var err error
+	floats := []struct {
+		key string
+		dst *float64
+	}{
+		{"moduleSize", &opts.ModuleSize},
+		{"moduleHeight", &opts.Height},
+		{"barHeight", &opts.BarHeight},
+	}
+	for _, f := range floats {
+		if *f.dst, err = parseFloat64Form(c, f.key, *f.dst); err != nil {
+			return opts, fmt.Errorf("invalid %s", f.key)
+		}
+	}
+	if opts.QuietZone, err = parseIntForm(c, "quietZone", opts.QuietZone); err != nil {
+		return opts, fmt.Errorf("invalid quietZone")
+	}
+	if level := strings.ToUpper(strings.TrimSpace(c.PostForm("errorCorrection"))); level != "" {
+		opts.Level = level
+	}
+	return opts, opts.Validate()
+}
+
+// parseFloatListForm 读取逗号分隔的数字列表，未提供时返回 nil
+func parseFloatListForm(c *gin.Context, key string) ([]float64, error) {
+	value := strings.TrimSpace(c.PostForm(key))
+	if value == "" {
+		return nil, nil
+	}
+
+	var list []float64
+	for _, field := range strings.Split(value, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// autoTunedFields 自动调参会覆盖的参数，不能与 autoTune 同时指定
+var autoTunedFields = []string{"backgroundClip", "detailStrength", "gamma", "lowPercentile", "highPercentile"}
+
+// parseDepthOptions 读取可选的深度估计参数，未提供的字段使用默认值
+func parseDepthOptions(c *gin.Context, invert bool) (depth.Options, error) {
+	opts := depth.DefaultOptions()
+	opts.Invert = invert
+
+	var err error
+	ints := []struct {
+		key string
+		dst *int
+	}{
+		{"baseSize", &opts.BaseSize},
+		{"backgroundClip", &opts.BackgroundClip},
+		{"levels", &opts.Levels},
+		{"smoothRadius", &opts.SmoothRadius},
+		{"claheTiles", &opts.ClaheTiles},
+		{"maxDisparity", &opts.MaxDisparity},
+		{"paletteSize", &opts.PaletteSize},
+	}
+	for _, f := range ints {
+		if *f.dst, err = parseIntForm(c, f.key, *f.dst); err != nil {
+			return opts, fmt.Errorf("invalid %s", f.key)
+		}
+	}
+
+	floats := []struct {
+		key string
+		dst *float64
+	}{
+		{"detailStrength", &opts.DetailStrength},
+		{"gamma", &opts.Gamma},
+		{"lowPercentile", &opts.LowPercentile},
+		{"highPercentile", &opts.HighPercentile},
+		{"heightGamma", &opts.HeightGamma},
+		{"smoothStrength", &opts.SmoothStrength},
+		{"claheLimit", &opts.ClaheLimit},
+		{"pillowDetail", &opts.PillowDetail},
+		{"normalDetail", &opts.NormalDetail},
+		{"lightElevation", &opts.LightElevation},
+		{"lineThreshold", &opts.LineThreshold},
+		{"lineWidth", &opts.LineWidth},
+		{"lineDepth", &opts.LineDepth},
+	}
+	for _, f := range floats {
+		if *f.dst, err = parseFloat64Form(c, f.key, *f.dst); err != nil {
+			return opts, fmt.Errorf("invalid %s", f.key)
+		}
+	}
+
+	bools := []struct {
+		key string
+		dst *bool
+	}{
+		{"normalFlipY", &opts.NormalFlipY},
+		{"stereoSwap", &opts.StereoSwap},
+		{"lineEngrave", &opts.LineEngrave},
+	}
+	for _, f := range bools {
+		if *f.dst, err = parseBoolForm(c, f.key, *f.dst); err != nil {
+			return opts, fmt.Errorf("invalid %s", f.key)
+		}
+	}
+
+	if smoothing := strings.ToLower(strings.TrimSpace(c.PostForm("smoothing"))); smoothing != "" {
+		opts.Smoothing = smoothing
+	}
+	if method := strings.ToLower(strings.TrimSpace(c.PostForm("lineMethod"))); method != "" {
+		opts.LineMethod = method
+	}
+
+	// 列表参数以逗号分隔，如 detailGains=1.8,1.4,1
+	lists := []struct {
+		key string
+		dst *[]float64
+	}{
+		{"detailGains", &opts.DetailGains},
+		{"paletteHeights", &opts.PaletteHeights},
+	}
+	for _, f := range lists {
+		if *f.dst, err = parseFloatListForm(c, f.key); err != nil {
+			return opts, fmt.Errorf("invalid %s", f.key)
+		}
+	}
+	if palette := strings.TrimSpace(c.PostForm("palette")); palette != "" {
+		for _, color := range strings.Split(palette, ",") {
+			opts.Palette = append(opts.Palette, strings.TrimSpace(color))
+		}
+	}
+
+	return opts, opts.Validate()
+}
+
 func CreateHandler(c *gin.Context) {
 	modelWidth, err := parseFloat64Form(c, "modelWidth", 50.0)
 	if err != nil {
@@ -73,7 +280,7 @@ func CreateHandler(c *gin.Context) {
 	}
 
 	baseThickness, err := parseFloat64Form(c, "baseThickness", 2.0)
-	if err != nil {
+	if err != nil || baseThickness < 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid baseThickness"})
 		return
 	}
@@ -96,48 +303,296 @@ func CreateHandler(c *gin.Context) {
 		return
 	}
 
+	maxError, err := parseFloat64Form(c, "maxError", 0)
+	if err != nil || maxError < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maxError"})
+		return
+	}
+
+	targetTriangles, err := parseIntForm(c, "targetTriangles", 0)
+	if err != nil || targetTriangles < 0 || (targetTriangles > 0 && targetTriangles < 4) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid targetTriangles"})
+		return
+	}
+
+	depthAlgorithm := strings.TrimSpace(c.PostForm("depthAlgorithm"))
+	if skipConv {
+		if depthAlgorithm != "" && depthAlgorithm != depth.EstimatorGray {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "skipConv conflicts with depthAlgorithm"})
+			return
+		}
+		depthAlgorithm = depth.EstimatorGray
+	}
+	if depthAlgorithm == "" {
+		depthAlgorithm = depth.DefaultEstimator
+	}
+	if _, ok := depth.Lookup(depthAlgorithm); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid depthAlgorithm"})
+		return
+	}
+
+	depthOptions, err := parseDepthOptions(c, invert)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	autoTune, err := parseBoolForm(c, "autoTune", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid autoTune"})
+		return
+	}
+	if autoTune {
+		for _, key := range autoTunedFields {
+			if strings.TrimSpace(c.PostForm(key)) != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "autoTune conflicts with " + key})
+				return
+			}
+		}
+	}
+
+	compression, err := parseFloat64Form(c, "compression", 0)
+	if err != nil || compression < 0 || compression > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid compression"})
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(c.PostForm("format")))
+	if format == "" {
+		format = FormatSTL
+	}
+	if format != FormatSTL && format != Format3MF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format"})
+		return
+	}
+
+	// 文字输入的 file 为可选的字体文件，二维码、条码输入不需要文件
+	input := strings.ToLower(strings.TrimSpace(c.PostForm("input")))
 	file, err := c.FormFile("file")
+	if err != nil && ((input != InputText && !isCodeInput(input)) || !errors.Is(err, http.ErrMissingFile)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ext := ""
+	if file != nil {
+		ext = filepath.Ext(file.Filename)
+	}
+	if input == "" {
+		input = InputImage
+		if modelExtensions[strings.ToLower(ext)] {
+			input = InputModel
+		}
+		if dem.Extensions[strings.ToLower(ext)] {
+			input = InputDEM
+		}
+		if svgExtensions[strings.ToLower(ext)] {
+			input = InputSVG
+		}
+	}
+	if !validInputs[input] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+	if file != nil && isCodeInput(input) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is not supported for " + input + " input"})
+		return
+	}
+	if file != nil {
+		if err := validateFileType(file, input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	text := c.PostForm("text")
+	textOptions, err := parseTextOptions(c, invert)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := validateFileType(file.Filename); err != nil {
+	if (input == InputText || isCodeInput(input)) && strings.TrimSpace(text) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text is required for " + input + " input"})
+		return
+	}
+
+	codeOptions, err := parseCodeOptions(c, input)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if isCodeInput(input) {
+		// 内容过长、含码制不支持的字符时直接返回 400，不进入队列
+		if _, err = barcode.Encode(text, codeOptions); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	ext := filepath.Ext(file.Filename)
-	filename := strings.TrimSuffix(file.Filename, ext)
+	demOptions, err := parseDEMOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input == InputDEM && dem.IsRaw(file.Filename) && demOptions.RawWidth == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rawWidth is required for raw float32 grids"})
+		return
+	}
+
+	// 光度立体、立体像对：file 字段上传多张图片
+	var (
+		photos []*multipart.FileHeader
+		lights [][3]float64
+	)
+	if input == InputPhotometric || input == InputStereo {
+		photos = c.Request.MultipartForm.File["file"]
+		if input == InputPhotometric && len(photos) < minPhotometricPhotos {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("photometric input needs at least %d photos", minPhotometricPhotos)})
+			return
+		}
+		if input == InputStereo && len(photos) > 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stereo input takes a left/right pair or one side-by-side image"})
+			return
+		}
+		for _, photo := range photos {
+			if err := validateFileType(photo, InputImage); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+	if input == InputPhotometric {
+		if lights, err = parseLights(c.PostForm("lights")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lights"})
+			return
+		}
+		if lights != nil && len(lights) != len(photos) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lights must match the number of photos"})
+			return
+		}
+	}
+
+	// 法线贴图可附带一张彩色图
+	colorFile, err := c.FormFile("color")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if colorFile != nil {
+		if input != InputNormal {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "color is only supported for normal input"})
+			return
+		}
+		if err := validateFileType(colorFile, InputImage); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	view := strings.ToLower(strings.TrimSpace(c.PostForm("view")))
+	if view == "" {
+		view = stl.ViewFront
+	}
+	if !stl.ValidView(view) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid view"})
+		return
+	}
 
 	jobID := ksuid.New().String()
 	tmpDir := filepath.Join(pwd, "tmp", jobID)
 	_ = os.MkdirAll(tmpDir, os.ModePerm)
 
-	inputPath := filepath.Clean(filepath.Join(tmpDir, file.Filename))
-	imgPath := filepath.Clean(filepath.Join(tmpDir, jobID+ext))
+	var filename, inputPath, fontPath string
+	if input == InputText || isCodeInput(input) {
+		// 文字保存为输入文件，任务目录随任务一起清理
+		filename = strings.TrimSpace(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0])
+		inputPath = filepath.Clean(filepath.Join(tmpDir, jobID+".txt"))
+		if err = os.WriteFile(inputPath, []byte(text), 0o644); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if file != nil {
+			fontPath = filepath.Clean(filepath.Join(tmpDir, file.Filename))
+		}
+	} else {
+		filename = strings.TrimSuffix(file.Filename, ext)
+		inputPath = filepath.Clean(filepath.Join(tmpDir, file.Filename))
+	}
+
+	// 深度图总是保存为 PNG
+	imgPath := filepath.Clean(filepath.Join(tmpDir, jobID+".png"))
 	stlPath := filepath.Clean(filepath.Join(tmpDir, jobID+".stl"))
+	threeMFPath := filepath.Clean(filepath.Join(tmpDir, jobID+".3mf"))
+
+	var photoPaths []string
+	for i, photo := range photos {
+		// 照片可能同名，加序号区分
+		photoPath := filepath.Clean(filepath.Join(tmpDir, fmt.Sprintf("%d_%s", i, photo.Filename)))
+		if err = c.SaveUploadedFile(photo, photoPath); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		photoPaths = append(photoPaths, photoPath)
+	}
+	switch {
+	case len(photoPaths) > 0:
+		inputPath = photoPaths[0]
+	case input == InputText || isCodeInput(input):
+		if fontPath != "" {
+			if err = c.SaveUploadedFile(file, fontPath); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	default:
+		if err = c.SaveUploadedFile(file, inputPath); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
 
-	err = c.SaveUploadedFile(file, inputPath)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		return
+	colorPath := ""
+	if colorFile != nil {
+		colorPath = filepath.Clean(filepath.Join(tmpDir, jobID+"_color"+filepath.Ext(colorFile.Filename)))
+		if err = c.SaveUploadedFile(colorFile, colorPath); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	job := &Job{
-		ID:             jobID,
-		Name:           filename,
-		FilePath:       inputPath,
-		ImagePath:      imgPath,
-		StlPath:        stlPath,
-		ModelWidth:     modelWidth,
-		ModelThickness: modelThickness,
-		BaseThickness:  baseThickness,
-		SkipConv:       skipConv,
-		Invert:         invert,
-		DetailLevel:    detailLevel,
-		Status:         StatusQueued,
+		ID:              jobID,
+		Name:            filename,
+		FilePath:        inputPath,
+		ColorPath:       colorPath,
+		PhotoPaths:      photoPaths,
+		Lights:          lights,
+		ImagePath:       imgPath,
+		StlPath:         stlPath,
+		ThreeMFPath:     threeMFPath,
+		ModelWidth:      modelWidth,
+		ModelThickness:  modelThickness,
+		BaseThickness:   baseThickness,
+		SkipConv:        skipConv,
+		Invert:          invert,
+		DetailLevel:     detailLevel,
+		DepthAlgorithm:  depthAlgorithm,
+		DepthOptions:    depthOptions,
+		AutoTune:        autoTune,
+		DEMOptions:      demOptions,
+		Text:            text,
+		TextOptions:     textOptions,
+		CodeOptions:     codeOptions,
+		FontPath:        fontPath,
+		MaxError:        maxError,
+		TargetTriangles: targetTriangles,
+		Compression:     compression,
+		Input:           input,
+		View:            view,
+		Format:          format,
+		Status:          StatusQueued,
 	}
 
 	select {
@@ -153,63 +608,144 @@ func CreateHandler(c *gin.Context) {
 	c.JSON(200, gin.H{"jobId": jobID})
 }
 
-func validateFileType(filename string) error {
-	ext := filepath.Ext(filename)
-	allowedExtensions := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
+var (
+	modelExtensions = map[string]bool{
+		".stl": true,
+		".obj": true,
 	}
-
-	if !allowedExtensions[strings.ToLower(ext)] {
-		return fmt.Errorf("unsupported file type: %s", ext)
+	fontExtensions = map[string]bool{
+		".ttf": true,
+		".otf": true,
 	}
-	return nil
+	svgExtensions = map[string]bool{
+		".svg": true,
+	}
+)
+
+var validInputs = map[string]bool{
+	InputImage:       true,
+	InputModel:       true,
+	InputNormal:      true,
+	InputPhotometric: true,
+	InputStereo:      true,
+	InputDEM:         true,
+	InputText:        true,
+	InputSVG:         true,
+	InputQR:          true,
+	InputCode128:     true,
 }
 
-func DownloadStlHandler(c *gin.Context) {
-	jobID := c.Param("jobId")
-	downloadKey := "stl:" + jobID
-	if _, loaded := downloadingFiles.LoadOrStore(downloadKey, struct{}{}); loaded {
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": "download already in progress"})
-		return
+// isCodeInput 是否为二维码、条码输入，input 同时是码制名称
+func isCodeInput(input string) bool {
+	return input == InputQR || input == InputCode128
+}
+
+// minPhotometricPhotos 光度立体至少需要的照片数
+const minPhotometricPhotos = 3
+
+// parseLights 解析 "x,y,z;x,y,z;..." 形式的光源方向，空字符串返回 nil
+func parseLights(value string) ([][3]float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
 	}
-	defer downloadingFiles.Delete(downloadKey)
 
-	val, ok := jobStore.Load(jobID)
-	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
-		return
+	var lights [][3]float64
+	for _, item := range strings.Split(value, ";") {
+		fields := strings.Split(item, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("light %q must have 3 components", item)
+		}
+		var l [3]float64
+		for i, field := range fields {
+			v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = v
+		}
+		if l == [3]float64{} {
+			return nil, fmt.Errorf("light %q must not be zero", item)
+		}
+		lights = append(lights, l)
 	}
+	return lights, nil
+}
 
-	job := val.(*Job)
+// validateFileType 图片按文件头的魔数判断格式（jpeg/png/gif/bmp/tiff/webp），其他输入按扩展名判断
+func validateFileType(file *multipart.FileHeader, input string) error {
+	ext := filepath.Ext(file.Filename)
+	var allowedExtensions map[string]bool
+	switch input {
+	case InputModel:
+		allowedExtensions = modelExtensions
+	case InputDEM:
+		allowedExtensions = dem.Extensions
+	case InputText:
+		allowedExtensions = fontExtensions
+	case InputSVG:
+		allowedExtensions = svgExtensions
+	default:
+		format, err := sniffImageFormat(file)
+		if err != nil {
+			return err
+		}
+		if format == "" {
+			return fmt.Errorf("unsupported image type: %s", file.Filename)
+		}
+		return nil
+	}
 
-	if job.Status != StatusDone {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":  "file not ready",
-			"status": job.Status,
-		})
-		return
+	if !allowedExtensions[strings.ToLower(ext)] {
+		return fmt.Errorf("unsupported file type: %s", ext)
 	}
+	return nil
+}
 
-	// 文件是否存在
-	if _, err := os.Stat(job.StlPath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "file missing"})
-		return
+// sniffImageFormat 读取上传文件的文件头判断图片格式，不支持的格式返回空字符串
+func sniffImageFormat(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
 	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	header := make([]byte, 16)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return util.ImageFormat(header[:n]), nil
+}
 
-	// 设置下载头
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.stl", job.ID))
-	c.Header("Content-Transfer-Encoding", "binary")
-	c.Header("Cache-Control", "public, max-age=86400, immutable")
+func DownloadStlHandler(c *gin.Context) {
+	downloadJobFile(c, "stl", "stl", func(job *Job) string {
+		if job.Format != FormatSTL {
+			return ""
+		}
+		return job.StlPath
+	})
+}
 
-	c.File(job.StlPath)
+func Download3MFHandler(c *gin.Context) {
+	downloadJobFile(c, Format3MF, "3mf", func(job *Job) string {
+		if job.Format != Format3MF {
+			return ""
+		}
+		return job.ThreeMFPath
+	})
 }
 
 func DownloadImageHandler(c *gin.Context) {
+	downloadJobFile(c, "image", "png", func(job *Job) string { return job.ImagePath })
+}
+
+// downloadJobFile 下载任务产物，kind 同时作为并发下载的 key 前缀；pathOf 返回空表示该任务未生成此类文件
+func downloadJobFile(c *gin.Context, kind, ext string, pathOf func(job *Job) string) {
 	jobID := c.Param("jobId")
-	downloadKey := "image:" + jobID
+	downloadKey := kind + ":" + jobID
 	if _, loaded := downloadingFiles.LoadOrStore(downloadKey, struct{}{}); loaded {
 		c.JSON(http.StatusTooManyRequests, gin.H{"error": "download already in progress"})
 		return
@@ -232,19 +768,28 @@ func DownloadImageHandler(c *gin.Context) {
 		return
 	}
 
+	filePath := pathOf(job)
+	if filePath == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":  "file not generated for this job",
+			"format": job.Format,
+		})
+		return
+	}
+
 	// 文件是否存在
-	if _, err := os.Stat(job.ImagePath); err != nil {
+	if _, err := os.Stat(filePath); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "file missing"})
 		return
 	}
 
 	// 设置下载头
 	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.png", job.ID))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", job.ID, ext))
 	c.Header("Content-Transfer-Encoding", "binary")
 	c.Header("Cache-Control", "public, max-age=86400, immutable")
 
-	c.File(job.ImagePath)
+	c.File(filePath)
 }
 
 func GetJobHandler(c *gin.Context) {
@@ -261,19 +806,67 @@ func GetJobHandler(c *gin.Context) {
 	resp := gin.H{
 		"jobId":  job.ID,
 		"status": job.Status,
+		"format": job.Format,
+		"input":  job.Input,
+	}
+
+	if job.Input == InputImage {
+		resp["depthAlgorithm"] = job.DepthAlgorithm
+	}
+	if job.Input == InputPhotometric && job.Lights != nil {
+		resp["lights"] = job.Lights
+	}
+	if job.Terrain != nil {
+		resp["terrain"] = job.Terrain
+	}
+	if job.Input == InputText {
+		resp["textOptions"] = job.TextOptions
+	}
+	if isCodeInput(job.Input) {
+		resp["codeOptions"] = job.CodeOptions
+		resp["modelWidth"] = job.ModelWidth
+	}
+	resp["depthOptions"] = job.DepthOptions
+	if job.AutoTune {
+		resp["autoTune"] = true
+		if job.ImageStats != nil {
+			resp["imageStats"] = job.ImageStats
+		}
 	}
 
 	if job.Status == StatusDone {
-		resp["downloadUrl"] = fmt.Sprintf("/download/%s", job.ID)
+		// 与 main.go 中按格式注册的下载路由一致
+		resp["downloadUrl"] = fmt.Sprintf("/v1/relief/download/%s/%s", job.Format, job.ID)
 	}
 
 	if job.Status == StatusFailed {
 		resp["error"] = job.Error
 	}
 
+	if job.Validation != nil {
+		resp["validation"] = job.Validation
+	}
+
 	c.JSON(http.StatusOK, resp)
 }
 
+// AlgorithmsHandler 列出可用的深度估计算法
+func AlgorithmsHandler(c *gin.Context) {
+	estimators := depth.Estimators()
+	list := make([]gin.H, 0, len(estimators))
+	for _, e := range estimators {
+		list = append(list, gin.H{
+			"name":        e.Name(),
+			"description": e.Description(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"algorithms": list,
+		"default":    depth.DefaultEstimator,
+	})
+}
+
 func QueueStatusHandler(c *gin.Context) {
 	var (
 		total      int
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"image"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -10,7 +11,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/chaos-io/depth2STL/depth"
+	"github.com/chaos-io/depth2STL/stl"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/font/gofont/goregular"
 )
 
 func TestCreateHandlerReadsJobOptionsFromRequest(t *testing.T) {
@@ -36,12 +41,26 @@ func TestCreateHandlerReadsJobOptionsFromRequest(t *testing.T) {
 	}
 
 	fields := map[string]string{
-		"modelWidth":     "66.5",
-		"modelThickness": "7.2",
-		"baseThickness":  "2.8",
-		"skipConv":       "true",
-		"invert":         "true",
-		"detailLevel":    "3",
+		"modelWidth":      "66.5",
+		"modelThickness":  "7.2",
+		"baseThickness":   "2.8",
+		"skipConv":        "true",
+		"invert":          "true",
+		"detailLevel":     "3",
+		"format":          "3MF",
+		"maxError":        "0.02",
+		"targetTriangles": "100000",
+		"compression":     "0.6",
+		"baseSize":        "480",
+		"gamma":           "0.9",
+		"heightGamma":     "1",
+		"smoothing":       "Guided",
+		"claheLimit":      "3",
+		"lineMethod":      "Canny",
+		"lineEngrave":     "true",
+		"detailGains":     "1.5, 1.2",
+		"palette":         "#ff0000, 00ff00",
+		"paletteHeights":  "0.2,1",
 	}
 
 	for key, value := range fields {
@@ -100,9 +119,715 @@ func TestCreateHandlerReadsJobOptionsFromRequest(t *testing.T) {
 	if job.DetailLevel != 3 {
 		t.Fatalf("unexpected detailLevel: %d", job.DetailLevel)
 	}
+	if job.MaxError != 0.02 {
+		t.Fatalf("unexpected maxError: %v", job.MaxError)
+	}
+	if job.TargetTriangles != 100000 {
+		t.Fatalf("unexpected targetTriangles: %d", job.TargetTriangles)
+	}
+	if job.DepthAlgorithm != depth.EstimatorGray {
+		t.Fatalf("unexpected depthAlgorithm: %s", job.DepthAlgorithm)
+	}
+	if job.DepthOptions.BaseSize != 480 || job.DepthOptions.Gamma != 0.9 || job.DepthOptions.HeightGamma != 1 {
+		t.Fatalf("unexpected depth options: %+v", job.DepthOptions)
+	}
+	if job.DepthOptions.Smoothing != depth.SmoothingGuided {
+		t.Fatalf("unexpected smoothing: %s", job.DepthOptions.Smoothing)
+	}
+	if job.DepthOptions.ClaheLimit != 3 || len(job.DepthOptions.DetailGains) != 2 || job.DepthOptions.DetailGains[1] != 1.2 {
+		t.Fatalf("unexpected enhancement options: %+v", job.DepthOptions)
+	}
+	if job.DepthOptions.LineMethod != depth.LineCanny || !job.DepthOptions.LineEngrave {
+		t.Fatalf("unexpected line options: %+v", job.DepthOptions)
+	}
+	if len(job.DepthOptions.Palette) != 2 || job.DepthOptions.Palette[1] != "00ff00" || job.DepthOptions.PaletteHeights[0] != 0.2 {
+		t.Fatalf("unexpected palette options: %+v", job.DepthOptions)
+	}
+	if !job.DepthOptions.Invert || job.DepthOptions.Levels != depth.DefaultOptions().Levels {
+		t.Fatalf("expected defaults for unset depth options: %+v", job.DepthOptions)
+	}
+	if job.Compression != 0.6 {
+		t.Fatalf("unexpected compression: %v", job.Compression)
+	}
+	if job.Format != Format3MF {
+		t.Fatalf("unexpected format: %s", job.Format)
+	}
+
+	jobStore.Delete(resp.JobID)
+	if err = os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+		t.Fatalf("cleanup temp dir: %v", err)
+	}
+}
+
+func TestCreateHandlerAcceptsModelUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	fileWriter, err := writer.CreateFormFile("file", "bust.STL")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	mesh := &stl.Mesh{
+		Vertices:  [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		Triangles: [][3]uint32{{0, 2, 1}, {0, 1, 3}, {0, 3, 2}, {1, 2, 3}},
+	}
+	if err = (stl.ASCIIEncoder{}).Encode(fileWriter, mesh); err != nil {
+		t.Fatalf("write model: %v", err)
+	}
+	if err = writer.WriteField("view", "Top"); err != nil {
+		t.Fatalf("write field view: %v", err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/relief", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	CreateHandler(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		JobID string `json:"jobId"`
+	}
+	if err = json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+
+	val, ok := jobStore.Load(resp.JobID)
+	if !ok {
+		t.Fatalf("job %s not found in store", resp.JobID)
+	}
+
+	job := val.(*Job)
+	if job.Input != InputModel {
+		t.Fatalf("unexpected input: %s", job.Input)
+	}
+	if job.View != stl.ViewTop {
+		t.Fatalf("unexpected view: %s", job.View)
+	}
+	if filepath.Ext(job.ImagePath) != ".png" {
+		t.Fatalf("unexpected depth image path: %s", job.ImagePath)
+	}
 
 	jobStore.Delete(resp.JobID)
 	if err = os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
 		t.Fatalf("cleanup temp dir: %v", err)
 	}
 }
+
+func TestCreateHandlerAcceptsNormalUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fileContent, err := os.ReadFile(filepath.Join("..", "testdata", "my_image1.png"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for field, name := range map[string]string{"file": "normal.png", "color": "albedo.png"} {
+		fileWriter, err := writer.CreateFormFile(field, name)
+		if err != nil {
+			t.Fatalf("create form file %s: %v", field, err)
+		}
+		if _, err = fileWriter.Write(fileContent); err != nil {
+			t.Fatalf("write form file %s: %v", field, err)
+		}
+	}
+	for key, value := range map[string]string{"input": "normal", "normalFlipY": "true", "normalDetail": "0.5"} {
+		if err = writer.WriteField(key, value); err != nil {
+			t.Fatalf("write field %s: %v", key, err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/relief", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	CreateHandler(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		JobID string `json:"jobId"`
+	}
+	if err = json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+
+	val, ok := jobStore.Load(resp.JobID)
+	if !ok {
+		t.Fatalf("job %s not found in store", resp.JobID)
+	}
+
+	job := val.(*Job)
+	if job.Input != InputNormal || !job.DepthOptions.NormalFlipY || job.DepthOptions.NormalDetail != 0.5 {
+		t.Fatalf("unexpected job: input %s, options %+v", job.Input, job.DepthOptions)
+	}
+	if _, err = os.Stat(job.ColorPath); err != nil {
+		t.Fatalf("color image not saved: %v", err)
+	}
+
+	jobStore.Delete(resp.JobID)
+	if err = os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+		t.Fatalf("cleanup temp dir: %v", err)
+	}
+}
+
+// uploadPhotos 以 file 字段上传 photos 张相同的图片
+func uploadPhotos(t *testing.T, photos int, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	fileContent, err := os.ReadFile(filepath.Join("..", "testdata", "my_image1.png"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for i := 0; i < photos; i++ {
+		fileWriter, err := writer.CreateFormFile("file", "photo.png")
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		if _, err = fileWriter.Write(fileContent); err != nil {
+			t.Fatalf("write form file: %v", err)
+		}
+	}
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			t.Fatalf("write field %s: %v", key, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/relief", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	CreateHandler(c)
+	return w
+}
+
+func TestCreateHandlerAcceptsPhotometricUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upload := func(photos int, lights string) *httptest.ResponseRecorder {
+		return uploadPhotos(t, photos, map[string]string{"input": "photometric", "lights": lights, "lightElevation": "30"})
+	}
+
+	for _, bad := range []struct {
+		photos int
+		lights string
+	}{
+		{2, ""},
+		{3, "1,0,1;0,1,1"},
+		{3, "1,0,1;0,1,1;0,0,x"},
+		{3, "1,0,1;0,1,1;0,0,0"},
+	} {
+		if w := upload(bad.photos, bad.lights); w.Code != http.StatusBadRequest {
+			t.Fatalf("%+v: unexpected status code %d, body: %s", bad, w.Code, w.Body.String())
+		}
+	}
+
+	w := upload(3, "1,0,1; 0,1,1; -1,0,1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		JobID string `json:"jobId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+
+	val, ok := jobStore.Load(resp.JobID)
+	if !ok {
+		t.Fatalf("job %s not found in store", resp.JobID)
+	}
+
+	job := val.(*Job)
+	if job.Input != InputPhotometric || len(job.PhotoPaths) != 3 || job.FilePath != job.PhotoPaths[0] || job.DepthOptions.LightElevation != 30 {
+		t.Fatalf("unexpected job: input %s, photos %v", job.Input, job.PhotoPaths)
+	}
+	for _, path := range job.PhotoPaths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("photo not saved: %v", err)
+		}
+	}
+
+	jobStore.Delete(resp.JobID)
+	if err := os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+		t.Fatalf("cleanup temp dir: %v", err)
+	}
+}
+
+func TestCreateHandlerAcceptsStereoUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	if w := uploadPhotos(t, 3, map[string]string{"input": "stereo"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("three images: unexpected status code %d, body: %s", w.Code, w.Body.String())
+	}
+
+	w := uploadPhotos(t, 1, map[string]string{"input": "stereo", "maxDisparity": "32", "stereoSwap": "true"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		JobID string `json:"jobId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+
+	val, ok := jobStore.Load(resp.JobID)
+	if !ok {
+		t.Fatalf("job %s not found in store", resp.JobID)
+	}
+
+	job := val.(*Job)
+	if job.Input != InputStereo || len(job.PhotoPaths) != 1 {
+		t.Fatalf("unexpected job: input %s, images %v", job.Input, job.PhotoPaths)
+	}
+	if job.DepthOptions.MaxDisparity != 32 || !job.DepthOptions.StereoSwap {
+		t.Fatalf("unexpected stereo options: %+v", job.DepthOptions)
+	}
+
+	jobStore.Delete(resp.JobID)
+	if err := os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+		t.Fatalf("cleanup temp dir: %v", err)
+	}
+}
+
+// uploadFile 以 file 字段上传一个文件
+func uploadFile(t *testing.T, name string, content []byte, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fileWriter, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	if _, err = fileWriter.Write(content); err != nil {
+		t.Fatalf("write form file: %v", err)
+	}
+	for key, value := range fields {
+		if err = writer.WriteField(key, value); err != nil {
+			t.Fatalf("write field %s: %v", key, err)
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/relief", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	CreateHandler(c)
+	return w
+}
+
+func TestCreateHandlerAcceptsDEMUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	asc := []byte("ncols 2\nnrows 2\nxllcorner 0\nyllcorner 0\ncellsize 30\n1 2\n3 4\n")
+	raw := make([]byte, 16)
+	for _, bad := range []struct {
+		name   string
+		fields map[string]string
+	}{
+		{"grid.raw", nil},
+		{"grid.asc", map[string]string{"exaggeration": "0"}},
+		{"grid.asc", map[string]string{"minElevation": "sea"}},
+		{"grid.txt", map[string]string{"input": "dem"}},
+	} {
+		if w := uploadFile(t, bad.name, asc, bad.fields); w.Code != http.StatusBadRequest {
+			t.Fatalf("%+v: unexpected status code %d, body: %s", bad, w.Code, w.Body.String())
+		}
+	}
+
+	for _, upload := range []struct {
+		name    string
+		content []byte
+		fields  map[string]string
+	}{
+		{"N37W122.ASC", asc, map[string]string{"exaggeration": "2.5", "minElevation": "0"}},
+		{"grid.raw", raw, map[string]string{"rawWidth": "2", "rawBigEndian": "true"}},
+	} {
+		w := uploadFile(t, upload.name, upload.content, upload.fields)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status code %d, body: %s", upload.name, w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			JobID string `json:"jobId"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal response: %v", err)
+		}
+
+		val, ok := jobStore.Load(resp.JobID)
+		if !ok {
+			t.Fatalf("job %s not found in store", resp.JobID)
+		}
+
+		job := val.(*Job)
+		if job.Input != InputDEM || filepath.Ext(job.ImagePath) != ".png" {
+			t.Fatalf("unexpected job: input %s, image %s", job.Input, job.ImagePath)
+		}
+		if upload.fields["exaggeration"] != "" && (job.DEMOptions.Exaggeration != 2.5 || !job.DEMOptions.ClampMin) {
+			t.Fatalf("unexpected dem options: %+v", job.DEMOptions)
+		}
+		if upload.fields["rawWidth"] != "" && (job.DEMOptions.RawWidth != 2 || !job.DEMOptions.RawBigEndian) {
+			t.Fatalf("unexpected dem options: %+v", job.DEMOptions)
+		}
+
+		jobStore.Delete(resp.JobID)
+		if err := os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+			t.Fatalf("cleanup temp dir: %v", err)
+		}
+	}
+}
+
+func TestCreateHandlerAcceptsSVGUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logo := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10"><rect width="20" height="10" fill="#d52b1e"/></svg>`)
+	if w := uploadFile(t, "logo.png", logo, map[string]string{"input": "svg"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status code %d for svg input with png name", w.Code)
+	}
+
+	w := uploadFile(t, "Logo.SVG", logo, map[string]string{"palette": "#d52b1e", "paletteHeights": "0.5"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		JobID string `json:"jobId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+
+	val, ok := jobStore.Load(resp.JobID)
+	if !ok {
+		t.Fatalf("job %s not found in store", resp.JobID)
+	}
+
+	job := val.(*Job)
+	if job.Input != InputSVG || job.Name != "Logo" || filepath.Ext(job.ImagePath) != ".png" {
+		t.Fatalf("unexpected job: input %s, name %q, image %s", job.Input, job.Name, job.ImagePath)
+	}
+	if len(job.DepthOptions.PaletteHeights) != 1 || job.DepthOptions.PaletteHeights[0] != 0.5 {
+		t.Fatalf("unexpected palette heights: %v", job.DepthOptions.PaletteHeights)
+	}
+
+	jobStore.Delete(resp.JobID)
+	if err := os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+		t.Fatalf("cleanup temp dir: %v", err)
+	}
+}
+
+func TestCreateHandlerSniffsImageType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 扩展名是 png，内容不是图片
+	if w := uploadFile(t, "photo.png", []byte("not an image"), nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status code %d for fake png", w.Code)
+	}
+
+	var scan bytes.Buffer
+	if err := bmp.Encode(&scan, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("encode bmp: %v", err)
+	}
+	for _, name := range []string{"scan.bmp", "scan", "scan.jpg"} {
+		w := uploadFile(t, name, scan.Bytes(), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status code %d, body: %s", name, w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			JobID string `json:"jobId"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal response: %v", err)
+		}
+
+		val, ok := jobStore.Load(resp.JobID)
+		if !ok {
+			t.Fatalf("job %s not found in store", resp.JobID)
+		}
+
+		job := val.(*Job)
+		if job.Input != InputImage || filepath.Ext(job.ImagePath) != ".png" {
+			t.Fatalf("unexpected job: input %s, image %s", job.Input, job.ImagePath)
+		}
+		// 未指定 depthAlgorithm 时与原先一样使用 8 位 detail
+		if job.DepthAlgorithm != depth.EstimatorDetail {
+			t.Fatalf("unexpected default depthAlgorithm: %s", job.DepthAlgorithm)
+		}
+
+		jobStore.Delete(resp.JobID)
+		if err := os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+			t.Fatalf("cleanup temp dir: %v", err)
+		}
+	}
+}
+
+// postForm 只提交表单字段，不上传文件
+func postForm(t *testing.T, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			t.Fatalf("write field %s: %v", key, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/relief", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	CreateHandler(c)
+	return w
+}
+
+func TestCreateHandlerAcceptsTextInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, bad := range []map[string]string{
+		{"input": "text"},
+		{"input": "text", "text": "Hi", "font": "comic"},
+		{"input": "text", "text": "Hi", "align": "justify"},
+		{"input": "text", "text": "Hi", "bevel": "-1"},
+	} {
+		if w := postForm(t, bad); w.Code != http.StatusBadRequest {
+			t.Fatalf("%v: unexpected status code %d, body: %s", bad, w.Code, w.Body.String())
+		}
+	}
+	if w := uploadFile(t, "font.woff", []byte("wOFF"), map[string]string{"input": "text", "text": "Hi"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("woff font: unexpected status code %d, body: %s", w.Code, w.Body.String())
+	}
+
+	for _, upload := range []struct {
+		font   []byte
+		fields map[string]string
+	}{
+		{nil, map[string]string{"input": "text", "text": "Hello\nWorld", "font": "Bold", "fontSize": "64", "bevel": "4", "align": "Left", "invert": "true"}},
+		{goregular.TTF, map[string]string{"input": "text", "text": "Hello", "lineSpacing": "1.5", "engrave": "false"}},
+	} {
+		var w *httptest.ResponseRecorder
+		if upload.font != nil {
+			w = uploadFile(t, "MyFont.TTF", upload.font, upload.fields)
+		} else {
+			w = postForm(t, upload.fields)
+		}
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code %d, body: %s", w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			JobID string `json:"jobId"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal response: %v", err)
+		}
+
+		val, ok := jobStore.Load(resp.JobID)
+		if !ok {
+			t.Fatalf("job %s not found in store", resp.JobID)
+		}
+
+		job := val.(*Job)
+		if job.Input != InputText || job.Name != "Hello" || job.Text != upload.fields["text"] {
+			t.Fatalf("unexpected job: input %s, name %q, text %q", job.Input, job.Name, job.Text)
+		}
+		if upload.font == nil {
+			opts := job.TextOptions
+			if opts.Font != "bold" || opts.Size != 64 || opts.Bevel != 4 || opts.Align != depth.AlignLeft || !opts.Engrave || job.FontPath != "" {
+				t.Fatalf("unexpected text options: %+v, font %q", opts, job.FontPath)
+			}
+		} else {
+			if job.TextOptions.LineSpacing != 1.5 || job.TextOptions.Engrave {
+				t.Fatalf("unexpected text options: %+v", job.TextOptions)
+			}
+			if _, err := os.Stat(job.FontPath); err != nil {
+				t.Fatalf("font not saved: %v", err)
+			}
+		}
+
+		jobStore.Delete(resp.JobID)
+		if err := os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+			t.Fatalf("cleanup temp dir: %v", err)
+		}
+	}
+}
+
+func TestCreateHandlerAcceptsCodeInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, bad := range []map[string]string{
+		{"input": "qr"},
+		{"input": "qr", "text": "hi", "errorCorrection": "X"},
+		{"input": "qr", "text": "hi", "moduleSize": "0"},
+		{"input": "qr", "text": "hi", "quietZone": "-1"},
+		{"input": "code128", "text": "中文"},
+	} {
+		if w := postForm(t, bad); w.Code != http.StatusBadRequest {
+			t.Fatalf("%v: unexpected status code %d, body: %s", bad, w.Code, w.Body.String())
+		}
+	}
+	if w := uploadFile(t, "tag.png", []byte("\x89PNG\r\n\x1a\n"), map[string]string{"input": "qr", "text": "hi"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("qr with file: unexpected status code %d", w.Code)
+	}
+
+	for _, fields := range []map[string]string{
+		{"input": "qr", "text": "https://example.com/tag/42", "errorCorrection": "h", "moduleSize": "1.5", "moduleHeight": "0.8", "quietZone": "2"},
+		{"input": "code128", "text": "SKU-0042", "barHeight": "12"},
+	} {
+		w := postForm(t, fields)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status code %d, body: %s", w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			JobID string `json:"jobId"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal response: %v", err)
+		}
+
+		val, ok := jobStore.Load(resp.JobID)
+		if !ok {
+			t.Fatalf("job %s not found in store", resp.JobID)
+		}
+
+		job := val.(*Job)
+		opts := job.CodeOptions
+		if job.Input != fields["input"] || job.Text != fields["text"] || opts.Symbology != job.Input {
+			t.Fatalf("unexpected job: input %s, text %q, options %+v", job.Input, job.Text, opts)
+		}
+		if job.Input == InputQR && (opts.Level != "H" || opts.ModuleSize != 1.5 || opts.Height != 0.8 || opts.QuietZone != 2) {
+			t.Fatalf("unexpected qr options: %+v", opts)
+		}
+		if job.Input == InputCode128 && (opts.BarHeight != 12 || opts.QuietZone != 10) {
+			t.Fatalf("unexpected code128 options: %+v", opts)
+		}
+
+		jobStore.Delete(resp.JobID)
+		if err := os.RemoveAll(filepath.Dir(job.FilePath)); err != nil {
+			t.Fatalf("cleanup temp dir: %v", err)
+		}
+	}
+}
+
+func TestCreateHandlerRejectsInvalidDepthOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []map[string]string{
+		{"skipConv": "true", "depthAlgorithm": depth.EstimatorDetail},
+		{"depthAlgorithm": "nope"},
+		{"baseSize": "abc"},
+		{"gamma": "0"},
+		{"lowPercentile": "60", "highPercentile": "55"},
+		{"autoTune": "true", "gamma": "0.8"},
+		{"detailGains": "1.5,x"},
+		{"claheLimit": "0.5"},
+		{"lineMethod": "sobel"},
+		{"palette": "#ff0000,#00ff00", "paletteHeights": "0.5"},
+		{"palette": "#ff00"},
+		{"baseThickness": "-1"},
+	}
+	for _, fields := range cases {
+		if w := postForm(t, fields); w.Code != http.StatusBadRequest {
+			t.Fatalf("%v: unexpected status code %d, body: %s", fields, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetJobHandlerReturnsDownloadURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, format := range []string{FormatSTL, Format3MF} {
+		job := &Job{ID: "download-" + format, Format: format, Input: InputImage, Status: StatusDone}
+		jobStore.Store(job.ID, job)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v1/relief/"+job.ID, nil)
+		c.Params = gin.Params{{Key: "jobId", Value: job.ID}}
+		GetJobHandler(c)
+		jobStore.Delete(job.ID)
+
+		var resp struct {
+			DownloadURL string `json:"downloadUrl"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal response: %v", err)
+		}
+		if want := "/v1/relief/download/" + format + "/" + job.ID; resp.DownloadURL != want {
+			t.Fatalf("downloadUrl %q, want %q", resp.DownloadURL, want)
+		}
+	}
+}
+
+func TestAlgorithmsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/relief/algorithms", nil)
+
+	AlgorithmsHandler(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code %d", w.Code)
+	}
+
+	var resp struct {
+		Algorithms []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		} `json:"algorithms"`
+		Default string `json:"default"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.Default != depth.DefaultEstimator {
+		t.Fatalf("unexpected default: %s", resp.Default)
+	}
+	if len(resp.Algorithms) != len(depth.Estimators()) {
+		t.Fatalf("unexpected algorithm count %d", len(resp.Algorithms))
+	}
+}
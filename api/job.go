@@ -6,6 +6,11 @@ import (
 	"path"
 	"sync"
 	"time"
+
+	"github.com/chaos-io/depth2STL/barcode"
+	"github.com/chaos-io/depth2STL/dem"
+	"github.com/chaos-io/depth2STL/depth"
+	"github.com/chaos-io/depth2STL/stl"
 )
 
 var (
@@ -25,23 +30,65 @@ const (
 	StatusFailed     JobStatus = "failed"
 )
 
+// 任务输入类型
+const (
+	InputImage  = "image"  // 图片，估计深度图
+	InputModel  = "model"  // STL/OBJ 模型，正交渲染高度图
+	InputNormal = "normal" // 切线空间法线贴图，积分得到高度
+
+	InputPhotometric = "photometric" // 同一物体不同光照下的多张照片，光度立体求法线后积分
+	InputStereo      = "stereo"      // 左右两张图或一张左右并排图，立体匹配视差
+	InputDEM         = "dem"         // 数字高程模型（.asc/.hgt/裸 float32），按真实比例建地形
+	InputText        = "text"        // 文字排版为铭牌、标牌，file 为可选的 TTF/OTF 字体
+	InputSVG         = "svg"         // SVG 矢量图，按网格分辨率光栅化，每种填充色一个高度
+	InputQR          = "qr"          // 文字编码为二维码，深色模块凸起，侧壁竖直
+	InputCode128     = "code128"     // 文字编码为 Code 128 条码，深色条凸起，侧壁竖直
+)
+
+// 模型输出格式
+const (
+	FormatSTL = "stl"
+	Format3MF = "3mf"
+)
+
 type Job struct {
-	ID             string
-	Name           string
-	FilePath       string
-	ImagePath      string
-	StlPath        string
-	ModelWidth     float64 // 模型宽度（毫米，默认：50.0）
-	ModelThickness float64 // 模型最大高度（毫米，默认：5.0）
-	BaseThickness  float64 // 底座高度（毫米，默认：2.0）
-	SkipConv       bool    // 跳过深度图处理（默认：false）
-	Invert         bool    // 反转浮雕（默认：false）
-	DetailLevel    int     // 精度 1:普通 2:推荐（质量高4倍） 3:高精度
-	PreProcess     string  // 图片预处理（比如使用 BiRefNet）
-	Status         JobStatus
-	Error          string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	ID              string
+	Name            string
+	FilePath        string
+	ColorPath       string       // 法线贴图输入时可选的彩色图，用于补充细节
+	PhotoPaths      []string     // 多图输入（光度立体的全部照片、立体像对的左右图），FilePath 为第一张
+	Lights          [][3]float64 // 光度立体的光源方向（x 向右、y 向上、z 朝向相机），未给出时处理后写回估计值
+	ImagePath       string
+	StlPath         string
+	ThreeMFPath     string
+	ModelWidth      float64           // 模型宽度（毫米，默认：50.0），二维码、条码输入处理后写回模块数 × 模块边长
+	ModelThickness  float64           // 模型最大高度（毫米，默认：5.0）
+	BaseThickness   float64           // 底座高度（毫米，默认：2.0）
+	SkipConv        bool              // 跳过深度图处理，等同于 DepthAlgorithm=gray（默认：false）
+	Invert          bool              // 反转浮雕（默认：false）
+	DetailLevel     int               // 精度 1:普通 2:推荐（质量高4倍） 3:高精度
+	MaxError        float64           // 自适应三角化的最大垂直误差（毫米，默认：0 不启用）
+	TargetTriangles int               // 简化后的目标三角形数（默认：0 不简化）
+	Compression     float64           // 梯度域浅浮雕压缩强度 0~1（默认：0 不启用）
+	PreProcess      string            // 图片预处理（比如使用 BiRefNet）
+	DepthAlgorithm  string            // 深度估计算法名称，见 depth.Estimators（默认：depth.DefaultEstimator）
+	DepthOptions    depth.Options     // 深度估计与高度映射参数（默认：depth.DefaultOptions）
+	AutoTune        bool              // 按图片统计自动选择深度参数，结果写回 DepthOptions（默认：false）
+	ImageStats      *depth.ImageStats // 自动调参时的图片统计
+	DEMOptions      dem.Options       // 地形输入的垂直夸张、最低高程裁剪和裸栅格格式（默认：dem.DefaultOptions）
+	Terrain         *dem.Info         // 地形输入的尺寸、高程范围和比例尺
+	Text            string            // 文字输入的内容，可多行；二维码、条码输入为编码的内容
+	TextOptions     depth.TextOptions // 文字输入的字体、字号、行距、对齐、斜面和凸起/凹刻（默认：depth.DefaultTextOptions）
+	FontPath        string            // 文字输入上传的字体文件，为空时使用 TextOptions.Font
+	CodeOptions     barcode.Options   // 二维码、条码输入的纠错等级、模块尺寸、静区、条高和凸起高度（默认：barcode.DefaultOptions）
+	Input           string            // 输入类型 image/model/normal/photometric/stereo/dem/text/svg/qr/code128（默认：按文件扩展名判断）
+	View            string            // 模型输入的观察方向 front/back/left/right/top/bottom（默认：front）
+	Format          string            // 模型输出格式 stl/3mf（默认：stl）
+	Status          JobStatus
+	Error           string
+	Validation      *stl.ValidationReport // 网格检查结果（开放边、非流形边、法线翻转、退化三角形、自相交）
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 func ClearJobs() {
@@ -1,20 +1,24 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"image"
-	_ "image/gif"
-	_ "image/jpeg"
 	"image/png"
-	_ "image/png"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/chaos-io/depth2STL/barcode"
+	"github.com/chaos-io/depth2STL/dem"
 	"github.com/chaos-io/depth2STL/depth"
 	"github.com/chaos-io/depth2STL/depth/rembg"
 	"github.com/chaos-io/depth2STL/stl"
+	"github.com/chaos-io/depth2STL/svg"
+	"github.com/chaos-io/depth2STL/util"
 )
 
 func init() {
@@ -50,19 +54,158 @@ func workerLoop() {
 
 func processJob(job *Job) error {
 	fmt.Printf("processing jobId:%s\n", job.ID)
-	// 读取图片
-	f, err := os.Open(job.FilePath)
+
+	mesh, err := buildMesh(job)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
 
-	img, _, err := image.Decode(f)
-	if err != nil {
+	if err = decimateMesh(job, mesh); err != nil {
+		return err
+	}
+
+	// 检查网格是否闭合、流形且朝向一致，有问题的模型不输出
+	job.Validation = stl.Validate(mesh)
+	if !job.Validation.OK() {
+		return fmt.Errorf("mesh validation failed: %s", job.Validation)
+	}
+
+	return writeModel(job, mesh)
+}
+
+// decimateMesh 简化到 job.TargetTriangles 以内。targetTriangles 是硬上限：剩余的折叠都会破坏网格而提前停止时，
+// 任务失败，不输出超出上限的模型
+func decimateMesh(job *Job, mesh *stl.Mesh) error {
+	if job.TargetTriangles <= 0 || mesh.TriangleCount() <= job.TargetTriangles {
+		return nil
+	}
+
+	before := mesh.TriangleCount()
+	if err := mesh.Decimate(job.TargetTriangles); err != nil {
 		return err
 	}
+	fmt.Printf("decimate mesh, triangles:%d -> %d\n", before, mesh.TriangleCount())
+	if n := mesh.TriangleCount(); n > job.TargetTriangles {
+		return fmt.Errorf("decimation stopped at %d triangles, above targetTriangles %d: no further collapse keeps the mesh valid", n, job.TargetTriangles)
+	}
+	return nil
+}
+
+// buildMesh 按输入类型得到高度图并生成网格，地形输入直接使用真实高度
+func buildMesh(job *Job) (*stl.Mesh, error) {
+	if job.Input == InputDEM {
+		return terrainMesh(job)
+	}
+	if isCodeInput(job.Input) {
+		return codeMesh(job)
+	}
+
+	var (
+		depthMap image.Image
+		err      error
+	)
+	switch job.Input {
+	case InputModel:
+		depthMap, err = renderModelDepth(job)
+	case InputNormal:
+		depthMap, err = normalDepth(job)
+	case InputPhotometric:
+		depthMap, err = photometricDepth(job)
+	case InputStereo:
+		depthMap, err = stereoDepth(job)
+	case InputText:
+		depthMap, err = textDepth(job)
+	case InputSVG:
+		depthMap, err = svgDepth(job)
+	default:
+		depthMap, err = imageDepth(job)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 生成模型
+	if linearHeights(job) {
+		job.DepthOptions.HeightGamma = 1
+	}
+	return stl.BuildReliefMesh(depthMap, stl.ReliefOptions{
+		ModelWidth:     job.ModelWidth,
+		ModelThickness: job.ModelThickness,
+		BaseThickness:  job.BaseThickness,
+		DetailLevel:    job.DetailLevel,
+		MaxError:       job.MaxError,
+		Compression:    job.Compression,
+		HeightGamma:    job.DepthOptions.HeightGamma,
+	})
+}
+
+// linearHeights 深度值本身就是目标高度（0~1）的输入，不能再经过 heightGamma 映射：
+// palette 算法的 paletteHeights、lineart 算法的 lineDepth、SVG 输入每种填充色的高度、文字输入的斜面。
+// 此时 heightGamma 固定为 1，并通过 depthOptions 返回
+func linearHeights(job *Job) bool {
+	if job.Input == InputSVG || job.Input == InputText {
+		return true
+	}
+	return job.Input == InputImage &&
+		(job.DepthAlgorithm == depth.EstimatorPalette || job.DepthAlgorithm == depth.EstimatorLineArt)
+}
+
+// terrainMesh 读取 DEM，按真实比例换算高度后直接建网格（modelThickness 不生效）
+func terrainMesh(job *Job) (*stl.Mesh, error) {
+	grid, err := dem.ReadFile(job.FilePath, job.DEMOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	field, w, h, info, err := grid.HeightField(job.ModelWidth, job.DEMOptions)
+	if err != nil {
+		return nil, err
+	}
+	job.Terrain = &info
+	if err = writePNG(job.ImagePath, dem.Preview(field, w, h)); err != nil {
+		return nil, err
+	}
+	fmt.Printf("read dem, size:%dx%d, elevation:%.1f~%.1f, scale:1:%.0f\n", w, h, info.MinElevation, info.MaxElevation, info.Scale)
+
+	return stl.BuildHeightFieldMesh(field, w, h, stl.ReliefOptions{
+		ModelWidth:    job.ModelWidth,
+		BaseThickness: job.BaseThickness,
+		DetailLevel:   job.DetailLevel,
+		MaxError:      job.MaxError,
+	})
+}
+
+// codePreviewSize 二维码、条码预览图长边的目标像素数，每个模块至少 1 像素
+const codePreviewSize = 1024
+
+// codeMesh 把文字编码为二维码、条码，按模块边长直接拼方块建网格，模块侧壁竖直（modelWidth、modelThickness 不生效）
+func codeMesh(job *Job) (*stl.Mesh, error) {
+	matrix, err := barcode.Encode(job.Text, job.CodeOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	job.ModelWidth = float64(matrix.Width) * job.CodeOptions.ModuleSize
+	scale := max(1, codePreviewSize/max(matrix.Width, matrix.Height))
+	if err = writePNG(job.ImagePath, matrix.Preview(scale)); err != nil {
+		return nil, err
+	}
+	fmt.Printf("encode %s, modules:%dx%d, size:%.1fx%.1fmm, path:%s\n", job.Input, matrix.Width, matrix.Height,
+		job.ModelWidth, float64(matrix.Height)*job.CodeOptions.ModuleSize, job.ImagePath)
+
+	return stl.BuildModuleMesh(matrix.Dark, matrix.Width, matrix.Height, stl.ReliefOptions{
+		ModelWidth:     job.ModelWidth,
+		ModelThickness: job.CodeOptions.Height,
+		BaseThickness:  job.BaseThickness,
+	})
+}
+
+// imageDepth 读取图片并用任务指定的算法估计深度图
+func imageDepth(job *Job) (image.Image, error) {
+	img, err := decodeImageFile(job.FilePath)
+	if err != nil {
+		return nil, err
+	}
 
 	if job.PreProcess == rembg.BiRefNetModel {
 		// TODO: 多次读取file
@@ -70,35 +213,243 @@ func processJob(job *Job) error {
 		img, err = p.ImagePreprocess(img)
 		if err != nil {
 			slog.Error("failed to preprocess image", "error", err)
-			return err
+			return nil, err
+		}
+	}
+
+	if job.AutoTune {
+		var stats depth.ImageStats
+		job.DepthOptions, stats = depth.AutoTune(img, job.DepthOptions)
+		job.ImageStats = &stats
+		fmt.Printf("auto tune, options:%s\n", depthOptionsJSON(job.DepthOptions))
+	}
+
+	// 生成深度图（16 位上传图片在 skipConv 时保留全部精度）
+	estimator, ok := depth.Lookup(job.DepthAlgorithm)
+	if !ok {
+		return nil, fmt.Errorf("unknown depth algorithm %q", job.DepthAlgorithm)
+	}
+	depthMap, err := estimator.Estimate(img, &job.DepthOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err = writePNG(job.ImagePath, depthMap); err != nil {
+		return nil, err
+	}
+	fmt.Printf("gen img, algorithm:%s, path:%s\n", job.DepthAlgorithm, job.ImagePath)
+	return depthMap, nil
+}
+
+// normalDepth 读取法线贴图（及可选的彩色图），积分得到高度图
+func normalDepth(job *Job) (image.Image, error) {
+	normal, err := decodeImageFile(job.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var colour image.Image
+	if job.ColorPath != "" {
+		if colour, err = decodeImageFile(job.ColorPath); err != nil {
+			return nil, err
 		}
 	}
 
-	// 生成深度图
-	var gray *image.Gray
-	if job.SkipConv {
-		gray = depth.ConvertToGray(img)
+	depthMap, err := depth.IntegrateNormals(normal, colour, &job.DepthOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err = writePNG(job.ImagePath, depthMap); err != nil {
+		return nil, err
+	}
+	fmt.Printf("integrate normal map, color:%t, path:%s\n", colour != nil, job.ImagePath)
+	return depthMap, nil
+}
+
+// photometricDepth 读取多张不同光照的照片，光度立体重建高度图
+func photometricDepth(job *Job) (image.Image, error) {
+	photos := make([]image.Image, 0, len(job.PhotoPaths))
+	for _, path := range job.PhotoPaths {
+		img, err := decodeImageFile(path)
+		if err != nil {
+			return nil, err
+		}
+		photos = append(photos, img)
+	}
+
+	depthMap, lights, err := depth.PhotometricStereo(photos, job.Lights, &job.DepthOptions)
+	if err != nil {
+		return nil, err
+	}
+	job.Lights = lights
+	if err = writePNG(job.ImagePath, depthMap); err != nil {
+		return nil, err
+	}
+	fmt.Printf("photometric stereo, photos:%d, path:%s\n", len(photos), job.ImagePath)
+	return depthMap, nil
+}
+
+// stereoDepth 读取左右图（或一张左右并排图），立体匹配得到高度图
+func stereoDepth(job *Job) (image.Image, error) {
+	left, err := decodeImageFile(job.PhotoPaths[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var right image.Image
+	if len(job.PhotoPaths) > 1 {
+		if right, err = decodeImageFile(job.PhotoPaths[1]); err != nil {
+			return nil, err
+		}
 	} else {
-		gray = depth.GenerateDepthMap4(img, job.Invert)
+		left, right = depth.SplitSideBySide(left)
 	}
-	f, err = os.Create(job.ImagePath)
+
+	depthMap, err := depth.StereoDepth(left, right, &job.DepthOptions)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err = writePNG(job.ImagePath, depthMap); err != nil {
+		return nil, err
+	}
+	fmt.Printf("stereo matching, images:%d, path:%s\n", len(job.PhotoPaths), job.ImagePath)
+	return depthMap, nil
+}
+
+// textDepth 用任务指定（或上传）的字体把文字排版为高度图
+func textDepth(job *Job) (image.Image, error) {
+	var fontData []byte
+	if job.FontPath != "" {
+		var err error
+		if fontData, err = os.ReadFile(job.FontPath); err != nil {
+			return nil, err
+		}
 	}
-	defer f.Close()
 
-	err = png.Encode(f, gray)
+	depthMap, err := depth.RenderText(job.Text, fontData, job.TextOptions)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err = writePNG(job.ImagePath, depthMap); err != nil {
+		return nil, err
+	}
+	fmt.Printf("render text, lines:%d, path:%s\n", strings.Count(strings.TrimRight(job.Text, "\n"), "\n")+1, job.ImagePath)
+	return depthMap, nil
+}
+
+// svgDepth 读取 SVG，按 detailLevel 对应的网格分辨率光栅化，每种填充色对应一个高度
+func svgDepth(job *Job) (image.Image, error) {
+	f, err := os.Open(job.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	doc, err := svg.Parse(f)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Printf("gen img, path:%s\n", job.ImagePath)
 
-	// 生成 STL
-	err = stl.GenerateSTL5(gray, job.StlPath, job.ModelWidth, job.ModelThickness, job.BaseThickness, job.DetailLevel)
+	w, h := stl.GridResolution(doc.Aspect(), job.DetailLevel)
+	depthMap, err := depth.RenderSVG(doc, w, h, &job.DepthOptions)
 	if err != nil {
+		return nil, err
+	}
+	if err = writePNG(job.ImagePath, depthMap); err != nil {
+		return nil, err
+	}
+	fmt.Printf("render svg, shapes:%d, size:%dx%d, palette:%v, path:%s\n", len(doc.Shapes), w, h, job.DepthOptions.Palette, job.ImagePath)
+	return depthMap, nil
+}
+
+// renderModelDepth 读取 STL/OBJ 模型，从指定方向正交渲染高度图
+func renderModelDepth(job *Job) (*image.Gray16, error) {
+	model, err := stl.ReadModelFile(job.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	height, err := stl.RenderHeightMap(model, stl.RenderOptions{View: job.View})
+	if err != nil {
+		return nil, err
+	}
+	if err = writePNG(job.ImagePath, height); err != nil {
+		return nil, err
+	}
+	fmt.Printf("render model, view:%s, triangles:%d, path:%s\n", job.View, model.TriangleCount(), job.ImagePath)
+
+	return height, nil
+}
+
+func decodeImageFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	// 按 EXIF 方向摆正后再做抠图等预处理
+	img, _, err := util.DecodeImage(f)
+	return img, err
+}
+
+func depthOptionsJSON(opts depth.Options) string {
+	data, _ := json.Marshal(opts)
+	return string(data)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, img); err != nil {
+		_ = f.Close()
 		return err
 	}
-	fmt.Printf("gen stl, path:%s\n", job.StlPath)
+	return f.Close()
+}
 
+// writeModel 按任务格式输出模型文件
+func writeModel(job *Job, mesh *stl.Mesh) error {
+	if job.Format == Format3MF {
+		err := stl.WriteFile(job.ThreeMFPath, mesh, stl.ThreeMFEncoder{Metadata: jobMetadata(job)})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("gen 3mf, path:%s, triangles:%d\n", job.ThreeMFPath, mesh.TriangleCount())
+		return nil
+	}
+
+	err := stl.WriteFile(job.StlPath, mesh, stl.BinaryEncoder{Header: "Relief STL Binary (depth2STL)"})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("gen stl, path:%s, triangles:%d\n", job.StlPath, mesh.TriangleCount())
 	return nil
 }
+
+// jobMetadata 写入 3MF 的任务名称与参数
+func jobMetadata(job *Job) map[string]string {
+	return map[string]string{
+		"Title":           job.Name,
+		"Application":     "depth2STL",
+		"jobId":           job.ID,
+		"input":           job.Input,
+		"modelWidth":      strconv.FormatFloat(job.ModelWidth, 'f', -1, 64),
+		"modelThickness":  strconv.FormatFloat(job.ModelThickness, 'f', -1, 64),
+		"baseThickness":   strconv.FormatFloat(job.BaseThickness, 'f', -1, 64),
+		"skipConv":        strconv.FormatBool(job.SkipConv),
+		"invert":          strconv.FormatBool(job.Invert),
+		"detailLevel":     strconv.Itoa(job.DetailLevel),
+		"depthAlgorithm":  job.DepthAlgorithm,
+		"depthOptions":    depthOptionsJSON(job.DepthOptions),
+		"autoTune":        strconv.FormatBool(job.AutoTune),
+		"maxError":        strconv.FormatFloat(job.MaxError, 'f', -1, 64),
+		"targetTriangles": strconv.Itoa(job.TargetTriangles),
+		"compression":     strconv.FormatFloat(job.Compression, 'f', -1, 64),
+	}
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaos-io/depth2STL/depth"
+	"github.com/chaos-io/depth2STL/stl"
+)
+
+func writeTestPNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if err = png.Encode(f, img); err != nil {
+		t.Fatalf("encode %s: %v", path, err)
+	}
+}
+
+func TestBuildMeshPaletteHeightsAreLinear(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 120, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 120; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 60 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	writeTestPNG(t, filepath.Join(dir, "flag.png"), img)
+
+	opts := depth.DefaultOptions()
+	opts.Palette = []string{"#ff0000", "#0000ff"}
+	opts.PaletteHeights = []float64{0.6, 0.3}
+	job := &Job{
+		Input:          InputImage,
+		FilePath:       filepath.Join(dir, "flag.png"),
+		ImagePath:      filepath.Join(dir, "depth.png"),
+		ModelWidth:     30,
+		ModelThickness: 5,
+		BaseThickness:  1,
+		DetailLevel:    1,
+		DepthAlgorithm: depth.EstimatorPalette,
+		DepthOptions:   opts,
+	}
+
+	mesh, err := buildMesh(job)
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+	if _, top := mesh.Bounds(); math.Abs(float64(top[2])-0.6*5) > 1e-3 {
+		t.Fatalf("unexpected top z %v, want %v", top[2], 0.6*5)
+	}
+	if job.DepthOptions.HeightGamma != 1 {
+		t.Fatalf("expected effective heightGamma 1, got %v", job.DepthOptions.HeightGamma)
+	}
+}
+
+func TestBuildMeshLineDepthIsLinear(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 120, 80))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for y := 10; y < 70; y++ {
+		for x := 55; x < 65; x++ {
+			img.Pix[y*img.Stride+x] = 0
+		}
+	}
+	writeTestPNG(t, filepath.Join(dir, "line.png"), img)
+
+	opts := depth.DefaultOptions()
+	opts.LineDepth = 0.4
+	job := &Job{
+		Input:          InputImage,
+		FilePath:       filepath.Join(dir, "line.png"),
+		ImagePath:      filepath.Join(dir, "depth.png"),
+		ModelWidth:     30,
+		ModelThickness: 5,
+		BaseThickness:  1,
+		DetailLevel:    1,
+		DepthAlgorithm: depth.EstimatorLineArt,
+		DepthOptions:   opts,
+	}
+
+	mesh, err := buildMesh(job)
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+	if _, top := mesh.Bounds(); math.Abs(float64(top[2])-0.4*5) > 1e-3 {
+		t.Fatalf("unexpected line height %v, want %v", top[2], 0.4*5)
+	}
+}
+
+func TestBuildMeshSVGHeightsAreLinear(t *testing.T) {
+	dir := t.TempDir()
+	logo := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10"><rect x="2" y="2" width="16" height="6" fill="#d52b1e"/></svg>`
+	if err := os.WriteFile(filepath.Join(dir, "logo.svg"), []byte(logo), 0o644); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+
+	opts := depth.DefaultOptions()
+	opts.Palette = []string{"#d52b1e"}
+	opts.PaletteHeights = []float64{0.5}
+	job := &Job{
+		Input:          InputSVG,
+		FilePath:       filepath.Join(dir, "logo.svg"),
+		ImagePath:      filepath.Join(dir, "depth.png"),
+		ModelWidth:     40,
+		ModelThickness: 4,
+		BaseThickness:  1,
+		DetailLevel:    1,
+		DepthOptions:   opts,
+	}
+
+	mesh, err := buildMesh(job)
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+	if _, top := mesh.Bounds(); math.Abs(float64(top[2])-0.5*4) > 1e-3 {
+		t.Fatalf("unexpected top z %v, want %v", top[2], 0.5*4)
+	}
+	if job.DepthOptions.HeightGamma != 1 {
+		t.Fatalf("expected effective heightGamma 1, got %v", job.DepthOptions.HeightGamma)
+	}
+}
+
+func TestBuildMeshTextBevelIsLinear(t *testing.T) {
+	dir := t.TempDir()
+	textOpts := depth.DefaultTextOptions()
+	textOpts.Size = 40
+	textOpts.Bevel = 6
+	job := &Job{
+		Input:          InputText,
+		Text:           "IO",
+		TextOptions:    textOpts,
+		ImagePath:      filepath.Join(dir, "depth.png"),
+		ModelWidth:     30,
+		ModelThickness: 5,
+		BaseThickness:  1,
+		DetailLevel:    1,
+		DepthOptions:   depth.DefaultOptions(),
+	}
+
+	mesh, err := buildMesh(job)
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+	f, err := os.Open(job.ImagePath)
+	if err != nil {
+		t.Fatalf("open depth map: %v", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	depthMap, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decode depth map: %v", err)
+	}
+
+	// 网格步长为 1 像素时，顶面顶点与深度图像素一一对应，斜面高度应为深度值 × modelThickness
+	b := depthMap.Bounds()
+	if len(mesh.Vertices) < b.Dx()*b.Dy() {
+		t.Fatalf("expected one top vertex per pixel, got %d vertices for %v", len(mesh.Vertices), b)
+	}
+	bevel := 0
+	for i := 0; i < b.Dx()*b.Dy(); i++ {
+		v := float64(color.Gray16Model.Convert(depthMap.At(b.Min.X+i%b.Dx(), b.Min.Y+i/b.Dx())).(color.Gray16).Y) / 65535
+		if math.Abs(float64(mesh.Vertices[i][2])-v*5) > 1e-3 {
+			t.Fatalf("vertex %d: z %v, want %v", i, mesh.Vertices[i][2], v*5)
+		}
+		if v > 0.1 && v < 0.9 {
+			bevel++
+		}
+	}
+	if bevel == 0 {
+		t.Fatal("expected bevel vertices between plate and letter height")
+	}
+}
+
+func TestDecimateMeshEnforcesTargetTriangles(t *testing.T) {
+	newMesh := func() *stl.Mesh {
+		mesh, err := stl.BuildModuleMesh([]bool{true, false, true, false, true, false, true, false, true}, 3, 3, stl.ReliefOptions{
+			ModelWidth:     3,
+			ModelThickness: 1,
+			BaseThickness:  1,
+		})
+		if err != nil {
+			t.Fatalf("build mesh: %v", err)
+		}
+		return mesh
+	}
+
+	// 棋盘格方块无法简化到 4 个三角形，简化会提前停止
+	if err := decimateMesh(&Job{TargetTriangles: 4}, newMesh()); err == nil {
+		t.Fatal("expected error when decimation stops above targetTriangles")
+	}
+
+	mesh := newMesh()
+	if err := decimateMesh(&Job{TargetTriangles: 60}, mesh); err != nil || mesh.TriangleCount() > 60 {
+		t.Fatalf("decimate to 60: err=%v count=%d", err, mesh.TriangleCount())
+	}
+}
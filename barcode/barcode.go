@@ -0,0 +1,147 @@
+package barcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+// 二维码、条码：把内容编码为模块矩阵（含四周静区），每个模块在模型上是边长 ModuleSize 的方块，
+// 深色模块凸起。一维条码的每一条按 BarHeight 展开成多行。
+
+// 码制
+const (
+	SymbologyQR      = "qr"
+	SymbologyCode128 = "code128"
+)
+
+// qrLevels QR 纠错等级，依次可恢复约 7%、15%、25%、30% 的损坏
+var qrLevels = map[string]qr.ErrorCorrectionLevel{
+	"L": qr.L,
+	"M": qr.M,
+	"Q": qr.Q,
+	"H": qr.H,
+}
+
+// maxModules 矩阵（含静区）模块数的上限，避免条高远大于模块边长时网格过大
+const maxModules = 250_000
+
+// Options 编码和模块尺寸参数，零值不可用，应从 DefaultOptions 开始修改
+type Options struct {
+	Symbology  string  `json:"symbology"`  // 码制：qr、code128
+	Level      string  `json:"level"`      // QR 纠错等级：L、M、Q、H
+	ModuleSize float64 `json:"moduleSize"` // 模块边长（毫米）
+	QuietZone  int     `json:"quietZone"`  // 四周静区宽度（模块数）
+	BarHeight  float64 `json:"barHeight"`  // 一维条码的条高（毫米），不含静区
+	Height     float64 `json:"height"`     // 深色模块凸起的高度（毫米）
+}
+
+// DefaultOptions 1 毫米模块、凸起 1 毫米、M 级纠错，静区取各码制规范的最小值（QR 4 个模块，Code 128 10 个模块）
+func DefaultOptions(symbology string) Options {
+	opts := Options{
+		Symbology:  symbology,
+		Level:      "M",
+		ModuleSize: 1,
+		QuietZone:  4,
+		BarHeight:  15,
+		Height:     1,
+	}
+	if symbology == SymbologyCode128 {
+		opts.QuietZone = 10
+	}
+	return opts
+}
+
+// Validate 检查参数是否在合理范围内
+func (o *Options) Validate() error {
+	if _, ok := qrLevels[o.Level]; !ok {
+		return fmt.Errorf("level must be one of L, M, Q, H, got %q", o.Level)
+	}
+	switch {
+	case o.Symbology != SymbologyQR && o.Symbology != SymbologyCode128:
+		return fmt.Errorf("symbology must be one of qr, code128, got %q", o.Symbology)
+	case o.ModuleSize < 0.1 || o.ModuleSize > 50:
+		return fmt.Errorf("moduleSize must be in [0.1, 50], got %v", o.ModuleSize)
+	case o.QuietZone < 0 || o.QuietZone > 100:
+		return fmt.Errorf("quietZone must be in [0, 100], got %d", o.QuietZone)
+	case o.Height <= 0 || o.Height > 50:
+		return fmt.Errorf("height must be in (0, 50], got %v", o.Height)
+	case o.Symbology == SymbologyCode128 && (o.BarHeight < o.ModuleSize || o.BarHeight > 1000):
+		return fmt.Errorf("barHeight must be in [moduleSize, 1000], got %v", o.BarHeight)
+	}
+	return nil
+}
+
+// Matrix 模块矩阵，行优先，第 0 行在上
+type Matrix struct {
+	Width, Height int
+	Dark          []bool
+}
+
+// At 模块 (x, y) 是否为深色（凸起）
+func (m *Matrix) At(x, y int) bool {
+	return m.Dark[y*m.Width+x]
+}
+
+// Preview 每个模块放大为 scale×scale 像素的高度预览图，凸起的深色模块为白色
+func (m *Matrix) Preview(scale int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, m.Width*scale, m.Height*scale))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if m.At(x/scale, y/scale) {
+				img.Pix[y*img.Stride+x] = 255
+			}
+		}
+	}
+	return img
+}
+
+// Encode 按 opts 的码制编码 content，返回加上静区的模块矩阵
+func Encode(content string, opts Options) (*Matrix, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("content is empty")
+	}
+
+	var (
+		symbol image.Image
+		rows   int // 条码符号的行数，二维码为符号本身的高度
+		err    error
+	)
+	switch opts.Symbology {
+	case SymbologyQR:
+		symbol, err = qr.Encode(content, qrLevels[opts.Level], qr.Auto)
+		if err == nil {
+			rows = symbol.Bounds().Dy()
+		}
+	case SymbologyCode128:
+		symbol, err = code128.Encode(content)
+		rows = max(1, int(math.Round(opts.BarHeight/opts.ModuleSize)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", opts.Symbology, err)
+	}
+
+	b := symbol.Bounds()
+	q := opts.QuietZone
+	m := &Matrix{Width: b.Dx() + 2*q, Height: rows + 2*q}
+	if m.Width*m.Height > maxModules {
+		return nil, fmt.Errorf("%dx%d modules exceed the limit of %d", m.Width, m.Height, maxModules)
+	}
+	m.Dark = make([]bool, m.Width*m.Height)
+	for y := 0; y < rows; y++ {
+		sy := b.Min.Y + min(y, b.Dy()-1) // 一维条码只有一行，逐行重复
+		for x := 0; x < b.Dx(); x++ {
+			gray := color.GrayModel.Convert(symbol.At(b.Min.X+x, sy)).(color.Gray)
+			m.Dark[(y+q)*m.Width+x+q] = gray.Y < 128
+		}
+	}
+	return m, nil
+}
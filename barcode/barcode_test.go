@@ -0,0 +1,105 @@
+package barcode
+
+import "testing"
+
+func TestEncodeQR(t *testing.T) {
+	opts := DefaultOptions(SymbologyQR)
+	m, err := Encode("https://example.com/tag/42", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 版本 n 的符号边长 17+4n，加两侧各 4 个模块的静区
+	size := m.Width - 2*opts.QuietZone
+	if m.Width != m.Height || (size-17)%4 != 0 {
+		t.Fatalf("unexpected matrix %dx%d", m.Width, m.Height)
+	}
+	for i := 0; i < m.Width; i++ {
+		if m.At(i, 0) || m.At(0, i) || m.At(i, m.Height-1) || m.At(m.Width-1, i) {
+			t.Fatalf("quiet zone has dark module at %d", i)
+		}
+	}
+	// 左上角定位图案：7×7 深色外框，中间一圈浅色，3×3 深色中心
+	q := opts.QuietZone
+	finder := []string{
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#.###.#",
+		"#.###.#",
+		"#.....#",
+		"#######",
+	}
+	for y, row := range finder {
+		for x, c := range row {
+			if m.At(q+x, q+y) != (c == '#') {
+				t.Fatalf("finder pattern mismatch at (%d,%d)", x, y)
+			}
+		}
+	}
+
+	// 纠错等级越高，同样内容的符号越大
+	opts.Level = "H"
+	high, err := Encode("https://example.com/tag/42", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if high.Width <= m.Width {
+		t.Fatalf("level H matrix %d not larger than level M %d", high.Width, m.Width)
+	}
+
+	preview := m.Preview(2)
+	if preview.Bounds().Dx() != 2*m.Width || preview.GrayAt(2*q, 2*q).Y != 255 || preview.GrayAt(0, 0).Y != 0 {
+		t.Fatalf("unexpected preview %v", preview.Bounds())
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	opts := DefaultOptions(SymbologyCode128)
+	opts.ModuleSize = 0.5
+	opts.BarHeight = 10
+	m, err := Encode("ABC-123", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Height != 20+2*opts.QuietZone {
+		t.Fatalf("height %d, want %d", m.Height, 20+2*opts.QuietZone)
+	}
+	// 每一行的条都相同，起始符第一条为深色
+	q := opts.QuietZone
+	if !m.At(q, q) || m.At(q-1, q) {
+		t.Fatal("start bar not at the quiet zone edge")
+	}
+	for x := 0; x < m.Width; x++ {
+		if m.At(x, q) != m.At(x, q+19) {
+			t.Fatalf("bar %d differs between rows", x)
+		}
+	}
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	opts := DefaultOptions(SymbologyQR)
+	if _, err := Encode("  ", opts); err == nil {
+		t.Fatal("expected error for empty content")
+	}
+	for _, modify := range []func(o *Options){
+		func(o *Options) { o.Symbology = "ean13" },
+		func(o *Options) { o.Level = "X" },
+		func(o *Options) { o.ModuleSize = 0 },
+		func(o *Options) { o.QuietZone = -1 },
+		func(o *Options) { o.Height = 0 },
+	} {
+		bad := DefaultOptions(SymbologyQR)
+		modify(&bad)
+		if _, err := Encode("hello", bad); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+	code := DefaultOptions(SymbologyCode128)
+	if _, err := Encode("中文", code); err == nil {
+		t.Fatal("expected error for non-ASCII code128 content")
+	}
+	code.ModuleSize, code.BarHeight = 0.1, 1000
+	if _, err := Encode("ABC-123", code); err == nil {
+		t.Fatal("expected error for too many modules")
+	}
+}
@@ -0,0 +1,198 @@
+package dem
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 数字高程模型（DEM）：读取 ESRI ASCII grid、SRTM .hgt 和裸 float32 栅格，
+// 换算成以毫米为单位的高度场，按真实比例（水平、垂直同一比例尺，再乘垂直夸张）建模。
+// 高程不经过 8 位 / 16 位灰度图，保留原始精度。
+
+// metersPerDegree 每度纬度对应的地面距离（米）
+const metersPerDegree = 111320
+
+// Grid 高程栅格，第一行在北
+type Grid struct {
+	Width, Height int
+	Elevation     []float64 // 行优先的高程（米），无数据为 NaN
+	CellWidth     float64   // 单元格东西方向的地面尺寸（米）
+	CellHeight    float64   // 单元格南北方向的地面尺寸（米）
+}
+
+// Options 地形建模参数
+type Options struct {
+	Exaggeration float64 // 垂直夸张倍数
+	ClampMin     bool    // 低于 MinElevation 的高程抬到 MinElevation（如海平面 0 以下的海底）
+	MinElevation float64 // ClampMin 时的最低高程（米）
+	RawWidth     int     // 裸 float32 栅格的列数
+	RawCellSize  float64 // 裸 float32 栅格的单元格尺寸（米）
+	RawBigEndian bool    // 裸 float32 栅格为大端字节序
+}
+
+// DefaultOptions 真实比例、不裁剪
+func DefaultOptions() Options {
+	return Options{
+		Exaggeration: 1,
+		RawCellSize:  30,
+	}
+}
+
+// Validate 检查参数是否在合理范围内
+func (o *Options) Validate() error {
+	switch {
+	case o.Exaggeration <= 0 || o.Exaggeration > 100:
+		return fmt.Errorf("exaggeration must be in (0, 100], got %v", o.Exaggeration)
+	case o.RawWidth < 0:
+		return fmt.Errorf("rawWidth must not be negative, got %d", o.RawWidth)
+	case o.RawCellSize <= 0:
+		return fmt.Errorf("rawCellSize must be positive, got %v", o.RawCellSize)
+	}
+	return nil
+}
+
+// Info 建模结果的统计，随任务返回
+type Info struct {
+	Width        int     `json:"width"`        // 列数（已按单元格长宽比重采样）
+	Height       int     `json:"height"`       // 行数
+	CellSize     float64 `json:"cellSize"`     // 单元格尺寸（米）
+	MinElevation float64 `json:"minElevation"` // 最低高程（米，裁剪后）
+	MaxElevation float64 `json:"maxElevation"` // 最高高程（米）
+	Scale        float64 `json:"scale"`        // 水平比例尺 1:Scale
+}
+
+// Extensions 支持的文件扩展名
+var Extensions = map[string]bool{
+	".asc": true,
+	".hgt": true,
+	".raw": true,
+	".f32": true,
+}
+
+// IsRaw 是否为需要 RawWidth 的裸 float32 栅格
+func IsRaw(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".raw" || ext == ".f32"
+}
+
+// ReadFile 按扩展名读取 DEM 文件
+func ReadFile(path string, opts Options) (*Grid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".asc":
+		return ReadASC(f)
+	case ".hgt":
+		return ReadHGT(f, filepath.Base(path))
+	case ".raw", ".f32":
+		if opts.RawWidth == 0 {
+			return nil, fmt.Errorf("dem: rawWidth is required for raw float32 grids")
+		}
+		return ReadFloat32(f, opts.RawWidth, opts.RawCellSize, opts.RawBigEndian)
+	default:
+		return nil, fmt.Errorf("dem: unsupported file type %s", ext)
+	}
+}
+
+// HeightField 高程换算为宽 modelWidth 毫米的模型高度（毫米），最低点为 0。
+// 单元格不是正方形（经纬度栅格）时先在南北方向重采样，返回的 w、h 为重采样后的尺寸
+func (g *Grid) HeightField(modelWidth float64, opts Options) (field []float64, w, h int, info Info, err error) {
+	if err = opts.Validate(); err != nil {
+		return nil, 0, 0, info, err
+	}
+	if g.Width < 2 || g.Height < 2 {
+		return nil, 0, 0, info, fmt.Errorf("dem: grid too small (%dx%d)", g.Width, g.Height)
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, e := range g.Elevation {
+		if math.IsNaN(e) {
+			continue
+		}
+		if opts.ClampMin {
+			e = max(e, opts.MinElevation)
+		}
+		lo, hi = min(lo, e), max(hi, e)
+	}
+	if math.IsInf(lo, 1) {
+		return nil, 0, 0, info, fmt.Errorf("dem: grid has no valid elevation")
+	}
+
+	// 无数据的单元格按最低点处理
+	elevation := make([]float64, len(g.Elevation))
+	for i, e := range g.Elevation {
+		switch {
+		case math.IsNaN(e):
+			e = lo
+		case opts.ClampMin:
+			e = max(e, opts.MinElevation)
+		}
+		elevation[i] = e
+	}
+
+	w = g.Width
+	h = max(2, int(math.Round(float64(g.Height)*g.CellHeight/g.CellWidth)))
+	if h != g.Height {
+		elevation = resampleRows(elevation, g.Width, g.Height, h)
+	}
+
+	// 模型每个单元格宽 modelWidth/w 毫米，对应 CellWidth 米
+	mmPerMeter := modelWidth / (float64(w) * g.CellWidth)
+	field = make([]float64, len(elevation))
+	for i, e := range elevation {
+		field[i] = (e - lo) * mmPerMeter * opts.Exaggeration
+	}
+
+	info = Info{
+		Width:        w,
+		Height:       h,
+		CellSize:     g.CellWidth,
+		MinElevation: lo,
+		MaxElevation: hi,
+		Scale:        math.Round(1000 / mmPerMeter),
+	}
+	return field, w, h, info, nil
+}
+
+// resampleRows 南北方向线性插值到 newH 行
+func resampleRows(src []float64, w, h, newH int) []float64 {
+	dst := make([]float64, w*newH)
+	for y := 0; y < newH; y++ {
+		sy := float64(y) * float64(h-1) / float64(newH-1)
+		y0 := int(sy)
+		y1 := min(y0+1, h-1)
+		f := sy - float64(y0)
+		for x := 0; x < w; x++ {
+			dst[y*w+x] = src[y0*w+x]*(1-f) + src[y1*w+x]*f
+		}
+	}
+	return dst
+}
+
+// Preview 高度场的 16 位灰度预览图，最高点为白色
+func Preview(field []float64, w, h int) *image.Gray16 {
+	var hi float64
+	for _, v := range field {
+		hi = max(hi, v)
+	}
+	img := image.NewGray16(image.Rect(0, 0, w, h))
+	for i, v := range field {
+		var y uint16
+		if hi > 0 {
+			y = uint16(v/hi*65535 + 0.5)
+		}
+		img.SetGray16(i%w, i/w, color.Gray16{Y: y})
+	}
+	return img
+}
@@ -0,0 +1,125 @@
+package dem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadASC(t *testing.T) {
+	data := `ncols 3
+nrows 2
+xllcorner 500000
+yllcorner 4000000
+cellsize 10
+NODATA_value -9999
+1 2 3
+4 -9999 6
+`
+	g, err := ReadASC(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("read asc: %v", err)
+	}
+	if g.Width != 3 || g.Height != 2 || g.CellWidth != 10 || g.CellHeight != 10 {
+		t.Fatalf("unexpected grid %+v", g)
+	}
+	if g.Elevation[2] != 3 || g.Elevation[3] != 4 || !math.IsNaN(g.Elevation[4]) {
+		t.Fatalf("unexpected elevation %v", g.Elevation)
+	}
+
+	// 经纬度栅格：东西方向按纬度收缩
+	geo := strings.Replace(strings.Replace(data, "cellsize 10", "cellsize 0.001", 1), "yllcorner 4000000", "yllcorner 59.999", 1)
+	geo = strings.Replace(geo, "xllcorner 500000", "xllcorner 10", 1)
+	if g, err = ReadASC(strings.NewReader(geo)); err != nil {
+		t.Fatalf("read geographic asc: %v", err)
+	}
+	if math.Abs(g.CellHeight-111.32) > 1e-6 || math.Abs(g.CellWidth-55.66) > 0.01 {
+		t.Fatalf("unexpected cell size %v x %v", g.CellWidth, g.CellHeight)
+	}
+
+	if _, err = ReadASC(strings.NewReader("ncols 3\nnrows 2\ncellsize 10\n1 2 3\n")); err == nil {
+		t.Fatal("expected error for truncated data")
+	}
+}
+
+func TestReadHGT(t *testing.T) {
+	const n = 11
+	var buf bytes.Buffer
+	for i := 0; i < n*n; i++ {
+		v := int16(i)
+		if i == 5 {
+			v = hgtVoid
+		}
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	}
+
+	g, err := ReadHGT(bytes.NewReader(buf.Bytes()), "S01E010.hgt")
+	if err != nil {
+		t.Fatalf("read hgt: %v", err)
+	}
+	if g.Width != n || g.Elevation[n*n-1] != n*n-1 || !math.IsNaN(g.Elevation[5]) {
+		t.Fatalf("unexpected grid %dx%d", g.Width, g.Height)
+	}
+	wantHeight := 0.1 * metersPerDegree
+	if math.Abs(g.CellHeight-wantHeight) > 1e-6 || math.Abs(g.CellWidth-wantHeight*math.Cos(0.5*math.Pi/180)) > 1e-6 {
+		t.Fatalf("unexpected cell size %v x %v", g.CellWidth, g.CellHeight)
+	}
+
+	if _, err = ReadHGT(bytes.NewReader(make([]byte, 10)), "N00E000.hgt"); err == nil {
+		t.Fatal("expected error for non-square file")
+	}
+}
+
+func TestReadFloat32(t *testing.T) {
+	var buf bytes.Buffer
+	for _, v := range []float32{1, 2, 3, 4, 5, -3.4e38} {
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	}
+	g, err := ReadFloat32(bytes.NewReader(buf.Bytes()), 3, 5, true)
+	if err != nil {
+		t.Fatalf("read float32: %v", err)
+	}
+	if g.Height != 2 || g.Elevation[4] != 5 || !math.IsNaN(g.Elevation[5]) || g.CellWidth != 5 {
+		t.Fatalf("unexpected grid %+v", g)
+	}
+	if _, err = ReadFloat32(bytes.NewReader(buf.Bytes()), 4, 5, true); err == nil {
+		t.Fatal("expected error for width not matching file size")
+	}
+}
+
+func TestHeightField(t *testing.T) {
+	g := &Grid{
+		Width: 4, Height: 2,
+		Elevation:  []float64{-20, 0, 100, math.NaN(), 50, 200, 300, 10},
+		CellWidth:  250,
+		CellHeight: 500, // 南北方向是东西方向的两倍，重采样为 4 行
+	}
+
+	opts := DefaultOptions()
+	opts.Exaggeration = 2
+	opts.ClampMin, opts.MinElevation = true, 0
+	field, w, h, info, err := g.HeightField(100, opts)
+	if err != nil {
+		t.Fatalf("height field: %v", err)
+	}
+	if w != 4 || h != 4 || len(field) != 16 {
+		t.Fatalf("unexpected size %dx%d", w, h)
+	}
+
+	// 每个单元格 25 毫米对应 250 米：1:10000，1 米高程 = 0.1 毫米，再乘 2 倍夸张
+	if info.Scale != 10000 || info.MinElevation != 0 || info.MaxElevation != 300 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	for i, want := range map[int]float64{0: 0, 2: 20, 3: 0, 12: 10, 14: 60} {
+		if math.Abs(field[i]-want) > 1e-9 {
+			t.Fatalf("field[%d] = %v, want %v", i, field[i], want)
+		}
+	}
+
+	opts.Exaggeration = 0
+	if _, _, _, _, err = g.HeightField(100, opts); err == nil {
+		t.Fatal("expected error for zero exaggeration")
+	}
+}
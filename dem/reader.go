@@ -0,0 +1,156 @@
+package dem
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// hgtVoid SRTM 中的空洞值
+const hgtVoid = -32768
+
+// ReadASC 读取 ESRI ASCII grid。cellsize 小于 1 且左下角坐标在经纬度范围内时按度处理
+func ReadASC(r io.Reader) (*Grid, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	scanner.Split(bufio.ScanWords)
+
+	header := map[string]float64{}
+	var first string
+	for scanner.Scan() {
+		key := strings.ToLower(scanner.Text())
+		if _, err := strconv.ParseFloat(key, 64); err == nil {
+			first = key // 头部结束，第一个数据
+			break
+		}
+		if !scanner.Scan() {
+			break
+		}
+		v, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("dem: invalid header value %s %q", key, scanner.Text())
+		}
+		header[key] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	w, h := int(header["ncols"]), int(header["nrows"])
+	if w < 2 || h < 2 {
+		return nil, fmt.Errorf("dem: invalid ncols/nrows %dx%d", w, h)
+	}
+	cellX, cellY := header["cellsize"], header["cellsize"]
+	if dx, ok := header["dx"]; ok {
+		cellX, cellY = dx, header["dy"]
+	}
+	if cellX <= 0 || cellY <= 0 {
+		return nil, fmt.Errorf("dem: invalid cellsize")
+	}
+	noData, hasNoData := header["nodata_value"]
+
+	g := &Grid{Width: w, Height: h, Elevation: make([]float64, w*h), CellWidth: cellX, CellHeight: cellY}
+	for i := range g.Elevation {
+		token := first
+		if i > 0 || token == "" {
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("dem: expected %d values, got %d", w*h, i)
+			}
+			token = scanner.Text()
+		}
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("dem: invalid value %q", token)
+		}
+		if hasNoData && v == noData {
+			v = math.NaN()
+		}
+		g.Elevation[i] = v
+	}
+
+	x, y := header["xllcorner"]+header["xllcenter"], header["yllcorner"]+header["yllcenter"]
+	if cellX < 1 && math.Abs(x) <= 180 && math.Abs(y) <= 90 {
+		g.degreesToMeters(y + cellY*float64(h)/2)
+	}
+	return g, nil
+}
+
+// ReadHGT 读取 SRTM .hgt 瓦片：大端 int16，正方形（1201² 为 3 角秒，3601² 为 1 角秒）。
+// 文件名（如 N37W122.hgt）给出瓦片西南角纬度，用于计算东西方向的单元格尺寸
+func ReadHGT(r io.Reader, name string) (*Grid, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	n := int(math.Sqrt(float64(len(data) / 2)))
+	if n < 2 || n*n*2 != len(data) {
+		return nil, fmt.Errorf("dem: hgt file size %d is not a square int16 grid", len(data))
+	}
+
+	g := &Grid{Width: n, Height: n, Elevation: make([]float64, n*n)}
+	for i := range g.Elevation {
+		v := int16(binary.BigEndian.Uint16(data[2*i:]))
+		if v == hgtVoid {
+			g.Elevation[i] = math.NaN()
+		} else {
+			g.Elevation[i] = float64(v)
+		}
+	}
+
+	cell := 1 / float64(n-1)
+	g.CellWidth, g.CellHeight = cell, cell
+	g.degreesToMeters(hgtLatitude(name) + 0.5)
+	return g, nil
+}
+
+// hgtLatitude 从文件名解析瓦片西南角纬度，解析不出时按赤道处理
+func hgtLatitude(name string) float64 {
+	name = strings.ToUpper(name)
+	if len(name) < 3 || (name[0] != 'N' && name[0] != 'S') {
+		return 0
+	}
+	lat, err := strconv.Atoi(name[1:3])
+	if err != nil {
+		return 0
+	}
+	if name[0] == 'S' {
+		return -float64(lat)
+	}
+	return float64(lat)
+}
+
+// ReadFloat32 读取无头部的 float32 栅格，行数由文件大小推出。NaN 和极小值（< -1e30）视为无数据
+func ReadFloat32(r io.Reader, width int, cellSize float64, bigEndian bool) (*Grid, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if width < 2 || len(data)%(4*width) != 0 {
+		return nil, fmt.Errorf("dem: file size %d does not match width %d", len(data), width)
+	}
+	h := len(data) / (4 * width)
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	g := &Grid{Width: width, Height: h, Elevation: make([]float64, width*h), CellWidth: cellSize, CellHeight: cellSize}
+	for i := range g.Elevation {
+		v := float64(math.Float32frombits(order.Uint32(data[4*i:])))
+		if v < -1e30 {
+			v = math.NaN()
+		}
+		g.Elevation[i] = v
+	}
+	return g, nil
+}
+
+// degreesToMeters 经纬度单元格换算为米，东西方向按中心纬度收缩
+func (g *Grid) degreesToMeters(latitude float64) {
+	g.CellHeight *= metersPerDegree
+	g.CellWidth *= metersPerDegree * math.Cos(latitude*math.Pi/180)
+}
@@ -0,0 +1,160 @@
+package depth
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// 自动调参：根据亮度直方图、对比度和边缘密度选择背景阈值、gamma、
+// 百分位拉伸和细节强度。分辨率、量化台阶和高度映射不受影响。
+
+// autoAnalyzeSize 统计前把图片缩小到的长边像素数
+const autoAnalyzeSize = 256
+
+// ImageStats 自动调参使用的图像统计（亮度归一化到 0~1）
+type ImageStats struct {
+	DarkFraction   float64 `json:"darkFraction"`   // 近黑像素（< 16/255）占比，高说明是黑底图
+	BrightFraction float64 `json:"brightFraction"` // 近白像素（> 239/255）占比
+	Mean           float64 `json:"mean"`           // 前景亮度均值
+	StdDev         float64 `json:"stdDev"`         // 前景亮度标准差（对比度）
+	EdgeDensity    float64 `json:"edgeDensity"`    // 前景中 Sobel 梯度明显的像素占比
+}
+
+// AnalyzeImage 统计图片的亮度分布、对比度和边缘密度
+func AnalyzeImage(img image.Image) ImageStats {
+	stats, _ := analyze(img)
+	return stats
+}
+
+// AutoTune 以 base 为基础，按图片统计选择 BackgroundClip、Gamma、
+// LowPercentile/HighPercentile 和 DetailStrength
+func AutoTune(img image.Image, base Options) (Options, ImageStats) {
+	stats, clip := analyze(img)
+
+	opts := base
+	opts.BackgroundClip = clip
+
+	// gamma 把前景平均亮度映射到中间调：暗图提亮，亮图压暗
+	mean := min(max(stats.Mean, 0.02), 0.98)
+	opts.Gamma = roundTo(min(max(math.Log(0.5)/math.Log(mean), 0.4), 1.6), 20)
+
+	// 对比度低时收紧拉伸区间，高时放宽以免截断高光和阴影
+	switch {
+	case stats.StdDev < 0.12:
+		opts.LowPercentile, opts.HighPercentile = 5, 95
+	case stats.StdDev > 0.25:
+		opts.LowPercentile, opts.HighPercentile = 1, 99
+	default:
+		opts.LowPercentile, opts.HighPercentile = 2, 98
+	}
+
+	// 边缘越密（线稿、噪声多的图），细节层越容易产生毛刺
+	opts.DetailStrength = roundTo(min(max(0.9-1.5*stats.EdgeDensity, 0.3), 0.9), 20)
+
+	return opts, stats
+}
+
+// analyze 统计图像，并返回建议的背景阈值
+func analyze(img image.Image) (ImageStats, int) {
+	lum, w, h := analysisLuminance(img)
+	var hist [256]int
+	for _, v := range lum {
+		hist[v]++
+	}
+	return analyzeLuminance(lum, w, h, &hist)
+}
+
+// analysisLuminance 缩小后的亮度图（0~255）
+func analysisLuminance(img image.Image) ([]uint8, int, int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if longest := max(w, h); longest > autoAnalyzeSize {
+		scale := float64(autoAnalyzeSize) / float64(longest)
+		w, h = max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+	}
+
+	small := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, b, draw.Src, nil)
+
+	lum := make([]uint8, w*h)
+	for i := range lum {
+		r, g, bl := uint32(small.Pix[i*4]), uint32(small.Pix[i*4+1]), uint32(small.Pix[i*4+2])
+		lum[i] = uint8((299*r + 587*g + 114*bl) / 1000)
+	}
+	return lum, w, h
+}
+
+// analyzeLuminance 计算统计量和背景阈值
+func analyzeLuminance(lum []uint8, w, h int, hist *[256]int) (ImageStats, int) {
+	total := float64(len(lum))
+	var stats ImageStats
+	dark, bright := 0, 0
+	for v := 0; v < 16; v++ {
+		dark += hist[v]
+	}
+	for v := 240; v < 256; v++ {
+		bright += hist[v]
+	}
+	stats.DarkFraction = float64(dark) / total
+	stats.BrightFraction = float64(bright) / total
+
+	// 黑底图：阈值取到暗像素簇之上；否则几乎不裁剪，避免照片的阴影被挖空
+	clip := 2
+	if stats.DarkFraction >= 0.15 {
+		target := int(stats.DarkFraction*total) + int(0.01*total)
+		sum := 0
+		for v := 0; v < 256; v++ {
+			sum += hist[v]
+			if sum >= target {
+				clip = min(max(v+2, 8), 48)
+				break
+			}
+		}
+	}
+
+	var n, sum, sumSq float64
+	for v := clip; v < 256; v++ {
+		c := float64(hist[v])
+		x := float64(v) / 255
+		n += c
+		sum += c * x
+		sumSq += c * x * x
+	}
+	if n > 0 {
+		stats.Mean = sum / n
+		stats.StdDev = math.Sqrt(max(sumSq/n-stats.Mean*stats.Mean, 0))
+	}
+
+	// Sobel 梯度，最大值为 4*255
+	var edges, foreground int
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), w-1)
+		y = min(max(y, 0), h-1)
+		return float64(lum[y*w+x])
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if int(lum[y*w+x]) < clip {
+				continue
+			}
+			foreground++
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			if math.Hypot(gx, gy)/(4*255) > 0.15 {
+				edges++
+			}
+		}
+	}
+	if foreground > 0 {
+		stats.EdgeDensity = float64(edges) / float64(foreground)
+	}
+
+	return stats, clip
+}
+
+// roundTo 四舍五入到 1/steps，避免返回给用户的参数带一长串小数
+func roundTo(v, steps float64) float64 {
+	return math.Round(v*steps) / steps
+}
@@ -0,0 +1,67 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func fillImage(w, h int, fn func(x, y int) uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := fn(x, y)
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestAutoTune(t *testing.T) {
+	// 黑底上的暗色角色
+	darkArt := fillImage(400, 300, func(x, y int) uint8 {
+		if x < 100 || x > 300 || y < 75 || y > 225 {
+			return 3
+		}
+		return uint8(40 + (x+y)%40)
+	})
+	// 白底上的亮色产品照片
+	brightPhoto := fillImage(400, 300, func(x, y int) uint8 {
+		return uint8(170 + (x/4+y/4)%80)
+	})
+	// 线稿：白底上密集的黑线
+	lineArt := fillImage(400, 300, func(x, y int) uint8 {
+		if x%6 == 0 || y%6 == 0 {
+			return 20
+		}
+		return 240
+	})
+
+	base := DefaultOptions()
+	dark, darkStats := AutoTune(darkArt, base)
+	bright, _ := AutoTune(brightPhoto, base)
+	lines, lineStats := AutoTune(lineArt, base)
+
+	if darkStats.DarkFraction < 0.4 || dark.BackgroundClip <= base.BackgroundClip {
+		t.Fatalf("dark art: expected background clip above default, got %d (%+v)", dark.BackgroundClip, darkStats)
+	}
+	if dark.Gamma >= 0.7 || bright.Gamma <= 1 {
+		t.Fatalf("expected dark art to be brightened and bright photo darkened, got gamma %v / %v", dark.Gamma, bright.Gamma)
+	}
+	if bright.BackgroundClip > base.BackgroundClip {
+		t.Fatalf("bright photo: unexpected background clip %d", bright.BackgroundClip)
+	}
+	if lineStats.EdgeDensity <= darkStats.EdgeDensity || lines.DetailStrength >= dark.DetailStrength {
+		t.Fatalf("line art: expected weaker detail, got %v vs %v (edges %v vs %v)",
+			lines.DetailStrength, dark.DetailStrength, lineStats.EdgeDensity, darkStats.EdgeDensity)
+	}
+
+	for _, opts := range []Options{dark, bright, lines} {
+		if err := opts.Validate(); err != nil {
+			t.Fatalf("auto options invalid: %v (%+v)", err, opts)
+		}
+		if opts.BaseSize != base.BaseSize || opts.Levels != base.Levels || opts.HeightGamma != base.HeightGamma {
+			t.Fatalf("auto tune changed untouched options: %+v", opts)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 	"math"
+	"sort"
 
 	"golang.org/x/image/draw"
 )
@@ -29,15 +30,21 @@ func ConvertToGray(img image.Image) *image.Gray {
 
 // GenerateDepthMap 生成深度图：灰度 + 缩放 + 高斯模糊 + 可反转
 func GenerateDepthMap(img image.Image, detailLevel float64, invert bool) *image.Gray {
+	opts := DefaultOptions()
+	opts.Invert = invert
+	return generateDepthMap(img, 320.0*detailLevel, &opts)
+}
+
+// generateDepthMap 使用 opts 中的 Invert 和 Smoothing，base 为缩放后长边的像素数
+func generateDepthMap(img image.Image, base float64, opts *Options) *image.Gray {
 	bounds := img.Bounds()
 	width := bounds.Dx()
 	height := bounds.Dy()
 
 	// 缩放尺寸
-	baseSize := 320.0 * detailLevel
-	ratio := math.Min(baseSize/float64(width), baseSize/float64(height))
-	newWidth := int(float64(width) * ratio)
-	newHeight := int(float64(height) * ratio)
+	ratio := math.Min(base/float64(width), base/float64(height))
+	newWidth := max(1, int(float64(width)*ratio))
+	newHeight := max(1, int(float64(height)*ratio))
 
 	// 灰度化 + gamma 校正
 	gray := image.NewGray(bounds)
@@ -53,44 +60,67 @@ func GenerateDepthMap(img image.Image, detailLevel float64, invert bool) *image.
 	resized := image.NewGray(image.Rect(0, 0, newWidth, newHeight))
 	draw.CatmullRom.Scale(resized, resized.Bounds(), gray, gray.Bounds(), draw.Over, nil)
 
-	// 高斯模糊 (3x3 高斯卷积)
-	kernel := [3][3]float64{
-		{1 / 16.0, 2 / 16.0, 1 / 16.0},
-		{2 / 16.0, 4 / 16.0, 2 / 16.0},
-		{1 / 16.0, 2 / 16.0, 1 / 16.0},
-	}
-	blur := image.NewGray(resized.Bounds())
-	for y := 1; y < newHeight-1; y++ {
-		for x := 1; x < newWidth-1; x++ {
-			var sum float64
-			for ky := -1; ky <= 1; ky++ {
-				for kx := -1; kx <= 1; kx++ {
-					sum += float64(resized.GrayAt(x+kx, y+ky).Y) * kernel[ky+1][kx+1]
+	// 高斯模糊 (3x3 高斯卷积)，已做过保边平滑时跳过
+	if !smoothed(opts) {
+		kernel := [3][3]float64{
+			{1 / 16.0, 2 / 16.0, 1 / 16.0},
+			{2 / 16.0, 4 / 16.0, 2 / 16.0},
+			{1 / 16.0, 2 / 16.0, 1 / 16.0},
+		}
+		blur := image.NewGray(resized.Bounds())
+		for y := 0; y < newHeight; y++ {
+			for x := 0; x < newWidth; x++ {
+				var sum float64
+				for ky := -1; ky <= 1; ky++ {
+					for kx := -1; kx <= 1; kx++ {
+						sum += float64(clampedGrayAt(resized, x+kx, y+ky)) * kernel[ky+1][kx+1]
+					}
 				}
+				blur.Pix[y*blur.Stride+x] = uint8(sum)
 			}
-			val := uint8(sum)
-			if invert {
-				val = 255 - val
-			}
-			blur.SetGray(x, y, color.Gray{Y: val})
 		}
+		resized = blur
 	}
 
-	return blur
+	if opts.Invert {
+		for i, v := range resized.Pix {
+			resized.Pix[i] = 255 - v
+		}
+	}
+	return resized
+}
+
+// smoothed 深度估计前是否已做过保边平滑，此时不再做 3x3 模糊
+func smoothed(opts *Options) bool {
+	return opts.Smoothing != SmoothingNone && opts.Smoothing != ""
+}
+
+// clampedGrayAt 超出图像的坐标取最近的边缘像素，边缘像素的邻域不再补 0
+func clampedGrayAt(img *image.Gray, x, y int) int {
+	b := img.Bounds()
+	x = min(max(x, b.Min.X), b.Max.X-1)
+	y = min(max(y, b.Min.Y), b.Max.Y-1)
+	return int(img.Pix[img.PixOffset(x, y)])
 }
 
 const (
 	// base   = 320.0 // XY 分辨率（影响 STL 面数）
-	base   = 320.0 // XY 分辨率（影响 STL 面数）
-	levels = 36    // Z 台阶数（影响 STL 高度层次）
+	base = 320.0 // XY 分辨率（影响 STL 面数）
+	// Z 台阶数（影响 STL 高度层次）见 Options.Levels
 )
 
 func GenerateDepthMap2(img image.Image, detailLevel float64, invert bool) *image.Gray {
+	opts := DefaultOptions()
+	opts.Invert = invert
+	return generateDepthMap2(img, math.Max(1, base*detailLevel), &opts)
+}
+
+// generateDepthMap2 使用 opts 中的 Levels 和 Invert，base 为缩放后长边的像素数
+func generateDepthMap2(img image.Image, base float64, opts *Options) *image.Gray {
 	b := img.Bounds()
 	w, h := b.Dx(), b.Dy()
 
 	// ---------- XY 分辨率：温和降级 ----------
-	base := math.Max(1, base*detailLevel)
 	ratio := math.Min(base/float64(w), base/float64(h))
 	nw, nh := max(1, int(float64(w)*ratio)), max(1, int(float64(h)*ratio))
 
@@ -107,26 +137,28 @@ func GenerateDepthMap2(img image.Image, detailLevel float64, invert bool) *image
 	resized := image.NewGray(image.Rect(0, 0, nw, nh))
 	draw.CatmullRom.Scale(resized, resized.Bounds(), gray, gray.Bounds(), draw.Over, nil)
 
-	// ---------- 轻度高斯模糊（仅消噪） ----------
-	blur := image.NewGray(resized.Bounds())
-	k := [3][3]int{
-		{1, 2, 1},
-		{2, 4, 2},
-		{1, 2, 1},
-	}
+	// ---------- 轻度高斯模糊（仅消噪，已做过保边平滑时跳过） ----------
+	if !smoothed(opts) {
+		blur := image.NewGray(resized.Bounds())
+		k := [3][3]int{
+			{1, 2, 1},
+			{2, 4, 2},
+			{1, 2, 1},
+		}
 
-	for y := 1; y < nh-1; y++ {
-		for x := 1; x < nw-1; x++ {
-			sum := 0
-			for ky := -1; ky <= 1; ky++ {
-				for kx := -1; kx <= 1; kx++ {
-					sum += int(resized.Pix[(y+ky)*resized.Stride+x+kx]) * k[ky+1][kx+1]
+		for y := 0; y < nh; y++ {
+			for x := 0; x < nw; x++ {
+				sum := 0
+				for ky := -1; ky <= 1; ky++ {
+					for kx := -1; kx <= 1; kx++ {
+						sum += clampedGrayAt(resized, x+kx, y+ky) * k[ky+1][kx+1]
+					}
 				}
+				blur.Pix[y*blur.Stride+x] = uint8(sum >> 4)
 			}
-			blur.Pix[y*blur.Stride+x] = uint8(sum >> 4)
 		}
+		resized = blur
 	}
-	resized = blur
 
 	// ---------- 轻 S 曲线（保形体） ----------
 	var lut [256]uint8
@@ -137,13 +169,13 @@ func GenerateDepthMap2(img image.Image, detailLevel float64, invert bool) *image
 	}
 
 	// ---------- Z 量化（细节保留版） ----------
-	step := uint8(256 / levels)
+	step := uint8(256 / opts.Levels)
 
 	out := image.NewGray(resized.Bounds())
 	for i, v := range resized.Pix {
 		v = lut[v]
 		q := (v / step) * step
-		if invert {
+		if opts.Invert {
 			q = 255 - q
 		}
 		out.Pix[i] = q
@@ -153,11 +185,17 @@ func GenerateDepthMap2(img image.Image, detailLevel float64, invert bool) *image
 }
 
 func GenerateDepthMap3(img image.Image, detailLevel float64, invert bool) *image.Gray {
+	opts := DefaultOptions()
+	opts.Invert = invert
+	return generateDepthMap3(img, math.Max(1, 320.0*detailLevel), &opts)
+}
+
+// generateDepthMap3 使用 opts 中的百分位、Gamma、Levels 和 Invert，base 为缩放后长边的像素数
+func generateDepthMap3(img image.Image, base float64, opts *Options) *image.Gray {
 	b := img.Bounds()
 	w, h := b.Dx(), b.Dy()
 
 	// ---------- 缩放 ----------
-	base := math.Max(1, 320.0*detailLevel)
 	ratio := math.Min(base/float64(w), base/float64(h))
 	nw, nh := max(1, int(float64(w)*ratio)), max(1, int(float64(h)*ratio))
 
@@ -175,19 +213,21 @@ func GenerateDepthMap3(img image.Image, detailLevel float64, invert bool) *image
 	resized := image.NewGray(image.Rect(0, 0, nw, nh))
 	draw.CatmullRom.Scale(resized, resized.Bounds(), gray, gray.Bounds(), draw.Over, nil)
 
-	// ---------- 轻模糊 ----------
-	blur := image.NewGray(resized.Bounds())
-	for y := 1; y < nh-1; y++ {
-		for x := 1; x < nw-1; x++ {
-			sum := int(resized.GrayAt(x, y).Y)*4 +
-				int(resized.GrayAt(x-1, y).Y) +
-				int(resized.GrayAt(x+1, y).Y) +
-				int(resized.GrayAt(x, y-1).Y) +
-				int(resized.GrayAt(x, y+1).Y)
-			blur.SetGray(x, y, color.Gray{Y: uint8(sum / 8)})
+	// ---------- 轻模糊（已做过保边平滑时跳过） ----------
+	if !smoothed(opts) {
+		blur := image.NewGray(resized.Bounds())
+		for y := 0; y < nh; y++ {
+			for x := 0; x < nw; x++ {
+				sum := clampedGrayAt(resized, x, y)*4 +
+					clampedGrayAt(resized, x-1, y) +
+					clampedGrayAt(resized, x+1, y) +
+					clampedGrayAt(resized, x, y-1) +
+					clampedGrayAt(resized, x, y+1)
+				blur.SetGray(x, y, color.Gray{Y: uint8(sum / 8)})
+			}
 		}
+		resized = blur
 	}
-	resized = blur
 
 	// =========================================================
 	// 🔥 关键：百分位对比拉伸（忽略黑背景）
@@ -199,8 +239,7 @@ func GenerateDepthMap3(img image.Image, detailLevel float64, invert bool) *image
 	}
 
 	total := len(resized.Pix)
-	lowCut := total * 2 / 100   // 2%
-	highCut := total * 98 / 100 // 98%
+	lowCut, highCut := opts.percentileCuts(total)
 
 	sum := 0
 	minVal, maxVal := 0, 255
@@ -231,7 +270,7 @@ func GenerateDepthMap3(img image.Image, detailLevel float64, invert bool) *image
 	// ---------- 拉伸 + gamma ----------
 	out := image.NewGray(resized.Bounds())
 
-	gamma := 0.7 // 🔥 提亮暗部（关键）
+	gamma := opts.Gamma // 🔥 提亮暗部（关键）
 
 	for i, v := range resized.Pix {
 		nv := float64(v-uint8(minVal)) * scale
@@ -247,7 +286,7 @@ func GenerateDepthMap3(img image.Image, detailLevel float64, invert bool) *image
 
 		val := uint8(nv + 0.5)
 
-		if invert {
+		if opts.Invert {
 			val = 255 - val
 		}
 
@@ -255,7 +294,7 @@ func GenerateDepthMap3(img image.Image, detailLevel float64, invert bool) *image
 	}
 
 	// ---------- Z量化 ----------
-	step := uint8(256 / levels)
+	step := uint8(256 / opts.Levels)
 	for i, v := range out.Pix {
 		out.Pix[i] = (v / step) * step
 	}
@@ -264,14 +303,22 @@ func GenerateDepthMap3(img image.Image, detailLevel float64, invert bool) *image
 }
 
 func GenerateDepthMap4(img image.Image, invert bool) *image.Gray {
+	opts := DefaultOptions()
+	opts.Invert = invert
+	return generateDepthMap4(img, &opts)
+}
+
+// generateDepthMap4 除 HeightGamma 外的所有 Options 都会生效。内部像素与最初的 8 位实现逐位一致；
+// 最外一圈像素按邻近像素计算（原先为黑边），百分位统计仍只用内部像素，不影响内部结果
+func generateDepthMap4(img image.Image, opts *Options) *image.Gray {
 	b := img.Bounds()
 	w, h := b.Dx(), b.Dy()
 
-	const (
-		baseSize       = 320.0
-		backgroundClip = 8
-		detailStrength = 0.6
-		gamma          = 0.7
+	var (
+		baseSize       = float64(opts.BaseSize)
+		backgroundClip = uint8(opts.BackgroundClip)
+		detailStrength = opts.DetailStrength
+		gamma          = opts.Gamma
 	)
 
 	// ---------- 缩放 ----------
@@ -301,26 +348,29 @@ func GenerateDepthMap4(img image.Image, invert bool) *image.Gray {
 	draw.CatmullRom.Scale(resized, resized.Bounds(), gray, gray.Bounds(), draw.Over, nil)
 
 	// =========================================================
-	// 1️⃣ 低频（Base）= 模糊
+	// 1️⃣ 低频（Base）= 模糊（已做过保边平滑时直接用原值）
 	// =========================================================
+	blurBase := !smoothed(opts)
 	base := image.NewGray(resized.Bounds())
 	var hist [256]int
-	rs := resized.Stride
-	bs := base.Stride
 	innerCount := 0
-	for y := 1; y < nh-1; y++ {
-		prev := (y - 1) * rs
-		curr := y * rs
-		next := (y + 1) * rs
-		out := y * bs
-		for x := 1; x < nw-1; x++ {
-			sum := int(resized.Pix[prev+x-1]) + int(resized.Pix[prev+x]) + int(resized.Pix[prev+x+1]) +
-				int(resized.Pix[curr+x-1]) + int(resized.Pix[curr+x]) + int(resized.Pix[curr+x+1]) +
-				int(resized.Pix[next+x-1]) + int(resized.Pix[next+x]) + int(resized.Pix[next+x+1])
-			v := uint8(sum / 9)
-			base.Pix[out+x] = v
-			hist[v]++
-			innerCount++
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			v := resized.Pix[y*resized.Stride+x]
+			if blurBase {
+				sum := 0
+				for ky := -1; ky <= 1; ky++ {
+					for kx := -1; kx <= 1; kx++ {
+						sum += clampedGrayAt(resized, x+kx, y+ky)
+					}
+				}
+				v = uint8(sum / 9)
+			}
+			base.Pix[y*base.Stride+x] = v
+			if x > 0 && y > 0 && x < nw-1 && y < nh-1 {
+				hist[v]++
+				innerCount++
+			}
 		}
 	}
 
@@ -328,18 +378,13 @@ func GenerateDepthMap4(img image.Image, invert bool) *image.Gray {
 	// 2️⃣ 高频（Detail）= Laplacian
 	// =========================================================
 	detail := image.NewGray(resized.Bounds())
-	ds := detail.Stride
-	for y := 1; y < nh-1; y++ {
-		prev := (y - 1) * rs
-		curr := y * rs
-		next := (y + 1) * rs
-		out := y * ds
-		for x := 1; x < nw-1; x++ {
-			sum := int(resized.Pix[curr+x])*4 -
-				int(resized.Pix[curr+x-1]) -
-				int(resized.Pix[curr+x+1]) -
-				int(resized.Pix[prev+x]) -
-				int(resized.Pix[next+x])
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			sum := clampedGrayAt(resized, x, y)*4 -
+				clampedGrayAt(resized, x-1, y) -
+				clampedGrayAt(resized, x+1, y) -
+				clampedGrayAt(resized, x, y-1) -
+				clampedGrayAt(resized, x, y+1)
 
 			sum = sum/2 + 128 // 中心化
 
@@ -350,7 +395,7 @@ func GenerateDepthMap4(img image.Image, invert bool) *image.Gray {
 				sum = 255
 			}
 
-			detail.Pix[out+x] = uint8(sum)
+			detail.Pix[y*detail.Stride+x] = uint8(sum)
 		}
 	}
 
@@ -359,8 +404,7 @@ func GenerateDepthMap4(img image.Image, invert bool) *image.Gray {
 	// =========================================================
 	total := len(base.Pix)
 	hist[0] += total - innerCount
-	lowCut := total * 2 / 100
-	highCut := total * 98 / 100
+	lowCut, highCut := opts.percentileCuts(total)
 
 	sum := 0
 	minVal, maxVal := 0, 255
@@ -421,7 +465,7 @@ func GenerateDepthMap4(img image.Image, invert bool) *image.Gray {
 
 		val := uint8(v + 0.5)
 
-		if invert {
+		if opts.Invert {
 			val = 255 - val
 		}
 
@@ -431,10 +475,132 @@ func GenerateDepthMap4(img image.Image, invert bool) *image.Gray {
 	// =========================================================
 	// 5️⃣ Z量化
 	// =========================================================
-	step := uint8(256 / levels)
+	step := uint8(256 / opts.Levels)
 	for i, v := range out.Pix {
 		out.Pix[i] = (v / step) * step
 	}
 
 	return out
 }
+
+// ConvertToGray16 直接把图像转换为 16 位灰度图，16 位 PNG 深度图不会丢失精度
+func ConvertToGray16(img image.Image) *image.Gray16 {
+	bounds := img.Bounds()
+	gray := image.NewGray16(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x, y, color.Gray16Model.Convert(img.At(x, y)))
+		}
+	}
+	return gray
+}
+
+// GenerateDepthMap16 与 GenerateDepthMap4 相同的流程，全程浮点计算并输出 16 位深度图：
+// 不做 Z 量化，边缘像素按邻近像素计算（不再是黑边）
+func GenerateDepthMap16(img image.Image, invert bool) *image.Gray16 {
+	opts := DefaultOptions()
+	opts.Invert = invert
+	return generateDepthMap16(img, &opts)
+}
+
+// generateDepthMap16 除 Levels 和 HeightGamma 外的所有 Options 都会生效
+func generateDepthMap16(img image.Image, opts *Options) *image.Gray16 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	var (
+		baseSize       = float64(opts.BaseSize)
+		backgroundClip = uint32(opts.BackgroundClip)
+		detailStrength = opts.DetailStrength
+		gamma          = opts.Gamma
+	)
+
+	// ---------- 缩放 ----------
+	ratio := math.Min(baseSize/float64(w), baseSize/float64(h))
+	nw, nh := max(1, int(float64(w)*ratio)), max(1, int(float64(h)*ratio))
+
+	// ---------- 灰度 ----------
+	gray := image.NewGray16(b)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(x+b.Min.X, y+b.Min.Y).RGBA()
+			v := (299*r + 587*g + 114*bl) / 1000
+
+			// 背景抑制（阈值与 8 位版本一致）
+			if v < backgroundClip*257 {
+				v = 0
+			}
+
+			gray.SetGray16(x+b.Min.X, y+b.Min.Y, color.Gray16{Y: uint16(v)})
+		}
+	}
+
+	resized := image.NewGray16(image.Rect(0, 0, nw, nh))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), gray, gray.Bounds(), draw.Over, nil)
+
+	// 以 0..255 的浮点数处理，常量含义与 8 位版本相同
+	src := make([]float64, nw*nh)
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			src[y*nw+x] = float64(resized.Gray16At(x, y).Y) / 257
+		}
+	}
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), nw-1)
+		y = min(max(y, 0), nh-1)
+		return src[y*nw+x]
+	}
+
+	// ---------- 低频（Base）= 3x3 均值（已做过保边平滑时直接用原值），高频（Detail）= Laplacian ----------
+	blurBase := !smoothed(opts)
+	base := make([]float64, nw*nh)
+	detail := make([]float64, nw*nh)
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			if blurBase {
+				var sum float64
+				for ky := -1; ky <= 1; ky++ {
+					for kx := -1; kx <= 1; kx++ {
+						sum += at(x+kx, y+ky)
+					}
+				}
+				base[y*nw+x] = sum / 9
+			} else {
+				base[y*nw+x] = at(x, y)
+			}
+
+			lap := 4*at(x, y) - at(x-1, y) - at(x+1, y) - at(x, y-1) - at(x, y+1)
+			detail[y*nw+x] = min(max(lap/2, -128), 127)
+		}
+	}
+
+	// ---------- 百分位拉伸（对 base 做） ----------
+	sorted := append([]float64(nil), base...)
+	sort.Float64s(sorted)
+	total := len(sorted)
+	lowCut, highCut := opts.percentileCuts(total)
+	minVal := sorted[min(lowCut, total-1)]
+	maxVal := sorted[max(highCut-1, 0)]
+	if maxVal-minVal < 1 {
+		maxVal = minVal + 1
+	}
+	scale := 255.0 / (maxVal - minVal)
+
+	// ---------- 融合 Base + Detail ----------
+	out := image.NewGray16(image.Rect(0, 0, nw, nh))
+	for i := range base {
+		bv := min(max((base[i]-minVal)*scale, 0), 255)
+		v := min(max(bv+detail[i]*detailStrength, 0), 255)
+
+		// gamma
+		v = math.Pow(v/255.0, gamma)
+		if opts.Invert {
+			v = 1 - v
+		}
+
+		out.SetGray16(i%nw, i/nw, color.Gray16{Y: uint16(v*65535 + 0.5)})
+	}
+
+	return out
+}
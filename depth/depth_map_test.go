@@ -3,8 +3,12 @@ package depth
 import (
 	_ "embed"
 	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
 	"image/png"
 	"log"
+	"math"
 	"os"
 	"testing"
 
@@ -45,3 +49,104 @@ func TestGenerateDepthMap(t *testing.T) {
 		t.Errorf("faild to generate depth map, %v", err)
 	}
 }
+
+func TestConvertToGray16KeepsPrecision(t *testing.T) {
+	src := image.NewGray16(image.Rect(0, 0, 4, 1))
+	for x, v := range []uint16{0, 1, 32769, 65535} {
+		src.SetGray16(x, 0, color.Gray16{Y: v})
+	}
+
+	got := ConvertToGray16(src)
+	for x, want := range []uint16{0, 1, 32769, 65535} {
+		if v := got.Gray16At(x, 0).Y; v != want {
+			t.Fatalf("pixel %d: got %d, want %d", x, v, want)
+		}
+	}
+}
+
+func TestGenerateDepthMap16(t *testing.T) {
+	// 平滑的径向渐变，8 位版本会被量化成少量台阶
+	const size = 640
+	img := image.NewRGBA(image.Rect(0, 0, size, size/2))
+	for y := 0; y < size/2; y++ {
+		for x := 0; x < size; x++ {
+			d := math.Hypot(float64(x-size/2), float64(y-size/4)) / float64(size/2)
+			v := uint8(255 * math.Max(0, 1-d))
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	got := GenerateDepthMap16(img, false)
+	if b := got.Bounds(); b.Dx() != 320 || b.Dy() != 160 {
+		t.Fatalf("unexpected size %v", b)
+	}
+
+	levels := map[uint16]bool{}
+	for y := 0; y < 160; y++ {
+		for x := 0; x < 320; x++ {
+			levels[got.Gray16At(x, y).Y] = true
+		}
+	}
+	if len(levels) <= 256 {
+		t.Fatalf("expected more than 256 height levels, got %d", len(levels))
+	}
+	if got.Gray16At(160, 80).Y < 60000 || got.Gray16At(0, 0).Y != 0 {
+		t.Fatalf("unexpected range: center %d, corner %d", got.Gray16At(160, 80).Y, got.Gray16At(0, 0).Y)
+	}
+
+	inverted := GenerateDepthMap16(img, true)
+	if v := inverted.Gray16At(0, 0).Y; v != 65535 {
+		t.Fatalf("expected inverted corner 65535, got %d", v)
+	}
+}
+
+// detail 算法的 8 位输出与最初的实现逐位一致（该图片边缘为黑色背景，边缘处理不影响结果）
+func TestGenerateDepthMap4MatchesBaseline(t *testing.T) {
+	img, err := util.OpenImage(dawnbreaker)
+	if err != nil {
+		t.Fatalf("open image: %v", err)
+	}
+
+	for invert, want := range map[bool]uint64{false: 0x5f686c9c322db4a8, true: 0x5fd533cd739f7248} {
+		got := GenerateDepthMap4(img, invert)
+		if b := got.Bounds(); b.Dx() != 320 || b.Dy() != 320 {
+			t.Fatalf("invert %v: unexpected size %v", invert, b)
+		}
+		h := fnv.New64a()
+		_, _ = h.Write(got.Pix)
+		if sum := h.Sum64(); sum != want {
+			t.Fatalf("invert %v: output checksum %#x, want %#x", invert, sum, want)
+		}
+	}
+}
+
+func TestDepthMapBorderMatchesInner(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 96, 64))
+	for i := range img.Pix {
+		img.Pix[i] = 160
+	}
+
+	for _, smoothing := range []string{SmoothingNone, SmoothingGuided} {
+		opts := DefaultOptions()
+		opts.BaseSize = 48
+		opts.BackgroundClip = 0
+		opts.Smoothing = smoothing
+		for name, got := range map[string]image.Image{
+			"blur":    generateDepthMap(img, 48, &opts),
+			"scurve":  generateDepthMap2(img, 48, &opts),
+			"stretch": generateDepthMap3(img, 48, &opts),
+			"detail":  generateDepthMap4(img, &opts),
+		} {
+			b := got.Bounds()
+			if b.Dx() != 48 || b.Dy() != 32 {
+				t.Fatalf("%s/%s: unexpected size %v", name, smoothing, b)
+			}
+			inner := got.At(b.Dx()/2, b.Dy()/2)
+			for _, p := range []image.Point{{0, 0}, {b.Dx() - 1, 0}, {0, b.Dy() - 1}, {b.Dx() - 1, b.Dy() - 1}, {b.Dx() / 2, 0}, {0, b.Dy() / 2}} {
+				if v := got.At(p.X, p.Y); v != inner {
+					t.Fatalf("%s/%s: border pixel %v is %v, inner is %v", name, smoothing, p, v, inner)
+				}
+			}
+		}
+	}
+}
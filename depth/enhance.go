@@ -0,0 +1,187 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// 对比度增强：
+//   - 深度估计前，对亮度做 CLAHE（限制对比度的自适应直方图均衡），
+//     低对比度照片中主体与背景的亮度差被拉开；
+//   - 深度估计后，把深度图分解为多个频带（à trous 小波），每个频带乘以各自的增益再合成，
+//     可以单独加强细纹理或中等尺度的起伏。
+// 两步都跳过背景（亮度低于 BackgroundClip 的像素 / 深度图中的背景值）。
+
+const (
+	claheBins     = 256
+	maxDetailBand = 6
+)
+
+// Equalize 按 opts.ClaheLimit 对图片亮度做 CLAHE，色相保持不变；ClaheLimit 为 0 时原样返回
+func Equalize(img image.Image, opts *Options) image.Image {
+	if opts.ClaheLimit <= 0 {
+		return img
+	}
+
+	p := newPlanes(img, opts.BaseSize)
+	lum := luminance(p)
+	floor := float64(opts.BackgroundClip) / 255
+	eq := clahe(lum, p.w, p.h, opts.ClaheTiles, opts.ClaheLimit, floor)
+
+	for i, l := range lum {
+		if l < floor || l <= 0 {
+			continue
+		}
+		k := eq[i] / l
+		p.r[i], p.g[i], p.b[i] = p.r[i]*k, p.g[i]*k, p.b[i]*k
+	}
+	return p.image()
+}
+
+// clahe 把 w×h 的亮度（0~1）分成 tiles×tiles 块，每块做限制对比度的直方图均衡，
+// 块之间双线性插值。低于 floor 的像素不参与统计、保持不变，其余像素映射到 [floor, 1]
+func clahe(lum []float64, w, h, tiles int, limit, floor float64) []float64 {
+	tx, ty := min(tiles, w), min(tiles, h)
+	bin := func(v float64) int {
+		return min(max(int(v*(claheBins-1)+0.5), 0), claheBins-1)
+	}
+
+	// 每块的映射表
+	maps := make([][claheBins]float64, tx*ty)
+	for j := 0; j < ty; j++ {
+		y0, y1 := j*h/ty, (j+1)*h/ty
+		for i := 0; i < tx; i++ {
+			x0, x1 := i*w/tx, (i+1)*w/tx
+			var hist [claheBins]float64
+			var n float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					if v := lum[y*w+x]; v >= floor {
+						hist[bin(v)]++
+						n++
+					}
+				}
+			}
+
+			m := &maps[j*tx+i]
+			if n == 0 {
+				for v := range m {
+					m[v] = float64(v) / (claheBins - 1)
+				}
+				continue
+			}
+
+			// 超过上限的部分均匀分配到所有 bin
+			clip := max(limit*n/claheBins, 1)
+			var excess float64
+			for v := range hist {
+				if hist[v] > clip {
+					excess += hist[v] - clip
+					hist[v] = clip
+				}
+			}
+			var cdf float64
+			for v := range hist {
+				cdf += hist[v] + excess/claheBins
+				m[v] = floor + (1-floor)*cdf/n
+			}
+		}
+	}
+
+	// 以块中心为网格点双线性插值
+	cell := func(pos float64, count, size int) (int, int, float64) {
+		f := pos/(float64(size)/float64(count)) - 0.5
+		i0 := int(math.Floor(f))
+		t := f - float64(i0)
+		i1 := i0 + 1
+		return min(max(i0, 0), count-1), min(max(i1, 0), count-1), t
+	}
+	out := make([]float64, len(lum))
+	for y := 0; y < h; y++ {
+		j0, j1, ty0 := cell(float64(y)+0.5, ty, h)
+		for x := 0; x < w; x++ {
+			v := lum[y*w+x]
+			if v < floor {
+				out[y*w+x] = v
+				continue
+			}
+			i0, i1, tx0 := cell(float64(x)+0.5, tx, w)
+			b := bin(v)
+			top := maps[j0*tx+i0][b]*(1-tx0) + maps[j0*tx+i1][b]*tx0
+			bottom := maps[j1*tx+i0][b]*(1-tx0) + maps[j1*tx+i1][b]*tx0
+			out[y*w+x] = top*(1-ty0) + bottom*ty0
+		}
+	}
+	return out
+}
+
+// BoostDetail 按 opts.DetailGains 放大深度图各频带（第 1 个为最细的一层），输出 16 位深度图；
+// DetailGains 为空时原样返回。背景（深度为 0，invert 时为最大值）保持不变
+func BoostDetail(depthMap image.Image, opts *Options) image.Image {
+	if len(opts.DetailGains) == 0 {
+		return depthMap
+	}
+
+	b := depthMap.Bounds()
+	w, h := b.Dx(), b.Dy()
+	background := 0.0
+	if opts.Invert {
+		background = 1
+	}
+
+	src := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			src[y*w+x] = float64(color.Gray16Model.Convert(depthMap.At(x+b.Min.X, y+b.Min.Y)).(color.Gray16).Y) / 65535
+		}
+	}
+
+	// à trous：第 k 层用间隔 2^k 的 B3 样条核模糊，相邻两层之差即为一个频带
+	out := make([]float64, w*h)
+	level := src
+	for k, gain := range opts.DetailGains {
+		next := atrousBlur(level, w, h, 1<<k)
+		for i := range out {
+			out[i] += gain * (level[i] - next[i])
+		}
+		level = next
+	}
+
+	img := image.NewGray16(image.Rect(0, 0, w, h))
+	for i, v := range src {
+		if v != background {
+			v = min(max(level[i]+out[i], 0), 1)
+		}
+		img.SetGray16(i%w, i/w, color.Gray16{Y: uint16(v*65535 + 0.5)})
+	}
+	return img
+}
+
+// atrousBlur 可分离的 [1 4 6 4 1]/16 核，抽头间隔为 step，边界截断
+func atrousBlur(src []float64, w, h, step int) []float64 {
+	kernel := [5]float64{1.0 / 16, 4.0 / 16, 6.0 / 16, 4.0 / 16, 1.0 / 16}
+	tmp := make([]float64, len(src))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for k, c := range kernel {
+				xx := min(max(x+(k-2)*step, 0), w-1)
+				sum += c * src[y*w+xx]
+			}
+			tmp[y*w+x] = sum
+		}
+	}
+	out := make([]float64, len(src))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for k, c := range kernel {
+				yy := min(max(y+(k-2)*step, 0), h-1)
+				sum += c * tmp[yy*w+x]
+			}
+			out[y*w+x] = sum
+		}
+	}
+	return out
+}
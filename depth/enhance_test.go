@@ -0,0 +1,87 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestEqualize(t *testing.T) {
+	// 黑底上的低对比度主体：亮度在 110~140 之间缓慢变化
+	const w, h = 128, 128
+	src := fillImage(w, h, func(x, y int) uint8 {
+		if x < 8 || x >= w-8 || y < 8 || y >= h-8 {
+			return 0
+		}
+		return uint8(110 + 30*x/w)
+	})
+
+	opts := DefaultOptions()
+	if got := Equalize(src, &opts); got != image.Image(src) {
+		t.Fatalf("claheLimit 0 should return the input unchanged")
+	}
+
+	opts.ClaheLimit = 3
+	opts.ClaheTiles = 2
+	got := Equalize(src, &opts)
+	lum := func(img image.Image, x, y int) float64 {
+		return float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+	}
+
+	if v := lum(got, 2, 2); v != 0 {
+		t.Fatalf("background changed: %v", v)
+	}
+	before := lum(src, w-9, h/2) - lum(src, 8, h/2)
+	after := lum(got, w-9, h/2) - lum(got, 8, h/2)
+	if after < 2*before {
+		t.Fatalf("contrast not increased: %.1f -> %.1f", before, after)
+	}
+	// 前景不会被压到背景阈值以下
+	for x := 8; x < w-8; x++ {
+		if v := lum(got, x, h/2); v < float64(opts.BackgroundClip) {
+			t.Fatalf("foreground pixel %d dropped to %v", x, v)
+		}
+	}
+}
+
+func TestBoostDetail(t *testing.T) {
+	// 缓坡上叠加细条纹
+	const w, h = 64, 32
+	src := image.NewGray16(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 0.3 + 0.4*float64(x)/w + 0.05*math.Sin(float64(x)*math.Pi/2)
+			src.SetGray16(x, y, color.Gray16{Y: uint16(v * 65535)})
+		}
+	}
+	at := func(img image.Image, x, y int) float64 {
+		return float64(color.Gray16Model.Convert(img.At(x, y)).(color.Gray16).Y) / 65535
+	}
+	ripple := func(img image.Image) float64 {
+		var sum float64
+		for x := 8; x < w-8; x++ {
+			sum += math.Abs(at(img, x+1, h/2) - at(img, x, h/2))
+		}
+		return sum
+	}
+
+	opts := DefaultOptions()
+	opts.DetailGains = []float64{1, 1, 1}
+	same := BoostDetail(src, &opts)
+	for x := 0; x < w; x++ {
+		if d := math.Abs(at(same, x, h/2) - at(src, x, h/2)); d > 1e-4 {
+			t.Fatalf("unit gains changed pixel %d by %v", x, d)
+		}
+	}
+
+	opts.DetailGains = []float64{2.5, 2}
+	boosted := BoostDetail(src, &opts)
+	if before, after := ripple(src), ripple(boosted); after < 1.5*before {
+		t.Fatalf("fine detail not boosted: %.3f -> %.3f", before, after)
+	}
+	// 低频的坡度基本不变
+	if d := math.Abs(at(boosted, w-8, h/2) - at(boosted, 8, h/2) - (at(src, w-8, h/2) - at(src, 8, h/2))); d > 0.08 {
+		t.Fatalf("base slope changed by %v", d)
+	}
+}
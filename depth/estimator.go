@@ -0,0 +1,177 @@
+package depth
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+)
+
+// Estimator 深度估计算法：输入图片，输出 8 位或 16 位灰度深度图（越亮越高）
+type Estimator interface {
+	Name() string
+	Description() string
+	Estimate(img image.Image, opts *Options) (image.Image, error)
+}
+
+// 内置算法名称
+const (
+	EstimatorGray     = "gray"     // ConvertToGray16
+	EstimatorBlur     = "blur"     // GenerateDepthMap
+	EstimatorSCurve   = "scurve"   // GenerateDepthMap2
+	EstimatorStretch  = "stretch"  // GenerateDepthMap3
+	EstimatorDetail   = "detail"   // GenerateDepthMap4
+	EstimatorDetail16 = "detail16" // GenerateDepthMap16
+	EstimatorPillow   = "pillow"   // 轮廓膨胀
+	EstimatorPalette  = "palette"  // 按颜色取高度
+	EstimatorLineArt  = "lineart"  // 线稿 / 雕刻
+
+	// DefaultEstimator 与原先固定调用 GenerateDepthMap4 的输出一致，detail16 需显式选择
+	DefaultEstimator = EstimatorDetail
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Estimator{}
+)
+
+// Register 注册深度估计算法，名称重复时 panic
+func Register(e Estimator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := e.Name()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("depth: estimator %q registered twice", name))
+	}
+	registry[name] = e
+}
+
+// Lookup 按名称查找算法
+func Lookup(name string) (Estimator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	e, ok := registry[name]
+	return e, ok
+}
+
+// Estimators 所有已注册的算法，按名称排序
+func Estimators() []Estimator {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]Estimator, 0, len(registry))
+	for _, e := range registry {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
+
+// estimatorFunc 用函数实现 Estimator
+type estimatorFunc struct {
+	name        string
+	description string
+	raw         bool // 直接使用输入（深度图），跳过平滑、CLAHE 和频带增强
+	exact       bool // 输出的深度值就是目标高度，只做平滑，跳过 CLAHE 和频带增强
+	fn          func(img image.Image, opts *Options) (image.Image, error)
+}
+
+func (e estimatorFunc) Name() string        { return e.name }
+func (e estimatorFunc) Description() string { return e.description }
+
+// Estimate opts 为 nil 时使用 DefaultOptions
+func (e estimatorFunc) Estimate(img image.Image, opts *Options) (image.Image, error) {
+	if opts == nil {
+		defaults := DefaultOptions()
+		opts = &defaults
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if e.raw {
+		return e.fn(img, opts)
+	}
+	img = Smooth(img, opts)
+	if e.exact {
+		return e.fn(img, opts)
+	}
+	img = Equalize(img, opts)
+	depthMap, err := e.fn(img, opts)
+	if err != nil {
+		return nil, err
+	}
+	return BoostDetail(depthMap, opts), nil
+}
+
+func init() {
+	Register(estimatorFunc{
+		name:        EstimatorGray,
+		description: "直接使用图片灰度作为深度（上传深度图时使用，忽略 invert、平滑和增强）",
+		raw:         true,
+		fn: func(img image.Image, _ *Options) (image.Image, error) {
+			return ConvertToGray16(img), nil
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorBlur,
+		description: "灰度 + gamma 1.5 + 3x3 高斯模糊",
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generateDepthMap(img, float64(opts.BaseSize), opts), nil
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorSCurve,
+		description: "线性灰度 + 轻度模糊 + S 曲线 + Z 量化",
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generateDepthMap2(img, float64(opts.BaseSize), opts), nil
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorStretch,
+		description: "轻模糊 + 2/98 百分位拉伸 + gamma 0.7",
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generateDepthMap3(img, float64(opts.BaseSize), opts), nil
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorDetail,
+		description: "背景抑制 + 低频拉伸 + Laplacian 细节融合 + Z 量化（8 位）",
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generateDepthMap4(img, opts), nil
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorDetail16,
+		description: "与 detail 相同的流程，浮点计算、不量化，输出 16 位深度图",
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generateDepthMap16(img, opts), nil
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorPillow,
+		description: "轮廓膨胀：按主体轮廓（alpha 通道，无透明时用 backgroundClip）鼓起圆润的高度，按 pillowDetail 叠加亮度细节",
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generatePillowMap(img, opts), nil
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorPalette,
+		description: "按颜色取高度：k-means 量化为 paletteSize 种颜色（或使用 palette），每种颜色对应 paletteHeights 中的高度，结果写回 depthOptions（忽略 CLAHE 和频带增强）",
+		exact:       true,
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generatePaletteMap(img, opts)
+		},
+	})
+	Register(estimatorFunc{
+		name:        EstimatorLineArt,
+		description: "线稿 / 雕刻：用 XDoG 或 Canny（lineMethod）提取线条，在平板上做成宽 lineWidth、高度差 lineDepth 的凸起或凹刻（lineEngrave）线条（忽略 CLAHE 和频带增强）",
+		exact:       true,
+		fn: func(img image.Image, opts *Options) (image.Image, error) {
+			return generateLineArtMap(img, opts), nil
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEstimators(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			v := uint8(x * 4)
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	names := map[string]bool{}
+	for _, e := range Estimators() {
+		names[e.Name()] = true
+		if e.Description() == "" {
+			t.Fatalf("%s: empty description", e.Name())
+		}
+
+		opts := DefaultOptions()
+		got, err := e.Estimate(img, &opts)
+		if err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		if b := got.Bounds(); b.Dx() < 1 || b.Dy() < 1 {
+			t.Fatalf("%s: empty depth map %v", e.Name(), b)
+		}
+	}
+
+	for _, name := range []string{EstimatorGray, EstimatorBlur, EstimatorSCurve, EstimatorStretch, EstimatorDetail, EstimatorDetail16, EstimatorPillow} {
+		if !names[name] {
+			t.Fatalf("estimator %s not registered", name)
+		}
+	}
+	if _, ok := Lookup(DefaultEstimator); !ok {
+		t.Fatalf("default estimator %s not registered", DefaultEstimator)
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Fatal("unexpected estimator nope")
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for duplicate estimator")
+		}
+	}()
+	Register(estimatorFunc{name: EstimatorGray})
+}
+
+func TestEstimateOptions(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 400, 200))
+	e, _ := Lookup(EstimatorDetail16)
+
+	// nil 使用默认参数
+	got, err := e.Estimate(img, nil)
+	if err != nil {
+		t.Fatalf("estimate with defaults: %v", err)
+	}
+	if b := got.Bounds(); b.Dx() != 320 {
+		t.Fatalf("expected default baseSize 320, got %v", b)
+	}
+
+	opts := DefaultOptions()
+	opts.BaseSize = 100
+	if got, err = e.Estimate(img, &opts); err != nil || got.Bounds().Dx() != 100 {
+		t.Fatalf("expected baseSize 100, got %v (%v)", got, err)
+	}
+
+	invalid := []func(o *Options){
+		func(o *Options) { o.BaseSize = 8 },
+		func(o *Options) { o.BackgroundClip = 300 },
+		func(o *Options) { o.DetailStrength = -1 },
+		func(o *Options) { o.Gamma = 0 },
+		func(o *Options) { o.LowPercentile, o.HighPercentile = 50, 50 },
+		func(o *Options) { o.Levels = 1 },
+		func(o *Options) { o.HeightGamma = 10 },
+		func(o *Options) { o.Smoothing = "median" },
+		func(o *Options) { o.SmoothRadius = 0 },
+		func(o *Options) { o.ClaheLimit = 0.5 },
+		func(o *Options) { o.DetailGains = []float64{1, 1, 1, 1, 1, 1, 1} },
+		func(o *Options) { o.DetailGains = []float64{-1} },
+		func(o *Options) { o.PillowDetail = 1.5 },
+		func(o *Options) { o.PaletteSize = 1 },
+		func(o *Options) { o.LineMethod = "sobel" },
+		func(o *Options) { o.LineWidth = 0 },
+		func(o *Options) { o.LineDepth = 0 },
+		func(o *Options) { o.Palette = []string{"#12345g"} },
+		func(o *Options) { o.Palette, o.PaletteHeights = []string{"#000000", "#ffffff"}, []float64{0} },
+		func(o *Options) { o.PaletteHeights = []float64{0, 0, 0, 0, 0, 2} },
+	}
+	for i, mutate := range invalid {
+		opts := DefaultOptions()
+		mutate(&opts)
+		if _, err = e.Estimate(img, &opts); err == nil {
+			t.Fatalf("case %d: expected validation error for %+v", i, opts)
+		}
+	}
+}
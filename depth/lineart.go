@@ -0,0 +1,212 @@
+package depth
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// 线稿 / 雕刻：从图片提取线条（XDoG 或 Canny），在平板上做成凸起或凹刻的线，
+// 线宽 LineWidth、线的高度差 LineDepth 可调。素描、漫画线稿用亮度估计时会变成一堆噪声凸起，
+// 这里只保留干净的线条。透明像素按白色（平板）处理。
+//
+// XDoG（Winnemöller 2012）：锐化的高斯差分再阈值化，线稿中的实心黑块会保留为整块；
+// Canny：只取轮廓，适合照片。
+
+// 线条提取方式
+const (
+	LineXDoG  = "xdog"
+	LineCanny = "canny"
+)
+
+const (
+	xdogSigma     = 1.0
+	xdogK         = 1.6 // 两个高斯的尺度比
+	xdogSharpness = 20  // 高斯差分的锐化系数 p
+	cannySigma    = 1.0
+)
+
+// generateLineArtMap 输出与 BaseSize 同尺度的 16 位深度图
+func generateLineArtMap(img image.Image, opts *Options) *image.Gray16 {
+	p := newPlanes(img, opts.BaseSize)
+	w, h := p.w, p.h
+
+	// 叠加在白底上的亮度
+	lum := luminance(p)
+	for i, v := range lum {
+		lum[i] = v*p.a[i] + 1 - p.a[i]
+	}
+
+	var edges []bool
+	switch opts.LineMethod {
+	case LineCanny:
+		edges = cannyEdges(lum, w, h, opts.LineThreshold)
+	default:
+		edges = xdogEdges(lum, w, h, opts.LineThreshold)
+	}
+	coverage := strokeCoverage(edges, w, h, opts.LineWidth)
+
+	// 凸起：平板为 0，线高 LineDepth；凹刻：平板为 1，线低 LineDepth
+	height := make([]float64, w*h)
+	for i, c := range coverage {
+		if opts.LineEngrave {
+			height[i] = 1 - opts.LineDepth*c
+		} else {
+			height[i] = opts.LineDepth * c
+		}
+	}
+	return heightGray16(height, w, h, opts.Invert)
+}
+
+// xdogEdges 阈值化的 XDoG，threshold 越大线条越多
+func xdogEdges(lum []float64, w, h int, threshold float64) []bool {
+	g1 := gaussianBlur(lum, w, h, xdogSigma)
+	g2 := gaussianBlur(lum, w, h, xdogSigma*xdogK)
+	edges := make([]bool, w*h)
+	for i := range edges {
+		edges[i] = (1+xdogSharpness)*g1[i]-xdogSharpness*g2[i] < threshold
+	}
+	return edges
+}
+
+// cannyEdges Canny 边缘：高斯模糊、Sobel、非极大值抑制、双阈值连接。
+// 高阈值取梯度 99 分位的 0.8×(1-threshold) 倍，低阈值为高阈值的 0.4 倍
+func cannyEdges(lum []float64, w, h int, threshold float64) []bool {
+	blurred := gaussianBlur(lum, w, h, cannySigma)
+	at := func(x, y int) float64 {
+		return blurred[min(max(y, 0), h-1)*w+min(max(x, 0), w-1)]
+	}
+
+	mag := make([]float64, w*h)
+	dir := make([]uint8, w*h) // 0 水平、1 45°、2 竖直、3 135°
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			i := y*w + x
+			mag[i] = math.Hypot(gx, gy)
+			angle := math.Atan2(gy, gx) * 180 / math.Pi
+			if angle < 0 {
+				angle += 180
+			}
+			dir[i] = uint8(int((angle+22.5)/45) % 4)
+		}
+	}
+
+	// 非极大值抑制：沿梯度方向比较两侧
+	offsets := [4][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}}
+	thin := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			o := offsets[dir[i]]
+			neighbour := func(dx, dy int) float64 {
+				xx, yy := x+dx, y+dy
+				if xx < 0 || yy < 0 || xx >= w || yy >= h {
+					return 0
+				}
+				return mag[yy*w+xx]
+			}
+			if mag[i] >= neighbour(o[0], o[1]) && mag[i] > neighbour(-o[0], -o[1]) {
+				thin[i] = mag[i]
+			}
+		}
+	}
+
+	sorted := append([]float64(nil), mag...)
+	sort.Float64s(sorted)
+	high := max(0.8*sorted[len(sorted)*99/100]*(1-threshold), 1e-3)
+	low := high * 0.4
+
+	// 从强边缘出发，沿 8 邻域连接弱边缘
+	edges := make([]bool, w*h)
+	var stack []int
+	for i, v := range thin {
+		if v >= high {
+			edges[i] = true
+			stack = append(stack, i)
+		}
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := i%w, i/w
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				xx, yy := x+dx, y+dy
+				if xx < 0 || yy < 0 || xx >= w || yy >= h {
+					continue
+				}
+				if j := yy*w + xx; !edges[j] && thin[j] >= low {
+					edges[j] = true
+					stack = append(stack, j)
+				}
+			}
+		}
+	}
+	return edges
+}
+
+// strokeCoverage 以线条像素为中心画宽 width 的线，边缘 1 像素抗锯齿，返回 0~1 的覆盖率
+func strokeCoverage(edges []bool, w, h int, width float64) []float64 {
+	// distanceTransform 把图片外部视为线条，四周补一圈足够宽的空白
+	pad := int(math.Ceil(width)) + 2
+	pw, ph := w+2*pad, h+2*pad
+	empty := make([]bool, pw*ph)
+	for i := range empty {
+		empty[i] = true
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			empty[(y+pad)*pw+x+pad] = !edges[y*w+x]
+		}
+	}
+	dist := distanceTransform(empty, pw, ph)
+
+	coverage := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// 线条像素自身的距离为 0，像素中心到线条中心再减半个像素
+			d := dist[(y+pad)*pw+x+pad]
+			coverage[y*w+x] = min(max(width/2+0.5-d, 0), 1)
+		}
+	}
+	return coverage
+}
+
+// gaussianBlur 可分离高斯模糊，边缘像素重复
+func gaussianBlur(src []float64, w, h int, sigma float64) []float64 {
+	r := max(1, int(math.Ceil(3*sigma)))
+	kernel := make([]float64, 2*r+1)
+	var total float64
+	for k := range kernel {
+		d := float64(k - r)
+		kernel[k] = math.Exp(-d * d / (2 * sigma * sigma))
+		total += kernel[k]
+	}
+	for k := range kernel {
+		kernel[k] /= total
+	}
+
+	tmp := make([]float64, w*h)
+	out := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for k, v := range kernel {
+				sum += v * src[y*w+min(max(x+k-r, 0), w-1)]
+			}
+			tmp[y*w+x] = sum
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum float64
+			for k, v := range kernel {
+				sum += v * tmp[min(max(y+k-r, 0), h-1)*w+x]
+			}
+			out[y*w+x] = sum
+		}
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestStrokeCoverage(t *testing.T) {
+	const w, h = 20, 9
+	edges := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		edges[y*w+10] = true
+	}
+
+	coverage := strokeCoverage(edges, w, h, 3)
+	for x, want := range map[int]float64{10: 1, 11: 1, 12: 0, 9: 1, 8: 0} {
+		if got := coverage[4*w+x]; math.Abs(got-want) > 1e-9 {
+			t.Fatalf("x=%d: got %v want %v", x, got, want)
+		}
+	}
+	// 图片边缘不应出现线条
+	if coverage[0] != 0 || coverage[w*h-1] != 0 {
+		t.Fatalf("border should be empty: %v %v", coverage[0], coverage[w*h-1])
+	}
+}
+
+// lineArtImage 白底上一条竖直细线和一块黑色方块
+func lineArtImage() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 96, 64))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for y := 8; y < 56; y++ {
+		img.SetGray(20, y, color.Gray{})
+		for x := 50; x < 80; x++ {
+			if y >= 16 && y < 48 {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+	return img
+}
+
+func TestLineArtEstimator(t *testing.T) {
+	img := lineArtImage()
+	for _, method := range []string{LineXDoG, LineCanny} {
+		opts := DefaultOptions()
+		opts.LineMethod = method
+		opts.LineDepth = 0.5
+		out := generateLineArtMap(img, &opts)
+		at := func(x, y int) float64 { return float64(out.Gray16At(x, y).Y) / 65535 }
+
+		// Canny 把细线的两侧各当作一条边，中心不一定是满高
+		if got := at(20, 32); got == 0 || method == LineXDoG && math.Abs(got-0.5) > 1e-3 {
+			t.Fatalf("%s: line height %v, want 0.5", method, got)
+		}
+		for _, p := range [][2]int{{0, 0}, {35, 32}, {95, 63}, {20, 62}} {
+			if got := at(p[0], p[1]); got != 0 {
+				t.Fatalf("%s: plate at %v should be 0, got %v", method, p, got)
+			}
+		}
+		if got := at(50, 32); got == 0 {
+			t.Fatalf("%s: square edge missing", method)
+		}
+
+		// Canny 只取方块轮廓，XDoG 保留整块
+		inside := at(65, 32)
+		if method == LineCanny && inside != 0 || method == LineXDoG && inside == 0 {
+			t.Fatalf("%s: unexpected square interior %v", method, inside)
+		}
+
+		opts.LineEngrave = true
+		out = generateLineArtMap(img, &opts)
+		if got, plate := at(20, 32), at(35, 32); got >= 1 || plate != 1 {
+			t.Fatalf("%s: engraved line %v plate %v", method, got, plate)
+		}
+	}
+}
+
+func TestLineArtEstimatorIgnoresEnhancement(t *testing.T) {
+	opts := DefaultOptions()
+	opts.LineDepth = 0.5
+	want := generateLineArtMap(lineArtImage(), &opts)
+
+	// CLAHE 和频带增强会改变线条提取的输入和 lineDepth 对应的高度
+	opts.ClaheLimit = 4
+	opts.DetailGains = []float64{3, 2}
+	e, _ := Lookup(EstimatorLineArt)
+	got, err := e.Estimate(lineArtImage(), &opts)
+	if err != nil {
+		t.Fatalf("estimate: %v", err)
+	}
+	gray := got.(*image.Gray16)
+	for i := range want.Pix {
+		if gray.Pix[i] != want.Pix[i] {
+			t.Fatalf("byte %d: got %d, want %d", i, gray.Pix[i], want.Pix[i])
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/chaos-io/depth2STL/util/poisson"
+	"golang.org/x/image/draw"
+)
+
+// 法线贴图积分：切线空间法线贴图（RGB = (n+1)/2，OpenGL 约定 Y 向上）换算成梯度，
+// 再用泊松方程最小二乘积分出高度（Frankot–Chellappa）。
+// 可选的彩色图经 detail16 估计后按 NormalDetail 混入，补充法线贴图中没有的细节。
+
+// minNormalZ 法线 Z 分量的下限，限制单个像素的最大坡度
+const minNormalZ = 0.1
+
+// IntegrateNormals 从法线贴图重建 16 位深度图，colour 可为 nil。
+// 长边超过 opts.BaseSize 时先缩小；opts.DetailGains 同样生效
+func IntegrateNormals(normal, colour image.Image, opts *Options) (image.Image, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := newPlanes(normal, opts.BaseSize)
+	w, h := p.w, p.h
+
+	// 每个像素的坡度 dz/dx、dz/dy（图像 y 向下）
+	sx := make([]float64, w*h)
+	sy := make([]float64, w*h)
+	for i := range sx {
+		ny := 2*p.g[i] - 1
+		if opts.NormalFlipY {
+			ny = -ny
+		}
+		sx[i], sy[i] = normalSlope(2*p.r[i]-1, ny, 2*p.b[i]-1)
+	}
+	height := integrateSlopes(sx, sy, w, h)
+
+	if colour != nil {
+		lumOpts := *opts
+		lumOpts.Invert = false
+		estimated := generateDepthMap16(colour, &lumOpts)
+		detail := image.NewGray16(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(detail, detail.Bounds(), estimated, estimated.Bounds(), draw.Src, nil)
+		for i, v := range height {
+			d := float64(detail.Gray16At(i%w, i/w).Y) / 65535
+			height[i] = (1-opts.NormalDetail)*v + opts.NormalDetail*d
+		}
+	}
+	return heightImage(height, w, h, opts), nil
+}
+
+// normalSlope 法线（x 向右、y 向上、z 朝向相机）对应的坡度 dz/dx、dz/dy（图像 y 向下）
+func normalSlope(nx, ny, nz float64) (float64, float64) {
+	nz = max(nz, minNormalZ)
+	return -nx / nz, ny / nz
+}
+
+// integrateSlopes 相邻两像素坡度的平均作为前向差分，积分后归一化到 0~1
+func integrateSlopes(sx, sy []float64, w, h int) []float64 {
+	gx := make([]float64, w*h)
+	gy := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if x < w-1 {
+				gx[i] = (sx[i] + sx[i+1]) / 2
+			}
+			if y < h-1 {
+				gy[i] = (sy[i] + sy[i+w]) / 2
+			}
+		}
+	}
+	height := poisson.Integrate(gx, gy, w, h)
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range height {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	span := max(hi-lo, 1e-9)
+	for i, v := range height {
+		height[i] = (v - lo) / span
+	}
+	return height
+}
+
+// heightImage 0~1 的高度转为 16 位深度图，处理 invert 和 DetailGains
+func heightImage(height []float64, w, h int, opts *Options) image.Image {
+	return BoostDetail(heightGray16(height, w, h, opts.Invert), opts)
+}
+
+// heightGray16 0~1 的高度转为 16 位深度图。注册为 Estimator 的算法用它，频带增强由 Estimate 统一处理
+func heightGray16(height []float64, w, h int, invert bool) *image.Gray16 {
+	out := image.NewGray16(image.Rect(0, 0, w, h))
+	for i, v := range height {
+		if invert {
+			v = 1 - v
+		}
+		out.SetGray16(i%w, i/w, color.Gray16{Y: uint16(min(max(v, 0), 1)*65535 + 0.5)})
+	}
+	return out
+}
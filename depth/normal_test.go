@@ -0,0 +1,95 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// bumpNormals 高斯凸起的切线空间法线贴图（OpenGL 约定）
+func bumpNormals(w, h int, flipY bool) (*image.NRGBA, []float64) {
+	height := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := float64(x-w/2), float64(y-h/2)
+			height[y*w+x] = 12 * math.Exp(-(dx*dx+dy*dy)/150)
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	at := func(x, y int) float64 {
+		return height[min(max(y, 0), h-1)*w+min(max(x, 0), w-1)]
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// 图像 y 向下，法线的 Y 分量向上
+			sx := (at(x+1, y) - at(x-1, y)) / 2
+			sy := (at(x, y+1) - at(x, y-1)) / 2
+			nx, ny, nz := -sx, sy, 1.0
+			if flipY {
+				ny = -ny
+			}
+			l := math.Sqrt(nx*nx + ny*ny + nz*nz)
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8((nx/l + 1) / 2 * 255),
+				G: uint8((ny/l + 1) / 2 * 255),
+				B: uint8((nz/l + 1) / 2 * 255),
+				A: 255,
+			})
+		}
+	}
+	return img, height
+}
+
+func TestIntegrateNormals(t *testing.T) {
+	const w, h = 64, 48
+	for _, flipY := range []bool{false, true} {
+		normal, height := bumpNormals(w, h, flipY)
+		opts := DefaultOptions()
+		opts.NormalFlipY = flipY
+
+		got, err := IntegrateNormals(normal, nil, &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := got.Bounds(); b.Dx() != w || b.Dy() != h {
+			t.Fatalf("unexpected size %v", b)
+		}
+
+		// 与原高度场（归一化到 0~1）比较
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, v := range height {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		var worst float64
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := float64(color.Gray16Model.Convert(got.At(x, y)).(color.Gray16).Y) / 65535
+				worst = max(worst, math.Abs(v-(height[y*w+x]-lo)/(hi-lo)))
+			}
+		}
+		if worst > 0.05 {
+			t.Fatalf("flipY=%t: reconstruction error %.3f", flipY, worst)
+		}
+	}
+
+	// 彩色图按 normalDetail 混入：左暗右亮的彩色图让右侧整体高于左侧
+	normal, _ := bumpNormals(w, h, false)
+	ramp := fillImage(w, h, func(x, y int) uint8 { return uint8(x * 4) })
+	opts := DefaultOptions()
+	plain, _ := IntegrateNormals(normal, nil, &opts)
+	blended, err := IntegrateNormals(normal, ramp, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tilt := func(img image.Image) float64 {
+		at := func(x int) float64 {
+			return float64(color.Gray16Model.Convert(img.At(x, 2)).(color.Gray16).Y) / 65535
+		}
+		return at(w-3) - at(2)
+	}
+	if tilt(blended) < tilt(plain)+0.1 {
+		t.Fatalf("color detail not blended: tilt %.3f vs %.3f", tilt(blended), tilt(plain))
+	}
+}
@@ -0,0 +1,146 @@
+package depth
+
+import (
+	"fmt"
+)
+
+// Options 深度估计参数，零值不可用，应从 DefaultOptions 开始修改
+type Options struct {
+	Invert         bool      `json:"invert"`                   // 反转浮雕（亮处变低）
+	BaseSize       int       `json:"baseSize"`                 // 处理分辨率：缩放后长边的像素数
+	BackgroundClip int       `json:"backgroundClip"`           // 灰度低于该值（0~255）视为背景，高度置 0
+	DetailStrength float64   `json:"detailStrength"`           // Laplacian 细节叠加的强度
+	Gamma          float64   `json:"gamma"`                    // 深度图的 gamma，小于 1 提亮暗部
+	LowPercentile  float64   `json:"lowPercentile"`            // 对比度拉伸的下百分位
+	HighPercentile float64   `json:"highPercentile"`           // 对比度拉伸的上百分位
+	Levels         int       `json:"levels"`                   // Z 量化台阶数（只影响 8 位算法）
+	HeightGamma    float64   `json:"heightGamma"`              // 建网格时深度到高度的映射 pow(z, HeightGamma)
+	Smoothing      string    `json:"smoothing"`                // 深度估计前的保边平滑：none、bilateral、guided
+	SmoothRadius   int       `json:"smoothRadius"`             // 平滑窗口半径（像素，按 BaseSize 分辨率）
+	SmoothStrength float64   `json:"smoothStrength"`           // 平滑强度：视为同一区域的颜色差（0~255 标度）
+	ClaheLimit     float64   `json:"claheLimit"`               // CLAHE 对比度上限（直方图 bin 高度相对均值的倍数），0 为关闭
+	ClaheTiles     int       `json:"claheTiles"`               // CLAHE 每个方向的分块数
+	DetailGains    []float64 `json:"detailGains,omitempty"`    // 深度图各频带的增益，从细到粗，空为关闭
+	PillowDetail   float64   `json:"pillowDetail"`             // pillow 算法中亮度细节所占的比例（0 为纯轮廓膨胀）
+	NormalFlipY    bool      `json:"normalFlipY"`              // 法线贴图为 DirectX 约定（绿色通道 Y 向下）
+	NormalDetail   float64   `json:"normalDetail"`             // 法线积分时彩色图细节所占的比例
+	LightElevation float64   `json:"lightElevation"`           // 光度立体未给出光源方向时假定的光源仰角（度）
+	MaxDisparity   int       `json:"maxDisparity"`             // 立体匹配的最大视差（像素，按 BaseSize 分辨率）
+	StereoSwap     bool      `json:"stereoSwap"`               // 交换左右图（交叉式并排图）
+	PaletteSize    int       `json:"paletteSize"`              // palette 算法自动量化的颜色数
+	Palette        []string  `json:"palette,omitempty"`        // palette 算法的调色板（#rrggbb），为空时自动量化
+	PaletteHeights []float64 `json:"paletteHeights,omitempty"` // 调色板每种颜色的高度（0~1），为空时按亮度顺序均匀分配
+	LineMethod     string    `json:"lineMethod"`               // lineart 算法的线条提取方式：xdog、canny
+	LineThreshold  float64   `json:"lineThreshold"`            // 线条提取的灵敏度，越大线条越多
+	LineWidth      float64   `json:"lineWidth"`                // 线宽（像素，按 BaseSize 分辨率）
+	LineDepth      float64   `json:"lineDepth"`                // 线与平板的高度差（0~1，相对最大浮雕高度）
+	LineEngrave    bool      `json:"lineEngrave"`              // 线条凹刻进平板，而不是凸起
+}
+
+// DefaultOptions 与原先硬编码的参数一致
+func DefaultOptions() Options {
+	return Options{
+		BaseSize:       320,
+		BackgroundClip: 8,
+		DetailStrength: 0.6,
+		Gamma:          0.7,
+		LowPercentile:  2,
+		HighPercentile: 98,
+		Levels:         36,
+		HeightGamma:    0.7,
+		Smoothing:      SmoothingNone,
+		SmoothRadius:   4,
+		SmoothStrength: 20,
+		ClaheTiles:     8,
+		PillowDetail:   0.3,
+		NormalDetail:   0.2,
+		LightElevation: 45,
+		MaxDisparity:   64,
+		PaletteSize:    6,
+		LineMethod:     LineXDoG,
+		LineThreshold:  0.5,
+		LineWidth:      2,
+		LineDepth:      1,
+	}
+}
+
+// Validate 检查参数是否在合理范围内
+func (o *Options) Validate() error {
+	switch {
+	case o.BaseSize < 32 || o.BaseSize > 4096:
+		return fmt.Errorf("baseSize must be in [32, 4096], got %d", o.BaseSize)
+	case o.BackgroundClip < 0 || o.BackgroundClip > 255:
+		return fmt.Errorf("backgroundClip must be in [0, 255], got %d", o.BackgroundClip)
+	case o.DetailStrength < 0 || o.DetailStrength > 4:
+		return fmt.Errorf("detailStrength must be in [0, 4], got %v", o.DetailStrength)
+	case o.Gamma < 0.1 || o.Gamma > 5:
+		return fmt.Errorf("gamma must be in [0.1, 5], got %v", o.Gamma)
+	case o.LowPercentile < 0 || o.LowPercentile > 50:
+		return fmt.Errorf("lowPercentile must be in [0, 50], got %v", o.LowPercentile)
+	case o.HighPercentile < 50 || o.HighPercentile > 100:
+		return fmt.Errorf("highPercentile must be in [50, 100], got %v", o.HighPercentile)
+	case o.HighPercentile <= o.LowPercentile:
+		return fmt.Errorf("highPercentile must be greater than lowPercentile")
+	case o.Levels < 2 || o.Levels > 256:
+		return fmt.Errorf("levels must be in [2, 256], got %d", o.Levels)
+	case o.HeightGamma < 0.1 || o.HeightGamma > 5:
+		return fmt.Errorf("heightGamma must be in [0.1, 5], got %v", o.HeightGamma)
+	case o.Smoothing != SmoothingNone && o.Smoothing != SmoothingBilateral && o.Smoothing != SmoothingGuided:
+		return fmt.Errorf("smoothing must be one of none, bilateral, guided, got %q", o.Smoothing)
+	case o.SmoothRadius < 1 || o.SmoothRadius > 32:
+		return fmt.Errorf("smoothRadius must be in [1, 32], got %d", o.SmoothRadius)
+	case o.SmoothStrength < 1 || o.SmoothStrength > 255:
+		return fmt.Errorf("smoothStrength must be in [1, 255], got %v", o.SmoothStrength)
+	case o.ClaheLimit != 0 && (o.ClaheLimit < 1 || o.ClaheLimit > 40):
+		return fmt.Errorf("claheLimit must be 0 or in [1, 40], got %v", o.ClaheLimit)
+	case o.ClaheTiles < 1 || o.ClaheTiles > 64:
+		return fmt.Errorf("claheTiles must be in [1, 64], got %d", o.ClaheTiles)
+	case o.PillowDetail < 0 || o.PillowDetail > 1:
+		return fmt.Errorf("pillowDetail must be in [0, 1], got %v", o.PillowDetail)
+	case o.NormalDetail < 0 || o.NormalDetail > 1:
+		return fmt.Errorf("normalDetail must be in [0, 1], got %v", o.NormalDetail)
+	case o.LightElevation < 5 || o.LightElevation > 85:
+		return fmt.Errorf("lightElevation must be in [5, 85], got %v", o.LightElevation)
+	case o.MaxDisparity < 4 || o.MaxDisparity > 256:
+		return fmt.Errorf("maxDisparity must be in [4, 256], got %d", o.MaxDisparity)
+	case o.PaletteSize < 2 || o.PaletteSize > maxPaletteSize:
+		return fmt.Errorf("paletteSize must be in [2, %d], got %d", maxPaletteSize, o.PaletteSize)
+	case len(o.Palette) > maxPaletteSize:
+		return fmt.Errorf("palette must have at most %d colors, got %d", maxPaletteSize, len(o.Palette))
+	case len(o.Palette) > 0 && len(o.PaletteHeights) > 0 && len(o.PaletteHeights) != len(o.Palette):
+		return fmt.Errorf("paletteHeights must have one height per palette color")
+	case len(o.Palette) == 0 && len(o.PaletteHeights) > 0 && len(o.PaletteHeights) != o.PaletteSize:
+		return fmt.Errorf("paletteHeights must have paletteSize heights")
+	case o.LineMethod != LineXDoG && o.LineMethod != LineCanny:
+		return fmt.Errorf("lineMethod must be one of xdog, canny, got %q", o.LineMethod)
+	case o.LineThreshold < 0.05 || o.LineThreshold > 0.95:
+		return fmt.Errorf("lineThreshold must be in [0.05, 0.95], got %v", o.LineThreshold)
+	case o.LineWidth < 0.5 || o.LineWidth > 32:
+		return fmt.Errorf("lineWidth must be in [0.5, 32], got %v", o.LineWidth)
+	case o.LineDepth <= 0 || o.LineDepth > 1:
+		return fmt.Errorf("lineDepth must be in (0, 1], got %v", o.LineDepth)
+	case len(o.DetailGains) > maxDetailBand:
+		return fmt.Errorf("detailGains must have at most %d bands, got %d", maxDetailBand, len(o.DetailGains))
+	}
+	for _, c := range o.Palette {
+		if _, _, _, err := parseHexColor(c); err != nil {
+			return err
+		}
+	}
+	for _, v := range o.PaletteHeights {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("paletteHeights must be in [0, 1], got %v", v)
+		}
+	}
+	for _, g := range o.DetailGains {
+		if g < 0 || g > 8 {
+			return fmt.Errorf("detailGains must be in [0, 8], got %v", g)
+		}
+	}
+	return nil
+}
+
+// percentileCuts 直方图拉伸时的下、上截断像素数
+func (o *Options) percentileCuts(total int) (lowCut, highCut int) {
+	return int(float64(total) * o.LowPercentile / 100), int(float64(total) * o.HighPercentile / 100)
+}
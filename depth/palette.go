@@ -0,0 +1,234 @@
+package depth
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 按颜色取高度：把图片量化为若干调色板颜色（k-means 或用户给定），每种颜色对应一个高度，
+// 适合 logo、旗帜、像素画等亮度相同但颜色不同的区域需要分开的图片。
+// 颜色距离在 CIELAB 空间计算；alpha < 0.5 的像素为背景，高度为 0。
+// 未给出调色板或高度时，估计结果写回 opts.Palette / opts.PaletteHeights，
+// 用户可以在任务结果中修改后重新提交。
+
+const (
+	maxPaletteSize   = 32
+	kmeansIterations = 20
+	kmeansSamples    = 20000 // k-means 最多使用的采样像素数
+)
+
+type lab [3]float64
+
+// parseHexColor 解析 "#rrggbb" 或 "rrggbb"
+func parseHexColor(s string) (r, g, b uint8, err error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color %q", s)
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), nil
+}
+
+func hexColor(c lab) string {
+	r, g, b := labToRGB(c)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// rgbToLab sRGB（0~1）转 CIELAB（D65）
+func rgbToLab(r, g, b float64) lab {
+	linear := func(c float64) float64 {
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	r, g, b = linear(r), linear(g), linear(b)
+	x := (0.4124*r + 0.3576*g + 0.1805*b) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*b
+	z := (0.0193*r + 0.1192*g + 0.9505*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return lab{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// labToRGB CIELAB 转 8 位 sRGB
+func labToRGB(c lab) (uint8, uint8, uint8) {
+	fy := (c[0] + 16) / 116
+	fx := fy + c[1]/500
+	fz := fy - c[2]/200
+	inv := func(t float64) float64 {
+		if t3 := t * t * t; t3 > 216.0/24389 {
+			return t3
+		}
+		return (116*t - 16) * 27 / 24389
+	}
+	x, y, z := inv(fx)*0.95047, inv(fy), inv(fz)*1.08883
+
+	gamma := func(c float64) uint8 {
+		if c <= 0.0031308 {
+			c *= 12.92
+		} else {
+			c = 1.055*math.Pow(c, 1/2.4) - 0.055
+		}
+		return uint8(min(max(c, 0), 1)*255 + 0.5)
+	}
+	return gamma(3.2406*x - 1.5372*y - 0.4986*z),
+		gamma(-0.9689*x + 1.8758*y + 0.0415*z),
+		gamma(0.0557*x - 0.2040*y + 1.0570*z)
+}
+
+func labDistance(a, b lab) float64 {
+	d0, d1, d2 := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return d0*d0 + d1*d1 + d2*d2
+}
+
+func nearestColor(c lab, palette []lab) int {
+	best, bestDist := 0, math.Inf(1)
+	for k, p := range palette {
+		if d := labDistance(c, p); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// kmeans k-means++ 初始化，随机数种子固定，同一张图片结果稳定
+func kmeans(pixels []lab, k int) []lab {
+	rng := rand.New(rand.NewSource(1))
+	if len(pixels) > kmeansSamples {
+		sampled := make([]lab, kmeansSamples)
+		for i := range sampled {
+			sampled[i] = pixels[rng.Intn(len(pixels))]
+		}
+		pixels = sampled
+	}
+	k = min(k, len(pixels))
+	if k == 0 {
+		return nil
+	}
+
+	centers := []lab{pixels[rng.Intn(len(pixels))]}
+	dist := make([]float64, len(pixels))
+	for len(centers) < k {
+		var total float64
+		for i, p := range pixels {
+			dist[i] = labDistance(p, centers[nearestColor(p, centers)])
+			total += dist[i]
+		}
+		if total == 0 {
+			break // 颜色数少于 k
+		}
+		target := rng.Float64() * total
+		i := 0
+		for ; i < len(pixels)-1 && target > dist[i]; i++ {
+			target -= dist[i]
+		}
+		centers = append(centers, pixels[i])
+	}
+
+	assign := make([]int, len(pixels))
+	for iter := 0; iter < kmeansIterations; iter++ {
+		sums := make([]lab, len(centers))
+		counts := make([]int, len(centers))
+		changed := false
+		for i, p := range pixels {
+			k := nearestColor(p, centers)
+			if iter == 0 || k != assign[i] {
+				changed = true
+			}
+			assign[i] = k
+			counts[k]++
+			for c := range p {
+				sums[k][c] += p[c]
+			}
+		}
+		for k := range centers {
+			if counts[k] > 0 {
+				for c := range sums[k] {
+					centers[k][c] = sums[k][c] / float64(counts[k])
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	// 按亮度从暗到亮排序
+	sort.Slice(centers, func(i, j int) bool { return centers[i][0] < centers[j][0] })
+	return centers
+}
+
+// generatePaletteMap 量化并按颜色取高度，opts.Palette / PaletteHeights 为空时写回估计值。
+// 只给出 paletteSize 个高度而图片的颜色数少于 paletteSize 时，高度无法与颜色对应，返回错误
+func generatePaletteMap(img image.Image, opts *Options) (*image.Gray16, error) {
+	p := newPlanes(img, opts.BaseSize)
+	w, h := p.w, p.h
+
+	pixels := make([]lab, w*h)
+	foreground := make([]lab, 0, w*h)
+	for i := range pixels {
+		if p.a[i] < 0.5 {
+			continue
+		}
+		pixels[i] = rgbToLab(p.r[i], p.g[i], p.b[i])
+		foreground = append(foreground, pixels[i])
+	}
+
+	var palette []lab
+	if len(opts.Palette) > 0 {
+		for _, s := range opts.Palette {
+			r, g, b, _ := parseHexColor(s) // Validate 已检查格式
+			palette = append(palette, rgbToLab(float64(r)/255, float64(g)/255, float64(b)/255))
+		}
+	} else {
+		palette = kmeans(foreground, opts.PaletteSize)
+		if len(opts.PaletteHeights) > 0 && len(palette) != len(opts.PaletteHeights) {
+			return nil, fmt.Errorf("image has only %d distinct colors but paletteHeights has %d heights, set paletteSize to %d or give palette explicitly",
+				len(palette), len(opts.PaletteHeights), len(palette))
+		}
+		opts.Palette = make([]string, len(palette))
+		for k, c := range palette {
+			opts.Palette[k] = hexColor(c)
+		}
+	}
+
+	// 默认按亮度顺序均匀分配高度：最暗为 0，最亮为 1
+	if len(opts.PaletteHeights) == 0 {
+		order := make([]int, len(palette))
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(i, j int) bool { return palette[order[i]][0] < palette[order[j]][0] })
+		opts.PaletteHeights = make([]float64, len(palette))
+		for rank, k := range order {
+			if len(palette) > 1 {
+				opts.PaletteHeights[k] = roundTo(float64(rank)/float64(len(palette)-1), 100)
+			}
+		}
+	}
+
+	height := make([]float64, w*h)
+	if len(palette) > 0 {
+		for i, c := range pixels {
+			if p.a[i] >= 0.5 {
+				height[i] = opts.PaletteHeights[nearestColor(c, palette)]
+			}
+		}
+	}
+	return heightGray16(height, w, h, opts.Invert), nil
+}
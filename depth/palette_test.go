@@ -0,0 +1,109 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// paletteImage 透明底上左红右绿两块，两种颜色亮度接近
+func paletteImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	for y := 4; y < 28; y++ {
+		for x := 4; x < 60; x++ {
+			col := color.NRGBA{R: 220, G: 40, B: 40, A: 255}
+			if x >= 32 {
+				col = color.NRGBA{R: 40, G: 120, B: 40, A: 255}
+			}
+			img.SetNRGBA(x, y, col)
+		}
+	}
+	return img
+}
+
+func TestLabRoundTrip(t *testing.T) {
+	for _, s := range []string{"#000000", "#ffffff", "#ff0000", "#00ff00", "#0000ff", "#7f3a12", "#c0c0c0"} {
+		r, g, b, err := parseHexColor(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hexColor(rgbToLab(float64(r)/255, float64(g)/255, float64(b)/255)); got != s {
+			t.Fatalf("round trip %s: got %s", s, got)
+		}
+	}
+	if _, _, _, err := parseHexColor("#ff00"); err == nil {
+		t.Fatalf("expected error for short color")
+	}
+}
+
+func TestPaletteEstimator(t *testing.T) {
+	opts := DefaultOptions()
+	opts.PaletteSize = 2
+	gray, err := generatePaletteMap(paletteImage(), &opts)
+	if err != nil {
+		t.Fatalf("palette map: %v", err)
+	}
+
+	if len(opts.Palette) != 2 || len(opts.PaletteHeights) != 2 {
+		t.Fatalf("palette not written back: %v %v", opts.Palette, opts.PaletteHeights)
+	}
+	bg, red, green := gray.Gray16At(1, 1).Y, gray.Gray16At(10, 16).Y, gray.Gray16At(50, 16).Y
+	if bg != 0 {
+		t.Fatalf("transparent background should be 0, got %d", bg)
+	}
+	if red == green {
+		t.Fatalf("different colors should get different heights, both %d", red)
+	}
+
+	// 用户给定调色板和高度
+	opts.Palette = []string{"#dc2828", "#287828"}
+	opts.PaletteHeights = []float64{0.25, 1}
+	if gray, err = generatePaletteMap(paletteImage(), &opts); err != nil {
+		t.Fatalf("palette map: %v", err)
+	}
+	if got := gray.Gray16At(10, 16).Y; got != 16384 {
+		t.Fatalf("red height: got %d", got)
+	}
+	if got := gray.Gray16At(50, 16).Y; got != 65535 {
+		t.Fatalf("green height: got %d", got)
+	}
+}
+
+func TestPaletteEstimatorIgnoresEnhancement(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Palette = []string{"#dc2828", "#287828"}
+	opts.PaletteHeights = []float64{0.25, 1}
+	opts.ClaheLimit = 4
+	opts.DetailGains = []float64{3, 2}
+
+	e, _ := Lookup(EstimatorPalette)
+	got, err := e.Estimate(paletteImage(), &opts)
+	if err != nil {
+		t.Fatalf("estimate: %v", err)
+	}
+	// 只能出现背景和两种颜色的高度，频带增强会在色块边缘产生其它高度
+	gray := got.(*image.Gray16)
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			if v := gray.Gray16At(x, y).Y; v != 0 && v != 16384 && v != 65535 {
+				t.Fatalf("pixel (%d, %d): unexpected height %d", x, y, v)
+			}
+		}
+	}
+	if v := gray.Gray16At(10, 16).Y; v != 16384 {
+		t.Fatalf("red height: got %d, want 16384", v)
+	}
+}
+
+func TestPaletteEstimatorRejectsUnmatchedHeights(t *testing.T) {
+	// 图片只有两种颜色，却给出了 paletteSize=4 个高度
+	opts := DefaultOptions()
+	opts.PaletteSize = 4
+	opts.PaletteHeights = []float64{0.1, 0.4, 0.7, 1}
+	if _, err := generatePaletteMap(paletteImage(), &opts); err == nil {
+		t.Fatal("expected error when the image has fewer colors than paletteHeights")
+	}
+	if len(opts.PaletteHeights) != 4 || opts.PaletteHeights[0] != 0.1 {
+		t.Fatalf("user heights should be kept, got %v", opts.PaletteHeights)
+	}
+}
@@ -0,0 +1,179 @@
+package depth
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// 光度立体：同一物体在固定相机、不同方向光照下拍摄多张照片，
+// 按朗伯反射 I = ρ·(n·l) 逐像素最小二乘求出法线，再积分为高度。
+// 阴影（过暗）和高光（过曝）的观测不参与求解。
+//
+// 光源方向 (x, y, z)：x 向右、y 向上、z 朝向相机。未给出时按拍摄约定估计：
+// 光源在 LightElevation 仰角上均匀环绕物体，第一张从正上方照亮，之后按顺时针依次排列，
+// 并把每张照片的平均亮度归一，抵消曝光差异。
+
+const (
+	minPhotometricImages = 3
+	shadowLevel          = 0.02 // 低于该亮度视为阴影
+	highlightLevel       = 0.98 // 高于该亮度视为高光或过曝
+)
+
+// DefaultLights n 个光源在 elevation（角度）仰角上均匀环绕，第一个在正上方，顺时针排列
+func DefaultLights(n int, elevation float64) [][3]float64 {
+	el := elevation * math.Pi / 180
+	lights := make([][3]float64, n)
+	for k := range lights {
+		az := 2 * math.Pi * float64(k) / float64(n)
+		lights[k] = [3]float64{math.Cos(el) * math.Sin(az), math.Cos(el) * math.Cos(az), math.Sin(el)}
+	}
+	return lights
+}
+
+// PhotometricStereo 从多张照片重建 16 位深度图，返回实际使用的（单位化的）光源方向。
+// lights 为 nil 时按 DefaultLights(len(photos), opts.LightElevation) 估计
+func PhotometricStereo(photos []image.Image, lights [][3]float64, opts *Options) (image.Image, [][3]float64, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+	n := len(photos)
+	if n < minPhotometricImages {
+		return nil, nil, fmt.Errorf("photometric stereo needs at least %d photos, got %d", minPhotometricImages, n)
+	}
+
+	estimated := lights == nil
+	if estimated {
+		lights = DefaultLights(n, opts.LightElevation)
+	}
+	if len(lights) != n {
+		return nil, nil, fmt.Errorf("got %d lights for %d photos", len(lights), n)
+	}
+	dirs := make([][3]float64, n)
+	for k, l := range lights {
+		length := math.Sqrt(l[0]*l[0] + l[1]*l[1] + l[2]*l[2])
+		if length == 0 {
+			return nil, nil, errors.New("light direction must not be zero")
+		}
+		dirs[k] = [3]float64{l[0] / length, l[1] / length, l[2] / length}
+	}
+
+	// 所有照片缩放到第一张的处理尺寸
+	first := newPlanes(photos[0], opts.BaseSize)
+	w, h := first.w, first.h
+	lum := make([][]float64, n)
+	for k, photo := range photos {
+		p := first
+		if k > 0 {
+			p = newPlanesSize(photo, w, h)
+		}
+		lum[k] = luminance(p)
+	}
+	if estimated {
+		equalizeExposure(lum)
+	}
+
+	sx := make([]float64, w*h)
+	sy := make([]float64, w*h)
+	for i := range sx {
+		nx, ny, nz, ok := solveNormal(lum, dirs, i)
+		if !ok {
+			continue // 背景或信息不足：视为平面
+		}
+		sx[i], sy[i] = normalSlope(nx, ny, nz)
+	}
+
+	return heightImage(integrateSlopes(sx, sy, w, h), w, h, opts), dirs, nil
+}
+
+// equalizeExposure 把每张照片的平均亮度缩放到所有照片的平均值（饱和像素不参与统计）
+func equalizeExposure(lum [][]float64) {
+	means := make([]float64, len(lum))
+	var overall float64
+	for k, plane := range lum {
+		var sum, count float64
+		for _, v := range plane {
+			if v > shadowLevel && v < highlightLevel {
+				sum += v
+				count++
+			}
+		}
+		if count > 0 {
+			means[k] = sum / count
+		}
+		overall += means[k] / float64(len(lum))
+	}
+	for k, plane := range lum {
+		if means[k] <= 0 {
+			continue
+		}
+		scale := overall / means[k]
+		for i, v := range plane {
+			if v < highlightLevel {
+				plane[i] = v * scale
+			}
+		}
+	}
+}
+
+// solveNormal 像素 i 的法线：用未处于阴影/高光的观测求解 (Σ l lᵀ) g = Σ l I，n = g/|g|。
+// 有效观测不足 3 个时退回到全部观测
+func solveNormal(lum [][]float64, dirs [][3]float64, i int) (nx, ny, nz float64, ok bool) {
+	solve := func(useAll bool) (g [3]float64, ok bool) {
+		var a [3][3]float64
+		var b [3]float64
+		count := 0
+		for k, l := range dirs {
+			v := lum[k][i]
+			if !useAll && (v <= shadowLevel || v >= highlightLevel) {
+				continue
+			}
+			count++
+			for r := 0; r < 3; r++ {
+				b[r] += l[r] * v
+				for c := 0; c < 3; c++ {
+					a[r][c] += l[r] * l[c]
+				}
+			}
+		}
+		if count < 3 {
+			return g, false
+		}
+		return solve3(a, b)
+	}
+
+	g, ok := solve(false)
+	if !ok {
+		if g, ok = solve(true); !ok {
+			return 0, 0, 1, false
+		}
+	}
+	length := math.Sqrt(g[0]*g[0] + g[1]*g[1] + g[2]*g[2])
+	if length < shadowLevel {
+		return 0, 0, 1, false
+	}
+	return g[0] / length, g[1] / length, g[2] / length, true
+}
+
+// solve3 克莱姆法则解 3×3 线性方程组，矩阵接近奇异（光源共面）时返回 false
+func solve3(a [3][3]float64, b [3]float64) ([3]float64, bool) {
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+	d := det(a)
+	if math.Abs(d) < 1e-9 {
+		return [3]float64{}, false
+	}
+	var x [3]float64
+	for c := 0; c < 3; c++ {
+		m := a
+		for r := 0; r < 3; r++ {
+			m[r][c] = b[r]
+		}
+		x[c] = det(m) / d
+	}
+	return x, true
+}
@@ -0,0 +1,93 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// renderLambert 按朗伯反射渲染高度场，亮度 = albedo·max(n·l, 0)·exposure
+func renderLambert(height []float64, w, h int, light [3]float64, exposure float64) *image.Gray16 {
+	at := func(x, y int) float64 {
+		return height[min(max(y, 0), h-1)*w+min(max(x, 0), w-1)]
+	}
+	img := image.NewGray16(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx := (at(x+1, y) - at(x-1, y)) / 2
+			sy := (at(x, y+1) - at(x, y-1)) / 2
+			nx, ny, nz := -sx, sy, 1.0
+			l := math.Sqrt(nx*nx + ny*ny + nz*nz)
+			v := 0.8 * max((nx*light[0]+ny*light[1]+nz*light[2])/l, 0) * exposure
+			img.SetGray16(x, y, color.Gray16{Y: uint16(min(v, 1) * 65535)})
+		}
+	}
+	return img
+}
+
+func TestPhotometricStereo(t *testing.T) {
+	const w, h = 64, 48
+	height := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := float64(x-w/2), float64(y-h/2)
+			height[y*w+x] = 10*math.Exp(-(dx*dx+dy*dy)/200) + 0.6*math.Sin(float64(x)/3)
+		}
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range height {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	check := func(name string, got image.Image) {
+		var worst float64
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := float64(color.Gray16Model.Convert(got.At(x, y)).(color.Gray16).Y) / 65535
+				worst = max(worst, math.Abs(v-(height[y*w+x]-lo)/(hi-lo)))
+			}
+		}
+		if worst > 0.06 {
+			t.Fatalf("%s: reconstruction error %.3f", name, worst)
+		}
+	}
+
+	opts := DefaultOptions()
+
+	// 按默认约定拍摄，曝光各不相同，不提供光源方向
+	lights := DefaultLights(4, opts.LightElevation)
+	exposures := []float64{1, 0.7, 1.2, 0.9}
+	photos := make([]image.Image, len(lights))
+	for k, l := range lights {
+		photos[k] = renderLambert(height, w, h, l, exposures[k])
+	}
+	got, used, err := PhotometricStereo(photos, nil, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(used) != 4 || math.Abs(used[0][1]-math.Cos(math.Pi/4)) > 1e-9 {
+		t.Fatalf("unexpected estimated lights: %v", used)
+	}
+	check("estimated lights", got)
+
+	// 给定的低角度光源会产生阴影，阴影观测被剔除
+	given := [][3]float64{{1, 0, 0.4}, {0, 1, 0.4}, {-1, 0, 0.4}, {0, -1, 0.4}, {0.3, 0.3, 1}}
+	photos = photos[:0]
+	for _, l := range given {
+		length := math.Sqrt(l[0]*l[0] + l[1]*l[1] + l[2]*l[2])
+		photos = append(photos, renderLambert(height, w, h, [3]float64{l[0] / length, l[1] / length, l[2] / length}, 1))
+	}
+	got, _, err = PhotometricStereo(photos, given, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("given lights", got)
+
+	if _, _, err = PhotometricStereo(photos[:2], nil, &opts); err == nil {
+		t.Fatal("expected error for two photos")
+	}
+	if _, _, err = PhotometricStereo(photos[:3], given, &opts); err == nil {
+		t.Fatal("expected error for mismatched lights")
+	}
+}
@@ -0,0 +1,140 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// 轮廓膨胀（pillow）：不看亮度，而是把主体轮廓像气球一样充气鼓起。
+// 主体由 alpha 通道确定（ImagePreprocess 抠图后的图片、透明底 PNG），
+// 没有透明信息时退回到亮度阈值 BackgroundClip（黑底图）。
+// 高度为到轮廓距离的圆弧剖面：边缘陡、中间平缓，细的部分比粗的部分低。
+// 再按 PillowDetail 叠加 detail16 的亮度细节。
+
+// generatePillowMap 输出与 generateDepthMap16 同尺寸的 16 位深度图
+func generatePillowMap(img image.Image, opts *Options) *image.Gray16 {
+	lumOpts := *opts
+	lumOpts.Invert = false
+	detail := generateDepthMap16(img, &lumOpts)
+	w, h := detail.Bounds().Dx(), detail.Bounds().Dy()
+
+	mask := silhouetteMask(newPlanesSize(img, w, h), float64(opts.BackgroundClip)/255)
+	dist := distanceTransform(mask, w, h)
+
+	var maxDist float64
+	for _, d := range dist {
+		maxDist = max(maxDist, d)
+	}
+
+	height := make([]float64, w*h)
+	if maxDist > 0 {
+		for i, d := range dist {
+			t := d / maxDist
+			height[i] = math.Sqrt(max(1-(1-t)*(1-t), 0))
+		}
+		// 距离场在中轴处有折痕，轻微模糊后再裁回轮廓内
+		r := max(1, int(maxDist/24))
+		height = boxMean(boxMean(height, w, h, r), w, h, r)
+	}
+
+	out := image.NewGray16(image.Rect(0, 0, w, h))
+	for i := range height {
+		var v float64
+		if mask[i] {
+			d := float64(detail.Gray16At(i%w, i/w).Y) / 65535
+			v = min(max((1-opts.PillowDetail)*height[i]+opts.PillowDetail*d, 0), 1)
+		}
+		if opts.Invert {
+			v = 1 - v
+		}
+		out.SetGray16(i%w, i/w, color.Gray16{Y: uint16(v*65535 + 0.5)})
+	}
+	return out
+}
+
+// silhouetteMask 有透明像素时按 alpha ≥ 0.5 取主体，否则按亮度 ≥ floor
+func silhouetteMask(p *planes, floor float64) []bool {
+	mask := make([]bool, p.w*p.h)
+	hasAlpha := false
+	for _, a := range p.a {
+		if a < 1 {
+			hasAlpha = true
+			break
+		}
+	}
+	for i := range mask {
+		if hasAlpha {
+			mask[i] = p.a[i] >= 0.5
+		} else {
+			mask[i] = 0.299*p.r[i]+0.587*p.g[i]+0.114*p.b[i] >= max(floor, 1.0/255)
+		}
+	}
+	return mask
+}
+
+// distanceTransform 主体像素到最近背景像素的欧氏距离（图片外部视为背景），
+// Felzenszwalb & Huttenlocher 的可分离精确算法
+func distanceTransform(mask []bool, w, h int) []float64 {
+	// 四周各补一圈背景
+	pw, ph := w+2, h+2
+	inf := float64(pw*pw + ph*ph)
+	grid := make([]float64, pw*ph)
+	for y := 0; y < ph; y++ {
+		for x := 0; x < pw; x++ {
+			if x > 0 && y > 0 && x <= w && y <= h && mask[(y-1)*w+x-1] {
+				grid[y*pw+x] = inf
+			}
+		}
+	}
+
+	buf := make([]float64, max(pw, ph))
+	for x := 0; x < pw; x++ {
+		for y := 0; y < ph; y++ {
+			buf[y] = grid[y*pw+x]
+		}
+		squaredDistance1D(buf[:ph])
+		for y := 0; y < ph; y++ {
+			grid[y*pw+x] = buf[y]
+		}
+	}
+	for y := 0; y < ph; y++ {
+		squaredDistance1D(grid[y*pw : (y+1)*pw])
+	}
+
+	dist := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dist[y*w+x] = math.Sqrt(grid[(y+1)*pw+x+1])
+		}
+	}
+	return dist
+}
+
+// squaredDistance1D 一维平方距离变换（抛物线下包络），原地写回
+func squaredDistance1D(f []float64) {
+	n := len(f)
+	d := make([]float64, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+	k := 0
+	z[0], z[1] = math.Inf(-1), math.Inf(1)
+	for q := 1; q < n; q++ {
+		s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		for s <= z[k] {
+			k--
+			s = ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		}
+		k++
+		v[k] = q
+		z[k], z[k+1] = s, math.Inf(1)
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		d[q] = float64((q-v[k])*(q-v[k])) + f[v[k]]
+	}
+	copy(f, d)
+}
@@ -0,0 +1,98 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDistanceTransform(t *testing.T) {
+	const w, h = 23, 17
+	rng := rand.New(rand.NewSource(3))
+	mask := make([]bool, w*h)
+	for i := range mask {
+		mask[i] = rng.Intn(5) > 0
+	}
+
+	got := distanceTransform(mask, w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			want := 0.0
+			if mask[y*w+x] {
+				// 暴力求解：最近的背景像素，或图片外的一圈
+				want = math.Inf(1)
+				for by := -1; by <= h; by++ {
+					for bx := -1; bx <= w; bx++ {
+						inside := bx >= 0 && by >= 0 && bx < w && by < h
+						if inside && mask[by*w+bx] {
+							continue
+						}
+						want = min(want, math.Hypot(float64(bx-x), float64(by-y)))
+					}
+				}
+			}
+			if math.Abs(got[y*w+x]-want) > 1e-9 {
+				t.Fatalf("(%d,%d): got %v want %v", x, y, got[y*w+x], want)
+			}
+		}
+	}
+}
+
+func TestPillowEstimator(t *testing.T) {
+	// 透明底上的圆盘，左右两半颜色不同但亮度相同
+	const size = 96
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	c := float64(size-1) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if math.Hypot(float64(x)-c, float64(y)-c) > 40 {
+				continue
+			}
+			col := color.NRGBA{R: 200, G: 40, B: 40, A: 255}
+			if x > size/2 {
+				col = color.NRGBA{R: 0, G: 93, B: 200, A: 255}
+			}
+			img.SetNRGBA(x, y, col)
+		}
+	}
+
+	e, _ := Lookup(EstimatorPillow)
+	opts := DefaultOptions()
+	opts.BaseSize = size
+	opts.PillowDetail = 0
+	got, err := e.Estimate(img, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(x, y int) float64 {
+		return float64(color.Gray16Model.Convert(got.At(x, y)).(color.Gray16).Y) / 65535
+	}
+
+	if v := at(2, 2); v != 0 {
+		t.Fatalf("background not flat: %v", v)
+	}
+	// 从边缘到中心单调升高，中心最高
+	prev := 0.0
+	for x := size/2 - 39; x <= size/2; x++ {
+		v := at(x, size/2)
+		if v+1e-3 < prev {
+			t.Fatalf("profile not rising at x=%d: %v < %v", x, v, prev)
+		}
+		prev = v
+	}
+	if center, rim := at(size/2, size/2), at(size/2-38, size/2); center < 0.9 || rim > 0.5 {
+		t.Fatalf("unexpected profile: center %v rim %v", center, rim)
+	}
+	// 对称：颜色不同不影响高度
+	if d := math.Abs(at(size/2-20, size/2) - at(size/2+20, size/2)); d > 0.05 {
+		t.Fatalf("height depends on colour: %v", d)
+	}
+
+	opts.Invert = true
+	inverted, _ := e.Estimate(img, &opts)
+	if v := color.Gray16Model.Convert(inverted.At(2, 2)).(color.Gray16).Y; v != 65535 {
+		t.Fatalf("inverted background: %v", v)
+	}
+}
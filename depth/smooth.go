@@ -0,0 +1,243 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// 保边平滑：在深度估计之前，以彩色图本身为引导对图片做双边或导向滤波，
+// 平坦区域的 JPEG 噪点被抹平，而颜色差异明显的轮廓保持锐利。
+// 窗口超出图片的部分直接不参与计算（而不是补 0），边缘像素同样被处理。
+
+// 平滑方式
+const (
+	SmoothingNone      = "none"
+	SmoothingBilateral = "bilateral" // 彩色双边滤波
+	SmoothingGuided    = "guided"    // 彩色导向滤波（He et al. 2010）
+)
+
+// planes 按通道存储的图像，取值 0~1
+type planes struct {
+	w, h       int
+	r, g, b, a []float64
+}
+
+// Smooth 按 opts.Smoothing 对图片做保边平滑；超过 opts.BaseSize 的图片先缩小到 BaseSize，
+// 后续深度估计本来就在这个分辨率上进行
+func Smooth(img image.Image, opts *Options) image.Image {
+	if opts.Smoothing == SmoothingNone || opts.Smoothing == "" {
+		return img
+	}
+
+	src := newPlanes(img, opts.BaseSize)
+	strength := opts.SmoothStrength / 255
+	var dst *planes
+	switch opts.Smoothing {
+	case SmoothingBilateral:
+		dst = bilateralFilter(src, opts.SmoothRadius, strength)
+	case SmoothingGuided:
+		dst = guidedFilter(src, opts.SmoothRadius, strength*strength)
+	default:
+		return img
+	}
+	return dst.image()
+}
+
+func newPlanes(img image.Image, maxSize int) *planes {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if longest := max(w, h); longest > maxSize {
+		scale := float64(maxSize) / float64(longest)
+		w, h = max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+	}
+	return newPlanesSize(img, w, h)
+}
+
+// newPlanesSize 缩放到 w×h 后按通道拆分
+func newPlanesSize(img image.Image, w, h int) *planes {
+	b := img.Bounds()
+	scaled := image.NewNRGBA64(image.Rect(0, 0, w, h))
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(scaled, scaled.Bounds(), img, b.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, b, draw.Src, nil)
+	}
+
+	p := &planes{
+		w: w, h: h,
+		r: make([]float64, w*h), g: make([]float64, w*h), b: make([]float64, w*h), a: make([]float64, w*h),
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := scaled.NRGBA64At(x, y)
+			i := y*w + x
+			p.r[i] = float64(c.R) / 65535
+			p.g[i] = float64(c.G) / 65535
+			p.b[i] = float64(c.B) / 65535
+			p.a[i] = float64(c.A) / 65535
+		}
+	}
+	return p
+}
+
+// luminance 亮度（0~1）
+func luminance(p *planes) []float64 {
+	lum := make([]float64, p.w*p.h)
+	for i := range lum {
+		lum[i] = 0.299*p.r[i] + 0.587*p.g[i] + 0.114*p.b[i]
+	}
+	return lum
+}
+
+func (p *planes) image() *image.NRGBA64 {
+	to16 := func(v float64) uint16 {
+		return uint16(min(max(v, 0), 1)*65535 + 0.5)
+	}
+	img := image.NewNRGBA64(image.Rect(0, 0, p.w, p.h))
+	for y := 0; y < p.h; y++ {
+		for x := 0; x < p.w; x++ {
+			i := y*p.w + x
+			img.SetNRGBA64(x, y, color.NRGBA64{R: to16(p.r[i]), G: to16(p.g[i]), B: to16(p.b[i]), A: to16(p.a[i])})
+		}
+	}
+	return img
+}
+
+// bilateralFilter 空间高斯（sigma = radius/2）× 颜色距离高斯（sigma = sigmaRange）
+func bilateralFilter(src *planes, radius int, sigmaRange float64) *planes {
+	w, h := src.w, src.h
+	dst := &planes{w: w, h: h, r: make([]float64, w*h), g: make([]float64, w*h), b: make([]float64, w*h), a: src.a}
+
+	sigmaSpace := max(float64(radius)/2, 0.5)
+	size := 2*radius + 1
+	spatial := make([]float64, size*size)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			spatial[(dy+radius)*size+dx+radius] = math.Exp(-float64(dx*dx+dy*dy) / (2 * sigmaSpace * sigmaSpace))
+		}
+	}
+	rangeScale := -1 / (2 * sigmaRange * sigmaRange)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			cr, cg, cb := src.r[i], src.g[i], src.b[i]
+			var sumW, sumR, sumG, sumB float64
+			for dy := max(-radius, -y); dy <= min(radius, h-1-y); dy++ {
+				row := (y + dy) * w
+				for dx := max(-radius, -x); dx <= min(radius, w-1-x); dx++ {
+					j := row + x + dx
+					dr, dg, db := src.r[j]-cr, src.g[j]-cg, src.b[j]-cb
+					weight := spatial[(dy+radius)*size+dx+radius] * math.Exp((dr*dr+dg*dg+db*db)*rangeScale)
+					sumW += weight
+					sumR += weight * src.r[j]
+					sumG += weight * src.g[j]
+					sumB += weight * src.b[j]
+				}
+			}
+			dst.r[i], dst.g[i], dst.b[i] = sumR/sumW, sumG/sumW, sumB/sumW
+		}
+	}
+	return dst
+}
+
+// boxMean 半径 r 的窗口均值，窗口被图片边界截断时按实际像素数平均
+func boxMean(src []float64, w, h, r int) []float64 {
+	integral := make([]float64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var rowSum float64
+		for x := 0; x < w; x++ {
+			rowSum += src[y*w+x]
+			integral[(y+1)*(w+1)+x+1] = integral[y*(w+1)+x+1] + rowSum
+		}
+	}
+
+	out := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		y0, y1 := max(y-r, 0), min(y+r+1, h)
+		for x := 0; x < w; x++ {
+			x0, x1 := max(x-r, 0), min(x+r+1, w)
+			sum := integral[y1*(w+1)+x1] - integral[y0*(w+1)+x1] - integral[y1*(w+1)+x0] + integral[y0*(w+1)+x0]
+			out[y*w+x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return out
+}
+
+func mulPlanes(a, b []float64) []float64 {
+	out := make([]float64, len(a))
+	for i := range a {
+		out[i] = a[i] * b[i]
+	}
+	return out
+}
+
+// guidedFilter 以彩色图 I 为引导，分别滤波 R、G、B：q = a·I + b，
+// 其中 (a, b) 为每个窗口内的线性回归系数，eps 越大越平滑
+func guidedFilter(src *planes, radius int, eps float64) *planes {
+	w, h, r := src.w, src.h, radius
+	guide := [3][]float64{src.r, src.g, src.b}
+
+	var meanI [3][]float64
+	for c := range guide {
+		meanI[c] = boxMean(guide[c], w, h, r)
+	}
+	// 协方差矩阵的上三角：rr rg rb gg gb bb
+	pairs := [6][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 1}, {1, 2}, {2, 2}}
+	var cov [6][]float64
+	for k, pr := range pairs {
+		cov[k] = boxMean(mulPlanes(guide[pr[0]], guide[pr[1]]), w, h, r)
+		for i := range cov[k] {
+			cov[k][i] -= meanI[pr[0]][i] * meanI[pr[1]][i]
+		}
+	}
+
+	dst := &planes{w: w, h: h, a: src.a}
+	filterChannel := func(p []float64) []float64 {
+		meanP := boxMean(p, w, h, r)
+		var covIp [3][]float64
+		for c := range guide {
+			covIp[c] = boxMean(mulPlanes(guide[c], p), w, h, r)
+			for i := range covIp[c] {
+				covIp[c][i] -= meanI[c][i] * meanP[i]
+			}
+		}
+
+		var coefA [3][]float64
+		for c := range coefA {
+			coefA[c] = make([]float64, w*h)
+		}
+		coefB := make([]float64, w*h)
+		for i := range coefB {
+			// (Σ + εU)⁻¹ · cov(I, p)，3x3 对称矩阵用伴随矩阵求逆
+			a, b, c := cov[0][i]+eps, cov[1][i], cov[2][i]
+			d, e := cov[3][i]+eps, cov[4][i]
+			f := cov[5][i] + eps
+			inv := [6]float64{d*f - e*e, c*e - b*f, b*e - c*d, a*f - c*c, b*c - a*e, a*d - b*b}
+			det := a*inv[0] + b*inv[1] + c*inv[2]
+			v0, v1, v2 := covIp[0][i], covIp[1][i], covIp[2][i]
+			a0 := (inv[0]*v0 + inv[1]*v1 + inv[2]*v2) / det
+			a1 := (inv[1]*v0 + inv[3]*v1 + inv[4]*v2) / det
+			a2 := (inv[2]*v0 + inv[4]*v1 + inv[5]*v2) / det
+			coefA[0][i], coefA[1][i], coefA[2][i] = a0, a1, a2
+			coefB[i] = meanP[i] - a0*meanI[0][i] - a1*meanI[1][i] - a2*meanI[2][i]
+		}
+
+		out := boxMean(coefB, w, h, r)
+		for c := range coefA {
+			meanA := boxMean(coefA[c], w, h, r)
+			for i := range out {
+				out[i] += meanA[i] * guide[c][i]
+			}
+		}
+		return out
+	}
+
+	dst.r = filterChannel(src.r)
+	dst.g = filterChannel(src.g)
+	dst.b = filterChannel(src.b)
+	return dst
+}
@@ -0,0 +1,85 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// noisyStep 左暗右亮的台阶，叠加 ±10 的噪点
+func noisyStep(w, h int) *image.RGBA {
+	rng := rand.New(rand.NewSource(7))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 60
+			if x >= w/2 {
+				v = 200
+			}
+			v += rng.Intn(21) - 10
+			img.SetRGBA(x, y, color.RGBA{R: uint8(v), G: uint8(v), B: uint8(v), A: 255})
+		}
+	}
+	return img
+}
+
+func TestSmooth(t *testing.T) {
+	const w, h = 80, 40
+	src := noisyStep(w, h)
+
+	noise := func(img image.Image, x0, x1 int) float64 {
+		var sum, sumSq, n float64
+		for y := 0; y < h; y++ {
+			for x := x0; x < x1; x++ {
+				v := float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+				sum += v
+				sumSq += v * v
+				n++
+			}
+		}
+		mean := sum / n
+		return math.Sqrt(sumSq/n - mean*mean)
+	}
+	gray := func(img image.Image, x, y int) float64 {
+		return float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+	}
+
+	for _, mode := range []string{SmoothingBilateral, SmoothingGuided} {
+		opts := DefaultOptions()
+		opts.Smoothing = mode
+		opts.SmoothStrength = 30
+		got := Smooth(src, &opts)
+
+		if b := got.Bounds(); b.Dx() != w || b.Dy() != h {
+			t.Fatalf("%s: unexpected size %v", mode, b)
+		}
+		// 包括最外圈在内，平坦区域的噪点明显减少
+		if before, after := noise(src, 0, w/2-3), noise(got, 0, w/2-3); after > before/2 {
+			t.Fatalf("%s: noise not reduced: %.2f -> %.2f", mode, before, after)
+		}
+		if before, after := noise(src, 0, 1), noise(got, 0, 1); after > before/2 {
+			t.Fatalf("%s: border column not smoothed: %.2f -> %.2f", mode, before, after)
+		}
+		// 台阶仍然锐利：相邻两列的差值接近原始的 140
+		var jump float64
+		for y := 0; y < h; y++ {
+			jump += gray(got, w/2, y) - gray(got, w/2-1, y)
+		}
+		if jump/h < 110 {
+			t.Fatalf("%s: edge blurred, average jump %.1f", mode, jump/h)
+		}
+	}
+
+	opts := DefaultOptions()
+	if got := Smooth(src, &opts); got != image.Image(src) {
+		t.Fatal("smoothing none should return the input")
+	}
+
+	opts.Smoothing = SmoothingGuided
+	opts.BaseSize = 40
+	if b := Smooth(src, &opts).Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Fatalf("expected downscale to baseSize, got %v", b)
+	}
+}
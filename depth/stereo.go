@@ -0,0 +1,281 @@
+package depth
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// 立体像对：对已水平校正的左右图做半全局匹配（SGM，Hirschmüller 2008），
+// 代价为 5×5 Census 变换的汉明距离（对两台相机的曝光差异不敏感），8 个方向聚合，
+// 抛物线拟合亚像素视差，左右一致性检查剔除遮挡后用较远（视差较小）的一侧填补。
+// 视差越大越近，直接作为高度（越亮越高）。
+
+const (
+	censusRadius = 2
+	sgmPenalty1  = 3  // 相邻像素视差差 1 的惩罚
+	sgmPenalty2  = 20 // 相邻像素视差跳变的惩罚
+
+	maxCostVolume = 64 << 20 // 代价体（像素数 × 视差数）上限，约 64M
+)
+
+// SplitSideBySide 把左右并排的立体图切成左、右两半
+func SplitSideBySide(img image.Image) (left, right image.Image) {
+	b := img.Bounds()
+	half := b.Dx() / 2
+	sub := func(r image.Rectangle) image.Image {
+		if s, ok := img.(interface {
+			SubImage(image.Rectangle) image.Image
+		}); ok {
+			return s.SubImage(r)
+		}
+		dst := image.NewNRGBA64(image.Rect(0, 0, r.Dx(), r.Dy()))
+		for y := 0; y < r.Dy(); y++ {
+			for x := 0; x < r.Dx(); x++ {
+				dst.Set(x, y, img.At(r.Min.X+x, r.Min.Y+y))
+			}
+		}
+		return dst
+	}
+	left = sub(image.Rect(b.Min.X, b.Min.Y, b.Min.X+half, b.Max.Y))
+	right = sub(image.Rect(b.Min.X+half, b.Min.Y, b.Min.X+2*half, b.Max.Y))
+	return left, right
+}
+
+// StereoDepth 从左右图计算视差并转为 16 位深度图。opts.StereoSwap 为 true 时交换左右（交叉式并排图）
+func StereoDepth(left, right image.Image, opts *Options) (image.Image, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.StereoSwap {
+		left, right = right, left
+	}
+
+	lp := newPlanes(left, opts.BaseSize)
+	w, h := lp.w, lp.h
+	rp := newPlanesSize(right, w, h)
+	maxDisp := min(opts.MaxDisparity, w-1)
+	if maxDisp < 1 {
+		return nil, fmt.Errorf("image too narrow for stereo matching: %d pixels", w)
+	}
+	numDisp := maxDisp + 1
+	if w*h*numDisp > maxCostVolume {
+		return nil, fmt.Errorf("stereo cost volume too large (%dx%dx%d), reduce baseSize or maxDisparity", w, h, numDisp)
+	}
+
+	cost := censusCost(census(luminance(lp), w, h), census(luminance(rp), w, h), w, h, numDisp)
+	sum := aggregateCost(cost, w, h, numDisp)
+
+	// 左图视差（亚像素）与右图视差（整数）
+	dispL := make([]float64, w*h)
+	dispR := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			s := sum[(y*w+x)*numDisp : (y*w+x+1)*numDisp]
+			best := 0
+			for d := 1; d < numDisp && d <= x; d++ {
+				if s[d] < s[best] {
+					best = d
+				}
+			}
+			v := float64(best)
+			if best > 0 && best < min(numDisp-1, x) {
+				c0, c1, c2 := float64(s[best-1]), float64(s[best]), float64(s[best+1])
+				if denom := c0 - 2*c1 + c2; denom > 0 {
+					v += (c0 - c2) / (2 * denom)
+				}
+			}
+			dispL[y*w+x] = v
+
+			bestR, bestCost := 0, uint16(math.MaxUint16)
+			for d := 0; d < numDisp && x+d < w; d++ {
+				if c := sum[(y*w+x+d)*numDisp+d]; c < bestCost {
+					bestR, bestCost = d, c
+				}
+			}
+			dispR[y*w+x] = bestR
+		}
+	}
+
+	// 左右一致性检查，遮挡区域置为无效
+	valid := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			d := dispL[y*w+x]
+			xr := x - int(math.Round(d))
+			valid[y*w+x] = xr >= 0 && math.Abs(float64(dispR[y*w+xr])-d) <= 1
+		}
+	}
+	fillInvalid(dispL, valid, w, h)
+	disp := median3(dispL, w, h)
+
+	// 按百分位拉伸到 0~1
+	sorted := append([]float64(nil), disp...)
+	sort.Float64s(sorted)
+	lowCut, highCut := opts.percentileCuts(len(sorted))
+	lo, hi := sorted[min(lowCut, len(sorted)-1)], sorted[max(highCut-1, 0)]
+	span := max(hi-lo, 1e-9)
+	height := make([]float64, w*h)
+	for i, d := range disp {
+		height[i] = min(max((d-lo)/span, 0), 1)
+	}
+	return heightImage(height, w, h, opts), nil
+}
+
+// census 5×5 Census 变换：邻域像素是否比中心暗，共 24 位
+func census(lum []float64, w, h int) []uint32 {
+	out := make([]uint32, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			center := lum[y*w+x]
+			var code uint32
+			for dy := -censusRadius; dy <= censusRadius; dy++ {
+				yy := min(max(y+dy, 0), h-1)
+				for dx := -censusRadius; dx <= censusRadius; dx++ {
+					if dx == 0 && dy == 0 {
+						continue
+					}
+					xx := min(max(x+dx, 0), w-1)
+					code <<= 1
+					if lum[yy*w+xx] < center {
+						code |= 1
+					}
+				}
+			}
+			out[y*w+x] = code
+		}
+	}
+	return out
+}
+
+// censusCost 匹配代价体 cost[(y*w+x)*numDisp+d]，右图越界时取最大代价
+func censusCost(left, right []uint32, w, h, numDisp int) []uint8 {
+	const maxCost = (2*censusRadius+1)*(2*censusRadius+1) - 1
+	cost := make([]uint8, w*h*numDisp)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			base := (y*w + x) * numDisp
+			for d := 0; d < numDisp; d++ {
+				if x-d < 0 {
+					cost[base+d] = maxCost
+					continue
+				}
+				cost[base+d] = uint8(bits.OnesCount32(left[y*w+x] ^ right[y*w+x-d]))
+			}
+		}
+	}
+	return cost
+}
+
+// aggregateCost 沿 8 个方向做 SGM 路径聚合，返回各方向代价之和
+func aggregateCost(cost []uint8, w, h, numDisp int) []uint16 {
+	sum := make([]uint16, len(cost))
+	path := make([]uint16, len(cost))
+	minPath := make([]uint16, w*h)
+	dirs := [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+
+	for _, dir := range dirs {
+		dx, dy := dir[0], dir[1]
+		y0, y1, ys := 0, h, 1
+		if dy < 0 {
+			y0, y1, ys = h-1, -1, -1
+		}
+		x0, x1, xs := 0, w, 1
+		if dx < 0 {
+			x0, x1, xs = w-1, -1, -1
+		}
+
+		for y := y0; y != y1; y += ys {
+			for x := x0; x != x1; x += xs {
+				i := y*w + x
+				base := i * numDisp
+				px, py := x-dx, y-dy
+				best := uint16(math.MaxUint16)
+				if px < 0 || px >= w || py < 0 || py >= h {
+					// 路径起点
+					for d := 0; d < numDisp; d++ {
+						v := uint16(cost[base+d])
+						path[base+d] = v
+						best = min(best, v)
+					}
+				} else {
+					prev := path[(py*w+px)*numDisp : (py*w+px+1)*numDisp]
+					prevMin := minPath[py*w+px]
+					for d := 0; d < numDisp; d++ {
+						v := prev[d]
+						if d > 0 {
+							v = min(v, prev[d-1]+sgmPenalty1)
+						}
+						if d < numDisp-1 {
+							v = min(v, prev[d+1]+sgmPenalty1)
+						}
+						v = min(v, prevMin+sgmPenalty2)
+						v = uint16(cost[base+d]) + v - prevMin
+						path[base+d] = v
+						best = min(best, v)
+					}
+				}
+				minPath[i] = best
+				for d := 0; d < numDisp; d++ {
+					sum[base+d] += path[base+d]
+				}
+			}
+		}
+	}
+	return sum
+}
+
+// fillInvalid 每行中无效的视差用左右最近有效值中较小的一个（较远的背景）填补
+func fillInvalid(disp []float64, valid []bool, w, h int) {
+	for y := 0; y < h; y++ {
+		row := disp[y*w : (y+1)*w]
+		ok := valid[y*w : (y+1)*w]
+		for x := 0; x < w; {
+			if ok[x] {
+				x++
+				continue
+			}
+			end := x
+			for end < w && !ok[end] {
+				end++
+			}
+			fill := math.Inf(1)
+			if x > 0 {
+				fill = row[x-1]
+			}
+			if end < w {
+				fill = min(fill, row[end])
+			}
+			if math.IsInf(fill, 1) {
+				fill = 0
+			}
+			for i := x; i < end; i++ {
+				row[i] = fill
+			}
+			x = end
+		}
+	}
+}
+
+// median3 3×3 中值滤波，去掉孤立的错误匹配
+func median3(src []float64, w, h int) []float64 {
+	out := make([]float64, len(src))
+	var window [9]float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					window[n] = src[min(max(y+dy, 0), h-1)*w+min(max(x+dx, 0), w-1)]
+					n++
+				}
+			}
+			s := window[:]
+			sort.Float64s(s)
+			out[y*w+x] = s[4]
+		}
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package depth
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// randomDotPair 随机点立体图：背景视差 4，中间方块视差 12；右图整体偏暗模拟曝光差异
+func randomDotPair(w, h int) (left, right *image.Gray, disparity func(x, y int) int) {
+	rng := rand.New(rand.NewSource(5))
+	texture := make([]uint8, (w+32)*h)
+	for i := range texture {
+		texture[i] = uint8(rng.Intn(256))
+	}
+	disparity = func(x, y int) int {
+		if x >= w/3 && x < 2*w/3 && y >= h/3 && y < 2*h/3 {
+			return 12
+		}
+		return 4
+	}
+
+	left = image.NewGray(image.Rect(0, 0, w, h))
+	right = image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			left.SetGray(x, y, color.Gray{Y: texture[y*(w+32)+x+16]})
+		}
+		// 右图像素 xr 对应左图 xr + d
+		for xr := 0; xr < w; xr++ {
+			// 只在右图可见（左图被方块遮挡）的区域是新的纹理
+			v := uint8(rng.Intn(256))
+			for x := w - 1; x >= 0; x-- {
+				if x-disparity(x, y) == xr {
+					v = texture[y*(w+32)+x+16]
+					break
+				}
+			}
+			right.SetGray(xr, y, color.Gray{Y: uint8(int(v) * 3 / 4)})
+		}
+	}
+	return left, right, disparity
+}
+
+func TestStereoDepth(t *testing.T) {
+	const w, h = 120, 90
+	left, right, disparity := randomDotPair(w, h)
+
+	opts := DefaultOptions()
+	opts.MaxDisparity = 24
+	opts.LowPercentile, opts.HighPercentile = 0, 100
+	got, err := StereoDepth(left, right, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := got.Bounds(); b.Dx() != w || b.Dy() != h {
+		t.Fatalf("unexpected size %v", b)
+	}
+
+	// 方块（近）应明显高于背景（远），除去边缘后几乎没有错误像素
+	var wrong int
+	for y := 4; y < h-4; y++ {
+		for x := 28; x < w-4; x++ {
+			v := color.Gray16Model.Convert(got.At(x, y)).(color.Gray16).Y
+			near := v > 32768
+			if near != (disparity(x, y) == 12) {
+				wrong++
+			}
+		}
+	}
+	if total := (h - 8) * (w - 32); wrong > total/50 {
+		t.Fatalf("%d of %d pixels on the wrong side", wrong, total)
+	}
+
+	// 交换左右后再交换回来，结果一致
+	opts.StereoSwap = true
+	swapped, err := StereoDepth(right, left, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if color.Gray16Model.Convert(swapped.At(w/2, h/2)) != color.Gray16Model.Convert(got.At(w/2, h/2)) {
+		t.Fatal("stereoSwap should swap the inputs")
+	}
+}
+
+func TestSplitSideBySide(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 4))
+	for x := 5; x < 10; x++ {
+		img.SetRGBA(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	left, right := SplitSideBySide(img)
+	if left.Bounds().Dx() != 5 || right.Bounds().Dx() != 5 {
+		t.Fatalf("unexpected halves %v %v", left.Bounds(), right.Bounds())
+	}
+	if r, _, _, _ := left.At(left.Bounds().Min.X, 0).RGBA(); r != 0 {
+		t.Fatal("left half contains right pixels")
+	}
+	if r, _, _, _ := right.At(right.Bounds().Min.X, 0).RGBA(); r == 0 {
+		t.Fatal("right half missing right pixels")
+	}
+}
@@ -0,0 +1,95 @@
+package depth
+
+import (
+	"errors"
+	"image"
+	"sort"
+
+	"github.com/chaos-io/depth2STL/svg"
+)
+
+// 矢量输入：SVG 直接按网格分辨率光栅化，边缘是抗锯齿的覆盖率而不是放大后的锯齿。
+// 每种填充色对应一个高度，复用 palette 算法的 opts.Palette / PaletteHeights：
+// 给出调色板时每个填充色取最接近的颜色，未给出时用文档中的填充色（超过 32 种时 k-means 合并）。
+// 未填充的区域为底板，高度 0，因此默认高度从 1/n 开始，最暗的填充色也会凸起。
+
+// RenderSVG 把 SVG 光栅化为 w×h 的 16 位高度图，opts.Palette / PaletteHeights 为空时写回估计值
+func RenderSVG(doc *svg.Document, w, h int, opts *Options) (*image.Gray16, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if len(doc.Shapes) == 0 {
+		return nil, errors.New("svg has no filled shapes")
+	}
+
+	// 文档中出现的填充色
+	fills := map[[3]uint8]lab{}
+	for _, s := range doc.Shapes {
+		key := [3]uint8{s.Fill.R, s.Fill.G, s.Fill.B}
+		fills[key] = rgbToLab(float64(key[0])/255, float64(key[1])/255, float64(key[2])/255)
+	}
+
+	var palette []lab
+	switch {
+	case len(opts.Palette) > 0:
+		for _, s := range opts.Palette {
+			r, g, b, _ := parseHexColor(s) // Validate 已检查格式
+			palette = append(palette, rgbToLab(float64(r)/255, float64(g)/255, float64(b)/255))
+		}
+	default:
+		for _, c := range fills {
+			palette = append(palette, c)
+		}
+		// map 的遍历顺序不固定，先排序保证结果稳定
+		sort.Slice(palette, func(i, j int) bool {
+			a, b := palette[i], palette[j]
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			if a[1] != b[1] {
+				return a[1] < b[1]
+			}
+			return a[2] < b[2]
+		})
+		if len(palette) > maxPaletteSize {
+			palette = kmeans(palette, opts.PaletteSize)
+		}
+		opts.Palette = make([]string, len(palette))
+		for k, c := range palette {
+			opts.Palette[k] = hexColor(c)
+		}
+	}
+
+	// 默认按亮度顺序均匀分配高度：最暗为 1/n，最亮为 1
+	if len(opts.PaletteHeights) != len(palette) {
+		order := make([]int, len(palette))
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(i, j int) bool { return palette[order[i]][0] < palette[order[j]][0] })
+		opts.PaletteHeights = make([]float64, len(palette))
+		for rank, k := range order {
+			opts.PaletteHeights[k] = roundTo(float64(rank+1)/float64(len(palette)), 100)
+		}
+	}
+
+	heights := make(map[[3]uint8]float64, len(fills))
+	for key, c := range fills {
+		heights[key] = opts.PaletteHeights[nearestColor(c, palette)]
+	}
+
+	// 按绘制顺序用覆盖率混合，后面的形状覆盖前面的
+	height := make([]float64, w*h)
+	doc.Rasterize(w, h, func(s *svg.Shape, mask *image.Alpha, r image.Rectangle) {
+		v := heights[[3]uint8{s.Fill.R, s.Fill.G, s.Fill.B}]
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if a := mask.Pix[mask.PixOffset(x, y)]; a > 0 {
+					c := float64(a) / 255
+					height[y*w+x] = height[y*w+x]*(1-c) + v*c
+				}
+			}
+		}
+	})
+	return heightGray16(height, w, h, opts.Invert), nil
+}
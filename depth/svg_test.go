@@ -0,0 +1,61 @@
+package depth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chaos-io/depth2STL/svg"
+)
+
+func TestRenderSVG(t *testing.T) {
+	// 透明底上一个红色方块，中间叠一个白色方块
+	doc, err := svg.Parse(strings.NewReader(`<svg viewBox="0 0 40 20">
+		<rect x="10" y="2" width="20" height="16" fill="#d52b1e"/>
+		<rect x="16" y="6" width="8" height="8" fill="#fff"/>
+	</svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	gray, err := RenderSVG(doc, 80, 40, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Palette) != 2 || opts.Palette[0] != "#d52b1e" || opts.Palette[1] != "#ffffff" {
+		t.Fatalf("palette not written back: %v", opts.Palette)
+	}
+	if len(opts.PaletteHeights) != 2 || opts.PaletteHeights[0] != 0.5 || opts.PaletteHeights[1] != 1 {
+		t.Fatalf("unexpected default heights: %v", opts.PaletteHeights)
+	}
+	bg, red, white := gray.Gray16At(2, 20).Y, gray.Gray16At(24, 20).Y, gray.Gray16At(40, 20).Y
+	if bg != 0 || red != 32768 || white != 65535 {
+		t.Fatalf("unexpected heights: background %d, red %d, white %d", bg, red, white)
+	}
+
+	// 用户给定调色板：白色映射到最接近的浅灰
+	opts.Palette = []string{"#ff0000", "#eeeeee"}
+	opts.PaletteHeights = []float64{1, 0.25}
+	if gray, err = RenderSVG(doc, 80, 40, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if red, white = gray.Gray16At(24, 20).Y, gray.Gray16At(40, 20).Y; red != 65535 || white != 16384 {
+		t.Fatalf("unexpected heights with user palette: red %d, white %d", red, white)
+	}
+
+	// 边缘像素按覆盖率混合
+	if gray, err = RenderSVG(doc, 81, 41, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if edge := gray.Gray16At(20, 20).Y; edge == 0 || edge == 65535 {
+		t.Fatalf("edge should be anti-aliased, got %d", edge)
+	}
+
+	empty, err := svg.Parse(strings.NewReader(`<svg viewBox="0 0 1 1"><rect width="1" height="1" fill="none"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RenderSVG(empty, 8, 8, &opts); err == nil {
+		t.Fatal("expected error for svg without filled shapes")
+	}
+}
@@ -0,0 +1,173 @@
+package depth
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomedium"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/gofont/gosmallcaps"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// 文字浮雕：用 TTF/OTF 字体把文字排版成高度图，做铭牌、标牌。
+// 凸起时字为 1、底板为 0，凹刻时相反；Bevel 为斜面宽度，0 为竖直的边。
+// 字号、留白、斜面都以像素为单位在渲染分辨率上计算，模型尺寸由 modelWidth 决定。
+
+// 文字对齐方式
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+const (
+	maxTextRunes  = 2000
+	maxTextPixels = 16 << 20 // 渲染图的像素数上限
+)
+
+// builtinFonts 内置的 Go 字体
+var builtinFonts = map[string][]byte{
+	"regular":    goregular.TTF,
+	"medium":     gomedium.TTF,
+	"bold":       gobold.TTF,
+	"italic":     goitalic.TTF,
+	"bolditalic": gobolditalic.TTF,
+	"smallcaps":  gosmallcaps.TTF,
+	"mono":       gomono.TTF,
+	"monobold":   gomonobold.TTF,
+}
+
+// TextOptions 文字排版参数，零值不可用，应从 DefaultTextOptions 开始修改
+type TextOptions struct {
+	Font        string  `json:"font"`        // 内置字体：regular、medium、bold、italic、bolditalic、smallcaps、mono、monobold
+	Size        float64 `json:"size"`        // 字号（像素）
+	LineSpacing float64 `json:"lineSpacing"` // 行距（字号的倍数）
+	Align       string  `json:"align"`       // 多行文字的对齐方式：left、center、right
+	Margin      float64 `json:"margin"`      // 四周留白（像素）
+	Bevel       float64 `json:"bevel"`       // 斜面宽度（像素），0 为竖直的边
+	Engrave     bool    `json:"engrave"`     // 文字凹刻进底板，而不是凸起
+}
+
+// DefaultTextOptions 128 像素的常规字体，1.2 倍行距，居中
+func DefaultTextOptions() TextOptions {
+	return TextOptions{
+		Font:        "regular",
+		Size:        128,
+		LineSpacing: 1.2,
+		Align:       AlignCenter,
+		Margin:      32,
+	}
+}
+
+// ValidFont 是否为内置字体
+func ValidFont(name string) bool {
+	_, ok := builtinFonts[name]
+	return ok
+}
+
+// Validate 检查参数是否在合理范围内
+func (o *TextOptions) Validate() error {
+	switch {
+	case !ValidFont(o.Font):
+		return fmt.Errorf("unknown font %q", o.Font)
+	case o.Size < 8 || o.Size > 1024:
+		return fmt.Errorf("size must be in [8, 1024], got %v", o.Size)
+	case o.LineSpacing < 0.5 || o.LineSpacing > 5:
+		return fmt.Errorf("lineSpacing must be in [0.5, 5], got %v", o.LineSpacing)
+	case o.Align != AlignLeft && o.Align != AlignCenter && o.Align != AlignRight:
+		return fmt.Errorf("align must be one of left, center, right, got %q", o.Align)
+	case o.Margin < 0 || o.Margin > 1024:
+		return fmt.Errorf("margin must be in [0, 1024], got %v", o.Margin)
+	case o.Bevel < 0 || o.Bevel > o.Size/2:
+		return fmt.Errorf("bevel must be in [0, size/2], got %v", o.Bevel)
+	}
+	return nil
+}
+
+// RenderText 把文字（可多行）排版为 16 位高度图。fontData 为 TTF/OTF 字体数据，nil 时使用 opts.Font
+func RenderText(text string, fontData []byte, opts TextOptions) (*image.Gray16, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	text = strings.ReplaceAll(text, "\r", "")
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("text is empty")
+	}
+	if n := len([]rune(text)); n > maxTextRunes {
+		return nil, fmt.Errorf("text too long: %d characters, at most %d", n, maxTextRunes)
+	}
+
+	if fontData == nil {
+		fontData = builtinFonts[opts.Font]
+	}
+	f, err := opentype.Parse(fontData)
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: opts.Size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = face.Close()
+	}()
+
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	widths := make([]int, len(lines))
+	maxWidth := 0
+	for i, line := range lines {
+		widths[i] = font.MeasureString(face, line).Ceil()
+		maxWidth = max(maxWidth, widths[i])
+	}
+
+	metrics := face.Metrics()
+	ascent, descent := metrics.Ascent.Ceil(), metrics.Descent.Ceil()
+	advance := opts.LineSpacing * opts.Size
+	margin := int(math.Ceil(opts.Margin))
+	w := maxWidth + 2*margin
+	h := ascent + descent + int(math.Round(advance*float64(len(lines)-1))) + 2*margin
+	if w*h > maxTextPixels {
+		return nil, fmt.Errorf("rendered text too large (%dx%d), reduce size or text length", w, h)
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	drawer := &font.Drawer{Dst: mask, Src: image.Opaque, Face: face}
+	for i, line := range lines {
+		x := margin
+		switch opts.Align {
+		case AlignCenter:
+			x += (maxWidth - widths[i]) / 2
+		case AlignRight:
+			x += maxWidth - widths[i]
+		}
+		drawer.Dot = fixed.P(x, margin+ascent+int(math.Round(advance*float64(i))))
+		drawer.DrawString(line)
+	}
+
+	coverage := make([]float64, w*h)
+	for i, a := range mask.Pix {
+		coverage[i] = float64(a) / 255
+	}
+	if opts.Bevel > 0 {
+		// 斜面：高度随到字形边缘的距离线性升高
+		inside := make([]bool, w*h)
+		for i, c := range coverage {
+			inside[i] = c >= 0.5
+		}
+		for i, d := range distanceTransform(inside, w, h) {
+			coverage[i] = min(coverage[i], d/opts.Bevel)
+		}
+	}
+	return heightGray16(coverage, w, h, opts.Engrave), nil
+}
@@ -0,0 +1,116 @@
+package depth
+
+import (
+	"image"
+	"testing"
+)
+
+// inkBounds 高度不为 0 的像素范围
+func inkBounds(img *image.Gray16, background uint16) image.Rectangle {
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.Gray16At(x, y).Y != background {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+func TestRenderText(t *testing.T) {
+	opts := DefaultTextOptions()
+	opts.Size = 48
+	opts.Margin = 10
+
+	img, err := RenderText("Hi", nil, opts)
+	if err != nil {
+		t.Fatalf("render text: %v", err)
+	}
+	ink := inkBounds(img, 0)
+	if ink.Empty() || ink.Min.X < 10 || ink.Min.Y < 10 || ink.Max.X > img.Bounds().Dx()-10 {
+		t.Fatalf("ink %v outside margin of %v", ink, img.Bounds())
+	}
+	// 字母 H 竖笔画中间是满高
+	full := 0
+	for y := ink.Min.Y; y < ink.Max.Y; y++ {
+		for x := ink.Min.X; x < ink.Max.X; x++ {
+			if img.Gray16At(x, y).Y == 65535 {
+				full++
+			}
+		}
+	}
+	if full == 0 {
+		t.Fatal("no fully raised pixels")
+	}
+
+	// 两行比一行高，右对齐时短行靠右
+	opts.Align = AlignRight
+	two, err := RenderText("Hi\nH", nil, opts)
+	if err != nil {
+		t.Fatalf("render two lines: %v", err)
+	}
+	if two.Bounds().Dx() != img.Bounds().Dx() || two.Bounds().Dy() <= img.Bounds().Dy() {
+		t.Fatalf("unexpected two-line size %v vs %v", two.Bounds(), img.Bounds())
+	}
+	second := inkBounds(two.SubImage(image.Rect(0, img.Bounds().Dy()-10, two.Bounds().Dx(), two.Bounds().Dy())).(*image.Gray16), 0)
+	if second.Min.X <= ink.Min.X+4 || second.Max.X < ink.Max.X-2 {
+		t.Fatalf("second line not right aligned: %v vs %v", second, ink)
+	}
+
+	// 凹刻：底板为满高，字为 0
+	opts.Align = AlignCenter
+	opts.Engrave = true
+	engraved, err := RenderText("Hi", nil, opts)
+	if err != nil {
+		t.Fatalf("render engraved: %v", err)
+	}
+	if engraved.Gray16At(0, 0).Y != 65535 || inkBounds(engraved, 65535) != ink {
+		t.Fatalf("engraved text differs from raised text")
+	}
+
+	// 斜面：边缘附近出现中间高度，满高像素变少
+	opts.Engrave = false
+	opts.Bevel = 2
+	beveled, err := RenderText("Hi", nil, opts)
+	if err != nil {
+		t.Fatalf("render beveled: %v", err)
+	}
+	beveledFull := 0
+	for y := ink.Min.Y; y < ink.Max.Y; y++ {
+		for x := ink.Min.X; x < ink.Max.X; x++ {
+			if beveled.Gray16At(x, y).Y == 65535 {
+				beveledFull++
+			}
+		}
+	}
+	if beveledFull == 0 || beveledFull >= full {
+		t.Fatalf("bevel should shrink the plateau: %d vs %d", beveledFull, full)
+	}
+}
+
+func TestRenderTextErrors(t *testing.T) {
+	opts := DefaultTextOptions()
+	if _, err := RenderText(" \n ", nil, opts); err == nil {
+		t.Fatal("expected error for blank text")
+	}
+	if _, err := RenderText("Hi", []byte("not a font"), opts); err == nil {
+		t.Fatal("expected error for invalid font data")
+	}
+
+	invalid := []func(o *TextOptions){
+		func(o *TextOptions) { o.Font = "comic" },
+		func(o *TextOptions) { o.Size = 4 },
+		func(o *TextOptions) { o.LineSpacing = 0 },
+		func(o *TextOptions) { o.Align = "justify" },
+		func(o *TextOptions) { o.Bevel = o.Size },
+	}
+	for i, mutate := range invalid {
+		opts := DefaultTextOptions()
+		mutate(&opts)
+		if _, err := RenderText("Hi", nil, opts); err == nil {
+			t.Fatalf("case %d: expected validation error for %+v", i, opts)
+		}
+	}
+}
@@ -43,6 +43,8 @@ let jobPollTimer = null;
 let currentSampleName = "";
 let selectedSourceFile = null;
 let currentJobStatus = "idle";
+// 模型下载路由按任务的输出格式区分（stl / 3mf），以任务查询结果为准
+let currentJobFormat = "stl";
 const downloadState = {
   image: { inFlight: false, triggeredJobs: new Set() },
   stl: { inFlight: false, triggeredJobs: new Set() },
@@ -335,6 +337,7 @@ async function queryJob(jobId) {
 
   const data = await requestJson(`/relief/${jobId}`);
   const status = data.status || "idle";
+  currentJobFormat = data.format || "stl";
 
   setCurrentJobId(jobId);
   setStatus(status);
@@ -380,7 +383,7 @@ async function downloadFile(jobId, kind) {
     throw new Error("下载进行中，请勿重复点击");
   }
 
-  const endpoint = kind === "image" ? "image" : "stl";
+  const endpoint = kind === "image" ? "image" : currentJobFormat;
   const downloadUrl = `${API_BASE}/relief/download/${endpoint}/${jobId}`;
   downloadState[kind].inFlight = true;
   downloadState[kind].triggeredJobs.add(jobId);
@@ -389,7 +392,7 @@ async function downloadFile(jobId, kind) {
     // 用浏览器原生下载链路，避免 fetch+blob 全量缓冲。
     const link = document.createElement("a");
     link.href = downloadUrl;
-    link.download = `${jobId}.${kind === "image" ? "png" : "stl"}`;
+    link.download = `${jobId}.${kind === "image" ? "png" : currentJobFormat}`;
     link.rel = "noopener";
     document.body.appendChild(link);
     link.click();
@@ -23,7 +23,9 @@ func main() {
 		v1.POST("/relief", api.CreateHandler)                             // 创建任务
 		v1.GET("/relief/download/image/:jobId", api.DownloadImageHandler) // 下载image
 		v1.GET("/relief/download/stl/:jobId", api.DownloadStlHandler)     // 下载STL
+		v1.GET("/relief/download/3mf/:jobId", api.Download3MFHandler)     // 下载3MF
 		v1.GET("/relief/:jobId", api.GetJobHandler)                       // 查询任务
+		v1.GET("/relief/algorithms", api.AlgorithmsHandler)               // 深度估计算法列表
 		v1.GET("/relief/queue/status", api.QueueStatusHandler)            // 队列状态
 		v1.DELETE("/relief/queue/:jobId", api.DeleteJobHandler)           // 删除任务
 	}
@@ -0,0 +1,334 @@
+package stl
+
+import (
+	"math"
+)
+
+// 自适应三角化：在均匀采样网格上做四叉树细分，直到每个叶子单元的三角化
+// 与高度场的垂直误差都不超过 maxError（毫米）。
+//
+// 叶子单元用「中心扇形」或「拉链」三角化，并把相邻更细单元落在公共边上的
+// 角点一并纳入，因此相邻单元之间没有 T 形接缝，网格保持闭合。
+
+// quadCell 四叉树叶子，坐标是网格下标（含两端）
+type quadCell struct {
+	x0, y0, x1, y1 int
+}
+
+func (c quadCell) splittable() bool {
+	return c.x1-c.x0 > 1 || c.y1-c.y0 > 1
+}
+
+func (c quadCell) split() []quadCell {
+	mx := (c.x0 + c.x1) / 2
+	my := (c.y0 + c.y1) / 2
+	switch {
+	case c.x1-c.x0 > 1 && c.y1-c.y0 > 1:
+		return []quadCell{
+			{c.x0, c.y0, mx, my},
+			{mx, c.y0, c.x1, my},
+			{c.x0, my, mx, c.y1},
+			{mx, my, c.x1, c.y1},
+		}
+	case c.x1-c.x0 > 1:
+		return []quadCell{{c.x0, c.y0, mx, c.y1}, {mx, c.y0, c.x1, c.y1}}
+	default:
+		return []quadCell{{c.x0, c.y0, c.x1, my}, {c.x0, my, c.x1, c.y1}}
+	}
+}
+
+// hasCenter 单元内部是否存在可作为扇形中心的网格点
+func (c quadCell) hasCenter() bool {
+	return c.x1-c.x0 > 1 && c.y1-c.y0 > 1
+}
+
+func (c quadCell) center() (int, int) {
+	return (c.x0 + c.x1) / 2, (c.y0 + c.y1) / 2
+}
+
+type adaptiveGrid struct {
+	gridW, gridH int
+	xModel       []float32
+	yModel       []float32
+	height       []float64
+	active       []bool // 当前被任一叶子用作顶点的网格点
+}
+
+func (g *adaptiveGrid) idx(x, y int) int {
+	return y*g.gridW + x
+}
+
+func (g *adaptiveGrid) markVertices(leaves []quadCell) {
+	clear(g.active)
+	for _, c := range leaves {
+		g.active[g.idx(c.x0, c.y0)] = true
+		g.active[g.idx(c.x1, c.y0)] = true
+		g.active[g.idx(c.x0, c.y1)] = true
+		g.active[g.idx(c.x1, c.y1)] = true
+		if c.hasCenter() {
+			cx, cy := c.center()
+			g.active[g.idx(cx, cy)] = true
+		}
+	}
+}
+
+// boundary 单元边界上所有活动网格点，顺序：上边 → 右边 → 下边 → 左边
+func (g *adaptiveGrid) boundary(c quadCell) [][2]int {
+	var ring [][2]int
+	for x := c.x0; x < c.x1; x++ {
+		if g.active[g.idx(x, c.y0)] {
+			ring = append(ring, [2]int{x, c.y0})
+		}
+	}
+	for y := c.y0; y < c.y1; y++ {
+		if g.active[g.idx(c.x1, y)] {
+			ring = append(ring, [2]int{c.x1, y})
+		}
+	}
+	for x := c.x1; x > c.x0; x-- {
+		if g.active[g.idx(x, c.y1)] {
+			ring = append(ring, [2]int{x, c.y1})
+		}
+	}
+	for y := c.y1; y > c.y0; y-- {
+		if g.active[g.idx(c.x0, y)] {
+			ring = append(ring, [2]int{c.x0, y})
+		}
+	}
+	return ring
+}
+
+// chain 单元某条边上的活动网格点（从起点到终点）
+func (g *adaptiveGrid) chain(x0, y0, x1, y1 int) [][2]int {
+	var pts [][2]int
+	if x0 == x1 {
+		for y := y0; y <= y1; y++ {
+			if g.active[g.idx(x0, y)] {
+				pts = append(pts, [2]int{x0, y})
+			}
+		}
+		return pts
+	}
+	for x := x0; x <= x1; x++ {
+		if g.active[g.idx(x, y0)] {
+			pts = append(pts, [2]int{x, y0})
+		}
+	}
+	return pts
+}
+
+// triangulate 按网格下标输出单元的三角形，三角形在下标空间（y 向下）内叉积为正；
+// 由于模型坐标 Y 与下标方向相反，输出到网格时需要交换后两个顶点才能让法线朝 +Z
+func (g *adaptiveGrid) triangulate(c quadCell, emit func(a, b, d [2]int)) {
+	if c.hasCenter() {
+		cx, cy := c.center()
+		center := [2]int{cx, cy}
+		ring := g.boundary(c)
+		for i := range ring {
+			emit(center, ring[i], ring[(i+1)%len(ring)])
+		}
+		return
+	}
+
+	// 宽或高只有一个网格步长：在两条长边之间「拉链」
+	var a, b [][2]int
+	if c.x1-c.x0 == 1 {
+		a = g.chain(c.x0, c.y0, c.x0, c.y1)
+		b = g.chain(c.x1, c.y0, c.x1, c.y1)
+	} else {
+		a = g.chain(c.x0, c.y0, c.x1, c.y0)
+		b = g.chain(c.x0, c.y1, c.x1, c.y1)
+	}
+	along := func(p [2]int) int {
+		if c.x1-c.x0 == 1 {
+			return p[1]
+		}
+		return p[0]
+	}
+
+	i, j := 0, 0
+	for i < len(a)-1 || j < len(b)-1 {
+		if j == len(b)-1 || (i < len(a)-1 && along(a[i+1]) <= along(b[j+1])) {
+			orientedEmit(emit, a[i], a[i+1], b[j])
+			i++
+		} else {
+			orientedEmit(emit, a[i], b[j+1], b[j])
+			j++
+		}
+	}
+}
+
+func orientedEmit(emit func(a, b, d [2]int), p1, p2, p3 [2]int) {
+	cross := (p2[0]-p1[0])*(p3[1]-p1[1]) - (p2[1]-p1[1])*(p3[0]-p1[0])
+	if cross < 0 {
+		p2, p3 = p3, p2
+	}
+	emit(p1, p2, p3)
+}
+
+// cellError 单元三角化后，单元内网格采样点的最大垂直误差
+func (g *adaptiveGrid) cellError(c quadCell, limit float64) float64 {
+	var maxErr float64
+	g.triangulate(c, func(a, b, d [2]int) {
+		if maxErr > limit {
+			return
+		}
+		maxErr = max(maxErr, g.triangleError(a, b, d))
+	})
+	return maxErr
+}
+
+// triangleError 逐行扫描三角形覆盖的网格点，与三角形平面比较
+func (g *adaptiveGrid) triangleError(a, b, d [2]int) float64 {
+	pos := func(p [2]int) (float64, float64, float64) {
+		return float64(g.xModel[p[0]]), float64(g.yModel[p[1]]), g.height[g.idx(p[0], p[1])]
+	}
+	ax, ay, az := pos(a)
+	bx, by, bz := pos(b)
+	dx, dy, dz := pos(d)
+	nx := (by-ay)*(dz-az) - (bz-az)*(dy-ay)
+	ny := (bz-az)*(dx-ax) - (bx-ax)*(dz-az)
+	nz := (bx-ax)*(dy-ay) - (by-ay)*(dx-ax)
+	if nz == 0 {
+		return 0
+	}
+
+	minY := min(a[1], b[1], d[1])
+	maxY := max(a[1], b[1], d[1])
+	edges := [3][2][2]int{{a, b}, {b, d}, {d, a}}
+
+	var maxErr float64
+	for y := minY; y <= maxY; y++ {
+		left, right := math.Inf(1), math.Inf(-1)
+		fy := float64(y)
+		for _, e := range edges {
+			p, q := e[0], e[1]
+			if (fy < float64(p[1]) && fy < float64(q[1])) || (fy > float64(p[1]) && fy > float64(q[1])) {
+				continue
+			}
+			if p[1] == q[1] {
+				left = min(left, float64(p[0]), float64(q[0]))
+				right = max(right, float64(p[0]), float64(q[0]))
+				continue
+			}
+			t := (fy - float64(p[1])) / float64(q[1]-p[1])
+			x := float64(p[0]) + t*float64(q[0]-p[0])
+			left = min(left, x)
+			right = max(right, x)
+		}
+
+		Y := float64(g.yModel[y])
+		row := y * g.gridW
+		for x := int(math.Ceil(left - 1e-9)); x <= int(math.Floor(right+1e-9)); x++ {
+			X := float64(g.xModel[x])
+			z := az - (nx*(X-ax)+ny*(Y-ay))/nz
+			maxErr = max(maxErr, math.Abs(g.height[row+x]-z))
+		}
+	}
+	return maxErr
+}
+
+// refine 反复细分误差超标的叶子，直到所有叶子都满足误差要求
+// 细分会给邻居的边增加顶点，从而改变邻居的三角化，所以每轮都要重新检查全部叶子
+func (g *adaptiveGrid) refine(maxError float64) []quadCell {
+	leaves := []quadCell{{0, 0, g.gridW - 1, g.gridH - 1}}
+	for {
+		g.markVertices(leaves)
+		next := make([]quadCell, 0, len(leaves))
+		changed := false
+		for _, c := range leaves {
+			if c.splittable() && g.cellError(c, maxError) > maxError {
+				next = append(next, c.split()...)
+				changed = true
+				continue
+			}
+			next = append(next, c)
+		}
+		leaves = next
+		if !changed {
+			return leaves
+		}
+	}
+}
+
+// addAdaptiveTop 自适应顶面，返回外边界上的顶点环（上边 → 右边 → 下边 → 左边）
+func (m *Mesh) addAdaptiveTop(xModel, yModel []float32, height []float64, maxError float64) []uint32 {
+	g := &adaptiveGrid{
+		gridW:  len(xModel),
+		gridH:  len(yModel),
+		xModel: xModel,
+		yModel: yModel,
+		height: height,
+		active: make([]bool, len(height)),
+	}
+	leaves := g.refine(maxError)
+	g.markVertices(leaves)
+
+	vertexOf := make([]uint32, len(height))
+	for y := 0; y < g.gridH; y++ {
+		for x := 0; x < g.gridW; x++ {
+			i := g.idx(x, y)
+			if !g.active[i] {
+				continue
+			}
+			vertexOf[i] = uint32(len(m.Vertices))
+			m.Vertices = append(m.Vertices, [3]float32{xModel[x], yModel[y], float32(height[i])})
+		}
+	}
+
+	for _, c := range leaves {
+		g.triangulate(c, func(a, b, d [2]int) {
+			m.Triangles = append(m.Triangles, [3]uint32{
+				vertexOf[g.idx(a[0], a[1])],
+				vertexOf[g.idx(d[0], d[1])],
+				vertexOf[g.idx(b[0], b[1])],
+			})
+		})
+	}
+
+	outer := quadCell{0, 0, g.gridW - 1, g.gridH - 1}
+	points := g.boundary(outer)
+	ring := make([]uint32, len(points))
+	for i, p := range points {
+		ring[i] = vertexOf[g.idx(p[0], p[1])]
+	}
+	return ring
+}
+
+// addRingBaseAndWalls 根据顶面外边界环生成底面扇形和侧壁，法线均朝外
+// 环的顺序为上边 → 右边 → 下边 → 左边（从模型上方看为顺时针）。
+// 顶面边界点已在底面高度时（底板厚度为 0）直接作为底面顶点，该处的侧壁面积为 0，不再生成
+func (m *Mesh) addRingBaseAndWalls(ring []uint32, zBase32 float32) {
+	base := make([]uint32, len(ring))
+	minV, maxV := m.Vertices[ring[0]], m.Vertices[ring[0]]
+	for i, vi := range ring {
+		v := m.Vertices[vi]
+		base[i] = vi
+		if v[2] != zBase32 {
+			base[i] = uint32(len(m.Vertices))
+			m.Vertices = append(m.Vertices, [3]float32{v[0], v[1], zBase32})
+		}
+		minV[0], minV[1] = min(minV[0], v[0]), min(minV[1], v[1])
+		maxV[0], maxV[1] = max(maxV[0], v[0]), max(maxV[1], v[1])
+	}
+	center := uint32(len(m.Vertices))
+	m.Vertices = append(m.Vertices, [3]float32{(minV[0] + maxV[0]) * 0.5, (minV[1] + maxV[1]) * 0.5, zBase32})
+
+	n := len(ring)
+	for i := 0; i < n; i++ {
+		j := (i + 1) % n
+		m.Triangles = append(m.Triangles, [3]uint32{center, base[i], base[j]})
+	}
+
+	for i := 0; i < n; i++ {
+		j := (i + 1) % n
+		a, b := ring[i], ring[j]
+		a2, b2 := base[i], base[j]
+		if a2 != a {
+			m.Triangles = append(m.Triangles, [3]uint32{a2, a, b2})
+		}
+		if b2 != b {
+			m.Triangles = append(m.Triangles, [3]uint32{b2, a, b})
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package stl
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// bumpDepthMap 平坦背景 + 中央一个圆形凸起
+func bumpDepthMap(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	cx, cy, r := float64(w)/2, float64(h)/2, float64(min(w, h))/4
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			d := math.Hypot(float64(x)-cx, float64(y)-cy)
+			if d < r {
+				img.Pix[y*img.Stride+x] = uint8(255 * math.Cos(d/r*math.Pi/2))
+			}
+		}
+	}
+	return img
+}
+
+// directedEdgeBalance 闭合且方向一致的网格中，每条有向边恰好出现一次且反向边也出现一次
+func directedEdgeBalance(t *testing.T, m *Mesh) {
+	t.Helper()
+	edges := map[[2]uint32]int{}
+	for _, tri := range m.Triangles {
+		for k := 0; k < 3; k++ {
+			edges[[2]uint32{tri[k], tri[(k+1)%3]}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 {
+			t.Fatalf("directed edge %v used %d times", e, n)
+		}
+		if edges[[2]uint32{e[1], e[0]}] != 1 {
+			t.Fatalf("edge %v has no opposite", e)
+		}
+	}
+}
+
+func TestBuildReliefMeshAdaptive(t *testing.T) {
+	const maxError = 0.05
+	depthMap := bumpDepthMap(64, 48)
+	opts := ReliefOptions{ModelWidth: 64, ModelThickness: 4, BaseThickness: 1, DetailLevel: 1}
+
+	uniform, err := BuildReliefMesh(depthMap, opts)
+	if err != nil {
+		t.Fatalf("build uniform mesh: %v", err)
+	}
+
+	opts.MaxError = maxError
+	adaptive, err := BuildReliefMesh(depthMap, opts)
+	if err != nil {
+		t.Fatalf("build adaptive mesh: %v", err)
+	}
+
+	if adaptive.TriangleCount() >= uniform.TriangleCount()/2 {
+		t.Fatalf("adaptive mesh not reduced: %d vs uniform %d", adaptive.TriangleCount(), uniform.TriangleCount())
+	}
+	directedEdgeBalance(t, adaptive)
+
+	// 顶面上每个采样点都必须落在某个顶面三角形内，且误差不超过 maxError
+	xSamples := buildAxisSamples(64, 1)
+	ySamples := buildAxisSamples(48, 1)
+	height := buildHeightField(depthMap, xSamples, ySamples, opts.ModelThickness, defaultHeightGamma)
+	xModel, yModel := buildModelCoordinates(xSamples, ySamples, 1, 48)
+
+	var top [][3][3]float32
+	for i := range adaptive.Triangles {
+		v1, v2, v3 := adaptive.Triangle(i)
+		if v1[2] >= 0 && v2[2] >= 0 && v3[2] >= 0 {
+			top = append(top, [3][3]float32{v1, v2, v3})
+		}
+	}
+
+	for gy, y := range yModel {
+		for gx, x := range xModel {
+			z, ok := interpolateTop(top, float64(x), float64(y))
+			if !ok {
+				t.Fatalf("sample (%d,%d) not covered by top surface", gx, gy)
+			}
+			if diff := math.Abs(z - height[gy*len(xModel)+gx]); diff > maxError+1e-4 {
+				t.Fatalf("sample (%d,%d) error %.4f exceeds %.4f", gx, gy, diff, maxError)
+			}
+		}
+	}
+}
+
+func interpolateTop(tris [][3][3]float32, x, y float64) (float64, bool) {
+	for _, tri := range tris {
+		ax, ay, az := float64(tri[0][0]), float64(tri[0][1]), float64(tri[0][2])
+		bx, by, bz := float64(tri[1][0]), float64(tri[1][1]), float64(tri[1][2])
+		cx, cy, cz := float64(tri[2][0]), float64(tri[2][1]), float64(tri[2][2])
+		det := (by-cy)*(ax-cx) + (cx-bx)*(ay-cy)
+		if det == 0 {
+			continue
+		}
+		l1 := ((by-cy)*(x-cx) + (cx-bx)*(y-cy)) / det
+		l2 := ((cy-ay)*(x-cx) + (ax-cx)*(y-cy)) / det
+		l3 := 1 - l1 - l2
+		const eps = -1e-6
+		if l1 >= eps && l2 >= eps && l3 >= eps {
+			return l1*az + l2*bz + l3*cz, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package stl
+
+import (
+	"math"
+
+	"github.com/chaos-io/depth2STL/util/poisson"
+)
+
+// 梯度域浅浮雕压缩（参考 Weyrich et al. 2007 "Digital Bas-Relief from 3D Scenes"）：
+// 对高度场的梯度做对数衰减，大的深度跳变被强烈压缩，细小的起伏基本保留，
+// 再用泊松方程从衰减后的梯度重建高度场，最后线性缩放到 modelThickness。
+
+// compressionAlpha 强度为 1 时的衰减系数
+const compressionAlpha = 100
+
+// attenuateGradient 对数衰减：小梯度近似不变，大梯度被压缩为对数增长
+func attenuateGradient(g, alpha float64) float64 {
+	return math.Copysign(math.Log1p(alpha*math.Abs(g))/alpha, g)
+}
+
+// compressHeightField field 为 w×h 的归一化高度（0..1），strength 取 0..1
+func compressHeightField(field []float64, w, h int, strength, modelThickness float64) []float64 {
+	height := make([]float64, len(field))
+	if w < 2 || h < 2 || strength <= 0 {
+		for i, z := range field {
+			height[i] = z * modelThickness
+		}
+		return height
+	}
+
+	// 梯度以「整幅图跨越全部深度」为单位 1，衰减效果与采样密度无关
+	scale := float64(max(w, h))
+	alpha := strength * compressionAlpha
+	compress := func(d float64) float64 {
+		return attenuateGradient(d*scale, alpha) / scale
+	}
+
+	// 前向差分梯度，最后一列/行为 0（Neumann 边界）
+	gx := make([]float64, len(field))
+	gy := make([]float64, len(field))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if x < w-1 {
+				gx[i] = compress(field[i+1] - field[i])
+			}
+			if y < h-1 {
+				gy[i] = compress(field[i+w] - field[i])
+			}
+		}
+	}
+
+	u := poisson.Integrate(gx, gy, w, h)
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range u {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	if hi-lo <= 0 {
+		return height
+	}
+	for i, v := range u {
+		height[i] = (v - lo) / (hi - lo) * modelThickness
+	}
+	return height
+}
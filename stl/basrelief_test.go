@@ -0,0 +1,40 @@
+package stl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompressHeightField(t *testing.T) {
+	// 左半平、右半整体抬高，两边带相同的细小纹理
+	const w, h = 64, 32
+	field := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			z := 0.02 * math.Sin(float64(x)*0.8)
+			if x >= w/2 {
+				z += 0.9
+			}
+			field[y*w+x] = z
+		}
+	}
+
+	height := compressHeightField(field, w, h, 1, 5)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, z := range height {
+		lo, hi = min(lo, z), max(hi, z)
+	}
+	if lo != 0 || math.Abs(hi-5) > 1e-9 {
+		t.Fatalf("expected heights in [0, 5], got [%v, %v]", lo, hi)
+	}
+
+	// 台阶被压缩后，纹理占总高度的比例应明显大于原来的 ~4%
+	row := height[h/2*w : h/2*w+w]
+	var texture float64
+	for x := 1; x < w/2-1; x++ {
+		texture = max(texture, math.Abs(row[x+1]-row[x]))
+	}
+	if texture/5 < 0.1 {
+		t.Fatalf("fine detail not preserved: texture step %v of 5mm", texture)
+	}
+}
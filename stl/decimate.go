@@ -0,0 +1,485 @@
+package stl
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+// 二次误差度量（Garland & Heckbert）边折叠简化。
+//
+// 每个顶点累积相邻面的平面二次型（按面积加权），边折叠到使两端二次型之和最小的位置，
+// 平面区域几乎零代价，脊线和顶面/侧壁的轮廓折线代价很高，因此会被优先保留。
+// 开放网格的边界边额外加入垂直于面的约束平面，避免边界收缩。
+
+const (
+	boundaryQuadricWeight = 1000
+	minNormalCosine       = 0.2  // 折叠后相邻面法线与原法线夹角余弦的下限，防止翻面
+	coplanarFanCosine     = 0.95 // 扇形内各面法线与扇形平均法线的余弦都不低于它时，视为接近共面
+	coplanarNormalCosine  = 0.8  // 接近共面的扇形中，折叠后法线与原法线夹角余弦的下限
+	minFanCosine          = 0.05 // 折叠后各面法线与扇形平均法线夹角余弦的下限，保证投影到扇形平面不重叠
+	minVerticalNormal     = 1e-3 // 原法线 z 分量的绝对值不低于它时，折叠后 z 分量不能变号
+	minCollapseArea       = 1e-6 // 折叠后面积（平方毫米）的下限，避免写回 float32 后成为退化三角形
+)
+
+// quadric 对称 4x4 矩阵的上三角：a² ab ac ad b² bc bd c² cd d²
+type quadric [10]float64
+
+func planeQuadric(a, b, c, d, w float64) quadric {
+	return quadric{
+		w * a * a, w * a * b, w * a * c, w * a * d,
+		w * b * b, w * b * c, w * b * d,
+		w * c * c, w * c * d,
+		w * d * d,
+	}
+}
+
+func (q *quadric) add(o quadric) {
+	for i := range q {
+		q[i] += o[i]
+	}
+}
+
+func (q *quadric) eval(p [3]float64) float64 {
+	x, y, z := p[0], p[1], p[2]
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+}
+
+// optimal 求使误差最小的位置，矩阵接近奇异（如共面区域）时返回 false
+func (q *quadric) optimal() ([3]float64, bool) {
+	a, b, c := q[0], q[1], q[2]
+	d, e := q[4], q[5]
+	f := q[7]
+	det := a*(d*f-e*e) - b*(b*f-e*c) + c*(b*e-d*c)
+	scale := math.Abs(a) + math.Abs(d) + math.Abs(f)
+	if scale == 0 || math.Abs(det) < 1e-9*scale*scale*scale {
+		return [3]float64{}, false
+	}
+
+	// 伴随矩阵求逆，解 A·p = -b
+	inv := [9]float64{
+		d*f - e*e, c*e - b*f, b*e - c*d,
+		c*e - b*f, a*f - c*c, b*c - a*e,
+		b*e - c*d, b*c - a*e, a*d - b*b,
+	}
+	rhs := [3]float64{-q[3], -q[6], -q[8]}
+	var p [3]float64
+	for i := 0; i < 3; i++ {
+		p[i] = (inv[i*3]*rhs[0] + inv[i*3+1]*rhs[1] + inv[i*3+2]*rhs[2]) / det
+	}
+	return p, true
+}
+
+type collapseCandidate struct {
+	cost   float64
+	u, v   uint32
+	stampU uint32
+	stampV uint32
+	target [3]float64
+}
+
+type candidateHeap []collapseCandidate
+
+func (h candidateHeap) Len() int           { return len(h) }
+func (h candidateHeap) Less(i, j int) bool { return h[i].cost < h[j].cost }
+func (h candidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *candidateHeap) Push(x any)        { *h = append(*h, x.(collapseCandidate)) }
+func (h *candidateHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+type decimator struct {
+	pos       [][3]float64
+	quadrics  []quadric
+	faces     [][3]uint32
+	faceAlive []bool
+	vertFaces [][]uint32
+	vertAlive []bool
+	stamp     []uint32
+	markU     []uint32 // 邻接集合标记，配合 gen 做 O(度数) 的集合运算
+	markV     []uint32
+	gen       uint32
+	heap      candidateHeap
+	live      int
+}
+
+// Decimate 用边折叠把网格简化到不超过 targetTriangles 个三角形
+// 闭合网格每次折叠减少 2 个三角形，因此结果为 targetTriangles 或 targetTriangles-1；
+// 若剩余的折叠都会破坏流形或翻转法线，则提前停止并返回 nil，调用方需检查 TriangleCount
+func (m *Mesh) Decimate(targetTriangles int) error {
+	if targetTriangles < 4 {
+		return fmt.Errorf("target triangles must be at least 4, got %d", targetTriangles)
+	}
+	if len(m.Triangles) <= targetTriangles {
+		return nil
+	}
+
+	d := newDecimator(m)
+	for d.live > targetTriangles && d.heap.Len() > 0 {
+		c := heap.Pop(&d.heap).(collapseCandidate)
+		if !d.vertAlive[c.u] || !d.vertAlive[c.v] || d.stamp[c.u] != c.stampU || d.stamp[c.v] != c.stampV {
+			continue
+		}
+		if !d.canCollapse(c.u, c.v, c.target) {
+			continue
+		}
+		d.collapse(c.u, c.v, c.target)
+	}
+
+	d.writeBack(m)
+	return nil
+}
+
+func newDecimator(m *Mesh) *decimator {
+	n := len(m.Vertices)
+	d := &decimator{
+		pos:       make([][3]float64, n),
+		quadrics:  make([]quadric, n),
+		faces:     make([][3]uint32, len(m.Triangles)),
+		faceAlive: make([]bool, len(m.Triangles)),
+		vertFaces: make([][]uint32, n),
+		vertAlive: make([]bool, n),
+		stamp:     make([]uint32, n),
+		markU:     make([]uint32, n),
+		markV:     make([]uint32, n),
+		live:      len(m.Triangles),
+	}
+	for i, v := range m.Vertices {
+		d.pos[i] = [3]float64{float64(v[0]), float64(v[1]), float64(v[2])}
+	}
+	copy(d.faces, m.Triangles)
+
+	edgeFaces := make(map[[2]uint32]int, len(m.Triangles)*3/2)
+	for fi, f := range d.faces {
+		d.faceAlive[fi] = true
+		for _, vi := range f {
+			d.vertFaces[vi] = append(d.vertFaces[vi], uint32(fi))
+			d.vertAlive[vi] = true
+		}
+
+		n, area := d.faceNormal(f, [3]float64{}, math.MaxUint32)
+		if area > 0 {
+			p := d.pos[f[0]]
+			q := planeQuadric(n[0], n[1], n[2], -(n[0]*p[0] + n[1]*p[1] + n[2]*p[2]), area)
+			for _, vi := range f {
+				d.quadrics[vi].add(q)
+			}
+		}
+		for k := 0; k < 3; k++ {
+			edgeFaces[edgeKey(f[k], f[(k+1)%3])]++
+		}
+	}
+
+	// 开放边界：加入经过边且垂直于面的约束平面
+	for _, f := range d.faces {
+		n, area := d.faceNormal(f, [3]float64{}, math.MaxUint32)
+		if area == 0 {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			a, b := f[k], f[(k+1)%3]
+			if edgeFaces[edgeKey(a, b)] != 1 {
+				continue
+			}
+			pa, pb := d.pos[a], d.pos[b]
+			e := sub3(pb, pa)
+			length := math.Sqrt(dot3(e, e))
+			if length == 0 {
+				continue
+			}
+			c := normalize3(cross3(e, n))
+			q := planeQuadric(c[0], c[1], c[2], -dot3(c, pa), boundaryQuadricWeight*length*length)
+			d.quadrics[a].add(q)
+			d.quadrics[b].add(q)
+		}
+	}
+
+	d.heap = make(candidateHeap, 0, len(edgeFaces))
+	for e := range edgeFaces {
+		d.pushCandidate(e[0], e[1])
+	}
+	heap.Init(&d.heap)
+	return d
+}
+
+func edgeKey(a, b uint32) [2]uint32 {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint32{a, b}
+}
+
+func (d *decimator) pushCandidate(u, v uint32) {
+	q := d.quadrics[u]
+	q.add(d.quadrics[v])
+
+	target, ok := q.optimal()
+	cost := math.Inf(1)
+	if ok {
+		cost = q.eval(target)
+	}
+	mid := scale3(add3(d.pos[u], d.pos[v]), 0.5)
+	for _, p := range [3][3]float64{d.pos[u], d.pos[v], mid} {
+		if c := q.eval(p); c < cost {
+			cost, target = c, p
+		}
+	}
+
+	heap.Push(&d.heap, collapseCandidate{
+		cost:   max(cost, 0),
+		u:      u,
+		v:      v,
+		stampU: d.stamp[u],
+		stampV: d.stamp[v],
+		target: target,
+	})
+}
+
+// faceNormal 面的单位法线和面积；moved 号顶点被替换为 p
+func (d *decimator) faceNormal(f [3]uint32, p [3]float64, moved uint32) ([3]float64, float64) {
+	var v [3][3]float64
+	for k, vi := range f {
+		if vi == moved {
+			v[k] = p
+		} else {
+			v[k] = d.pos[vi]
+		}
+	}
+	n := cross3(sub3(v[1], v[0]), sub3(v[2], v[0]))
+	length := math.Sqrt(dot3(n, n))
+	if length == 0 {
+		return n, 0
+	}
+	return scale3(n, 1/length), length / 2
+}
+
+// neighbors u 的一环邻点，mark 用于去重（调用前需递增 gen）
+func (d *decimator) neighbors(u uint32, mark []uint32) []uint32 {
+	var out []uint32
+	for _, fi := range d.vertFaces[u] {
+		if !d.faceAlive[fi] {
+			continue
+		}
+		for _, w := range d.faces[fi] {
+			if w != u && mark[w] != d.gen {
+				mark[w] = d.gen
+				out = append(out, w)
+			}
+		}
+	}
+	return out
+}
+
+func containsVertex(list []uint32, v uint32) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// canCollapse 检查流形条件（link condition）、法线翻转和折叠后的面互相重叠（fold-over）
+func (d *decimator) canCollapse(u, v uint32, target [3]float64) bool {
+	// 共享 (u,v) 的面的第三个顶点
+	var opposite []uint32
+	for _, fi := range d.vertFaces[u] {
+		if !d.faceAlive[fi] {
+			continue
+		}
+		f := d.faces[fi]
+		if f[0] != v && f[1] != v && f[2] != v {
+			continue
+		}
+		for _, w := range f {
+			if w != u && w != v {
+				opposite = append(opposite, w)
+			}
+		}
+	}
+	if len(opposite) == 0 || len(opposite) > 2 {
+		return false
+	}
+
+	d.gen++
+	d.neighbors(u, d.markU)
+	common := 0
+	for _, w := range d.neighbors(v, d.markV) {
+		if d.markU[w] == d.gen {
+			if !containsVertex(opposite, w) {
+				return false
+			}
+			common++
+		}
+	}
+	if common != len(opposite) {
+		return false
+	}
+
+	// 折叠后保留下来的面（u、v 的一环中不含边 (u,v) 的面）及其原法线，
+	// 按面积加权求和得到整个扇形的投影平面法线
+	type ringFace struct {
+		f      [3]uint32
+		moved  uint32
+		before [3]float64
+		area   float64
+	}
+	var (
+		ring []ringFace
+		fan  [3]float64
+	)
+	for _, vi := range [2]uint32{u, v} {
+		for _, fi := range d.vertFaces[vi] {
+			if !d.faceAlive[fi] {
+				continue
+			}
+			f := d.faces[fi]
+			if containsVertex(f[:], u) && containsVertex(f[:], v) {
+				continue
+			}
+			before, area := d.faceNormal(f, [3]float64{}, math.MaxUint32)
+			ring = append(ring, ringFace{f, vi, before, area})
+			fan = add3(fan, scale3(before, area))
+		}
+	}
+	fan = normalize3(fan)
+
+	// 扇形接近共面时，单面法线的容许转角收紧
+	minCosine := minNormalCosine
+	coplanar := true
+	for _, r := range ring {
+		if r.area > 0 && dot3(r.before, fan) < coplanarFanCosine {
+			coplanar = false
+			break
+		}
+	}
+	if coplanar {
+		minCosine = coplanarNormalCosine
+	}
+
+	for _, r := range ring {
+		after, newArea := d.faceNormal(r.f, target, r.moved)
+		if newArea < minCollapseArea || (r.area > 0 && dot3(r.before, after) < minCosine) {
+			return false
+		}
+		// 折叠后每个面投影到扇形平面上仍需保持正向，否则会与相邻面重叠（折叠翻面）。
+		// 陡峭的面只看自身法线转角时，转过接近 90° 就可能在投影中翻转
+		if dot3(after, fan) < minFanCosine {
+			return false
+		}
+		// 浮雕的顶面、底面是高度场：法线 z 分量不能变号，投影到 XY 平面上就始终不重叠，
+		// 远处的陡坡也不会互相穿插。竖直侧壁的 z 分量为 0，不受此限制
+		if math.Abs(r.before[2]) >= minVerticalNormal && after[2]*r.before[2] <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// collapse 把 v 合并到 u 并把 u 移动到 target
+func (d *decimator) collapse(u, v uint32, target [3]float64) {
+	for _, fi := range d.vertFaces[v] {
+		if !d.faceAlive[fi] {
+			continue
+		}
+		f := &d.faces[fi]
+		if containsVertex(f[:], u) {
+			d.faceAlive[fi] = false
+			d.live--
+			continue
+		}
+		for k := range f {
+			if f[k] == v {
+				f[k] = u
+			}
+		}
+		d.vertFaces[u] = append(d.vertFaces[u], fi)
+	}
+
+	alive := d.vertFaces[u][:0]
+	for _, fi := range d.vertFaces[u] {
+		if d.faceAlive[fi] {
+			alive = append(alive, fi)
+		}
+	}
+	d.vertFaces[u] = alive
+	d.vertFaces[v] = nil
+	d.vertAlive[v] = false
+
+	d.pos[u] = target
+	d.quadrics[u].add(d.quadrics[v])
+	d.stamp[u]++
+
+	d.gen++
+	for _, w := range d.neighbors(u, d.markU) {
+		d.pushCandidate(u, w)
+	}
+}
+
+// writeBack 压缩存活的顶点和面，写回网格
+func (d *decimator) writeBack(m *Mesh) {
+	remap := make([]int32, len(d.pos))
+	for i := range remap {
+		remap[i] = -1
+	}
+
+	vertices := make([][3]float32, 0, len(d.pos))
+	triangles := make([][3]uint32, 0, d.live)
+	for fi, f := range d.faces {
+		if !d.faceAlive[fi] {
+			continue
+		}
+		var t [3]uint32
+		for k, vi := range f {
+			if remap[vi] < 0 {
+				remap[vi] = int32(len(vertices))
+				p := d.pos[vi]
+				vertices = append(vertices, [3]float32{float32(p[0]), float32(p[1]), float32(p[2])})
+			}
+			t[k] = uint32(remap[vi])
+		}
+		triangles = append(triangles, t)
+	}
+
+	m.Vertices = vertices
+	m.Triangles = triangles
+}
+
+func add3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func sub3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func scale3(a [3]float64, s float64) [3]float64 {
+	return [3]float64{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func dot3(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross3(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func normalize3(a [3]float64) [3]float64 {
+	l := math.Sqrt(dot3(a, a))
+	if l == 0 {
+		return a
+	}
+	return scale3(a, 1/l)
+}
@@ -0,0 +1,85 @@
+package stl
+
+import (
+	"math"
+	"testing"
+
+	"github.com/chaos-io/depth2STL/depth"
+	"github.com/chaos-io/depth2STL/util"
+)
+
+func TestMeshDecimate(t *testing.T) {
+	mesh, err := BuildReliefMesh(bumpDepthMap(64, 48), ReliefOptions{
+		ModelWidth:     64,
+		ModelThickness: 4,
+		BaseThickness:  1,
+		DetailLevel:    1,
+		MaxError:       1e-4,
+	})
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+	if mesh.TriangleCount() < 1200 {
+		t.Fatalf("fixture mesh too coarse: %d triangles", mesh.TriangleCount())
+	}
+	minBefore, maxBefore := mesh.Bounds()
+
+	const target = 600
+	if err = mesh.Decimate(target); err != nil {
+		t.Fatalf("decimate: %v", err)
+	}
+
+	if n := mesh.TriangleCount(); n > target || n < target-1 {
+		t.Fatalf("unexpected triangle count %d, want %d or %d", n, target, target-1)
+	}
+	directedEdgeBalance(t, mesh)
+
+	// 轮廓（包围盒）和凸起高度应被保留
+	minAfter, maxAfter := mesh.Bounds()
+	for i := 0; i < 3; i++ {
+		if math.Abs(float64(minAfter[i]-minBefore[i])) > 0.05 || math.Abs(float64(maxAfter[i]-maxBefore[i])) > 0.05 {
+			t.Fatalf("bounds changed: before %v..%v after %v..%v", minBefore, maxBefore, minAfter, maxAfter)
+		}
+	}
+}
+
+// 真实浮雕含大量陡峭侧壁和接近共面的扇形，简化后不能出现折叠导致的自相交
+func TestMeshDecimateRealRelief(t *testing.T) {
+	img, err := util.OpenImage(dawnbreaker)
+	if err != nil {
+		t.Fatalf("open image: %v", err)
+	}
+	mesh, err := BuildReliefMesh(depth.GenerateDepthMap16(img, false), ReliefOptions{
+		ModelWidth:     50,
+		ModelThickness: 5,
+		BaseThickness:  2,
+		DetailLevel:    2,
+	})
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+
+	const target = 100000
+	if mesh.TriangleCount() <= target {
+		t.Fatalf("fixture mesh too coarse: %d triangles", mesh.TriangleCount())
+	}
+	if err = mesh.Decimate(target); err != nil {
+		t.Fatalf("decimate: %v", err)
+	}
+	if n := mesh.TriangleCount(); n > target {
+		t.Fatalf("unexpected triangle count %d, want at most %d", n, target)
+	}
+	if report := Validate(mesh); !report.OK() {
+		t.Fatalf("decimated mesh is invalid: %+v", report)
+	}
+}
+
+func TestMeshDecimateInvalidTarget(t *testing.T) {
+	mesh := unitTriangleMesh()
+	if err := mesh.Decimate(0); err == nil {
+		t.Fatal("expected error for zero target")
+	}
+	if err := mesh.Decimate(10); err != nil || mesh.TriangleCount() != 4 {
+		t.Fatalf("mesh below target should be unchanged, err=%v count=%d", err, mesh.TriangleCount())
+	}
+}
@@ -0,0 +1,91 @@
+package stl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Encoder 把 Mesh 编码为某种文件格式
+type Encoder interface {
+	Encode(w io.Writer, m *Mesh) error
+}
+
+// WriteFile 用 enc 把网格写入 path
+func WriteFile(path string, m *Mesh, enc Encoder) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := enc.Encode(f, m); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// BinaryEncoder 二进制 STL：80 字节头 + 三角形数量 + 每个三角形 50 字节
+type BinaryEncoder struct {
+	Header string // 超出 80 字节的部分会被截断
+}
+
+func (e BinaryEncoder) Encode(w io.Writer, m *Mesh) error {
+	bw := bufio.NewWriterSize(w, 1<<20)
+
+	header := make([]byte, 80)
+	copy(header, e.Header)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(m.Triangles))); err != nil {
+		return err
+	}
+
+	var record [50]byte
+	for i := range m.Triangles {
+		v1, v2, v3 := m.Triangle(i)
+		nx, ny, nz := calcNormal(v1, v2, v3)
+		values := [12]float32{nx, ny, nz, v1[0], v1[1], v1[2], v2[0], v2[1], v2[2], v3[0], v3[1], v3[2]}
+		for j, v := range values {
+			binary.LittleEndian.PutUint32(record[j*4:j*4+4], math.Float32bits(v))
+		}
+		if _, err := bw.Write(record[:]); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ASCIIEncoder 文本 STL，Name 为 solid 名称（默认 relief_model）
+type ASCIIEncoder struct {
+	Name string
+}
+
+func (e ASCIIEncoder) Encode(w io.Writer, m *Mesh) error {
+	name := e.Name
+	if name == "" {
+		name = "relief_model"
+	}
+
+	bw := bufio.NewWriterSize(w, 1<<20)
+	_, _ = fmt.Fprintf(bw, "solid %s\n", name)
+	for i := range m.Triangles {
+		v1, v2, v3 := m.Triangle(i)
+		nx, ny, nz := calcNormal(v1, v2, v3)
+		_, _ = fmt.Fprintf(bw, "  facet normal %f %f %f\n", nx, ny, nz)
+		_, _ = fmt.Fprintf(bw, "    outer loop\n")
+		_, _ = fmt.Fprintf(bw, "      vertex %f %f %f\n", v1[0], v1[1], v1[2])
+		_, _ = fmt.Fprintf(bw, "      vertex %f %f %f\n", v2[0], v2[1], v2[2])
+		_, _ = fmt.Fprintf(bw, "      vertex %f %f %f\n", v3[0], v3[1], v3[2])
+		_, _ = fmt.Fprintf(bw, "    endloop\n")
+		_, _ = fmt.Fprintf(bw, "  endfacet\n")
+	}
+	_, _ = fmt.Fprintf(bw, "endsolid %s\n", name)
+
+	return bw.Flush()
+}
@@ -0,0 +1,51 @@
+package stl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func unitTriangleMesh() *Mesh {
+	return &Mesh{
+		Vertices:  [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		Triangles: [][3]uint32{{0, 2, 1}, {0, 1, 3}, {0, 3, 2}, {1, 2, 3}},
+	}
+}
+
+func TestBinaryEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (BinaryEncoder{Header: "test header"}).Encode(&buf, unitTriangleMesh()); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	data := buf.Bytes()
+	if len(data) != 84+4*50 {
+		t.Fatalf("unexpected size %d", len(data))
+	}
+	if !strings.HasPrefix(string(data[:80]), "test header") {
+		t.Fatalf("unexpected header %q", data[:80])
+	}
+	if n := binary.LittleEndian.Uint32(data[80:84]); n != 4 {
+		t.Fatalf("unexpected triangle count %d", n)
+	}
+}
+
+func TestASCIIEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (ASCIIEncoder{Name: "tetra"}).Encode(&buf, unitTriangleMesh()); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	text := buf.String()
+	if !strings.HasPrefix(text, "solid tetra\n") || !strings.HasSuffix(text, "endsolid tetra\n") {
+		t.Fatalf("unexpected solid framing: %q", text)
+	}
+	if n := strings.Count(text, "facet normal"); n != 4 {
+		t.Fatalf("unexpected facet count %d", n)
+	}
+	if !strings.Contains(text, "facet normal 0.000000 0.000000 -1.000000") {
+		t.Fatalf("missing bottom facet normal: %s", text)
+	}
+}
@@ -0,0 +1,171 @@
+package stl
+
+import (
+	"fmt"
+	"image"
+)
+
+// Mesh 顶点共享的三角网格，坐标单位为毫米
+// Triangles 中每个元素是三个顶点在 Vertices 中的下标
+type Mesh struct {
+	Vertices  [][3]float32
+	Triangles [][3]uint32
+}
+
+// ReliefOptions 由深度图构建浮雕网格的参数
+type ReliefOptions struct {
+	ModelWidth     float64 // 模型宽度（毫米）
+	ModelThickness float64 // 浮雕最大高度（毫米）
+	BaseThickness  float64 // 底座厚度（毫米）
+	DetailLevel    int     // 精度等级，决定采样步长和三角形预算
+	MaxError       float64 // >0 时启用自适应三角化，顶面与高度场的最大垂直误差（毫米）
+	Compression    float64 // >0 时启用梯度域浅浮雕压缩（0..1），代替 pow(z, HeightGamma) 映射
+	HeightGamma    float64 // 深度到高度的映射 pow(z, HeightGamma)，默认 0.7
+}
+
+// TriangleCount 三角形数量
+func (m *Mesh) TriangleCount() int {
+	return len(m.Triangles)
+}
+
+// Triangle 返回第 i 个三角形的三个顶点坐标
+func (m *Mesh) Triangle(i int) (v1, v2, v3 [3]float32) {
+	t := m.Triangles[i]
+	return m.Vertices[t[0]], m.Vertices[t[1]], m.Vertices[t[2]]
+}
+
+// Bounds 返回包围盒的最小点和最大点
+func (m *Mesh) Bounds() (minV, maxV [3]float32) {
+	if len(m.Vertices) == 0 {
+		return minV, maxV
+	}
+	minV, maxV = m.Vertices[0], m.Vertices[0]
+	for _, v := range m.Vertices[1:] {
+		for i := 0; i < 3; i++ {
+			minV[i] = min(minV[i], v[i])
+			maxV[i] = max(maxV[i], v[i])
+		}
+	}
+	return minV, maxV
+}
+
+// Transform 对每个顶点原地应用 fn（例如平移、缩放、镜像）
+// 若变换会翻转手性（如单轴镜像），调用方需自行调用 FlipWinding
+func (m *Mesh) Transform(fn func(v [3]float32) [3]float32) {
+	for i, v := range m.Vertices {
+		m.Vertices[i] = fn(v)
+	}
+}
+
+// FlipWinding 翻转所有三角形的环绕方向（法线取反）
+func (m *Mesh) FlipWinding() {
+	for i, t := range m.Triangles {
+		m.Triangles[i] = [3]uint32{t[0], t[2], t[1]}
+	}
+}
+
+// BuildReliefMesh 深度图 → 高度场 → 网格：顶面网格 + 底面扇形 + 四周侧壁
+func BuildReliefMesh(depthMap image.Image, opts ReliefOptions) (*Mesh, error) {
+	b := depthMap.Bounds()
+	return buildGridMesh(b.Dx(), b.Dy(), opts, func(xSamples, ySamples []float64) []float64 {
+		if opts.Compression > 0 {
+			field := sampleDepthField(depthMap, xSamples, ySamples)
+			return compressHeightField(field, len(xSamples), len(ySamples), min(opts.Compression, 1), opts.ModelThickness)
+		}
+		heightGamma := opts.HeightGamma
+		if heightGamma <= 0 {
+			heightGamma = defaultHeightGamma
+		}
+		return buildHeightField(depthMap, xSamples, ySamples, opts.ModelThickness, heightGamma)
+	})
+}
+
+// BuildHeightFieldMesh 直接由 w×h 的高度场（毫米）构建网格，不经过深度图量化，
+// 用于 DEM 地形等本身就有真实高度的数据。opts 中 ModelThickness、Compression、HeightGamma 不生效
+func BuildHeightFieldMesh(field []float64, w, h int, opts ReliefOptions) (*Mesh, error) {
+	if len(field) != w*h {
+		return nil, fmt.Errorf("height field has %d values, want %dx%d", len(field), w, h)
+	}
+	get := func(x, y int) float64 {
+		return field[y*w+x]
+	}
+	return buildGridMesh(w, h, opts, func(xSamples, ySamples []float64) []float64 {
+		return sampleField(get, w, h, xSamples, ySamples)
+	})
+}
+
+// buildGridMesh 按精度等级选采样步长，sample 返回采样点上的高度（毫米）
+func buildGridMesh(w, h int, opts ReliefOptions, sample func(xSamples, ySamples []float64) []float64) (*Mesh, error) {
+	if w < 2 || h < 2 {
+		return nil, fmt.Errorf("depth map too small")
+	}
+
+	detailLevel := max(1, opts.DetailLevel)
+	preferredStep := 1.0 / float64(detailLevel)
+	step := findStepForTriangleBudget(w, h, preferredStep, triangleBudgetByDetailLevel(detailLevel))
+	xSamples := buildAxisSamples(w, step)
+	ySamples := buildAxisSamples(h, step)
+	gridW, gridH := len(xSamples), len(ySamples)
+
+	totalFaces := faceCountByStep(w, h, step)
+	if totalFaces <= 0 {
+		return nil, fmt.Errorf("invalid face count")
+	}
+
+	height := sample(xSamples, ySamples)
+	xModel, yModel := buildModelCoordinates(xSamples, ySamples, opts.ModelWidth/float64(w), h)
+
+	if opts.MaxError > 0 {
+		mesh := &Mesh{}
+		ring := mesh.addAdaptiveTop(xModel, yModel, height, opts.MaxError)
+		mesh.addRingBaseAndWalls(ring, float32(-opts.BaseThickness))
+		return mesh, nil
+	}
+
+	mesh := &Mesh{
+		Vertices:  make([][3]float32, 0, gridW*gridH+2*(gridW+gridH)-3),
+		Triangles: make([][3]uint32, 0, totalFaces),
+	}
+	mesh.addGridTop(xModel, yModel, height)
+	mesh.addRingBaseAndWalls(gridBoundaryRing(gridW, gridH), float32(-opts.BaseThickness))
+
+	return mesh, nil
+}
+
+// addGridTop 顶面：每个网格单元两个三角形，顶点下标为 y*gridW+x，法线朝 +Z
+func (m *Mesh) addGridTop(xModel, yModel []float32, height []float64) {
+	gridW, gridH := len(xModel), len(yModel)
+	for y := 0; y < gridH; y++ {
+		for x := 0; x < gridW; x++ {
+			m.Vertices = append(m.Vertices, [3]float32{xModel[x], yModel[y], float32(height[y*gridW+x])})
+		}
+	}
+	for y := 0; y < gridH-1; y++ {
+		row := uint32(y * gridW)
+		nextRow := uint32((y + 1) * gridW)
+		for x := uint32(0); x < uint32(gridW-1); x++ {
+			m.Triangles = append(m.Triangles,
+				[3]uint32{row + x, nextRow + x, row + x + 1},
+				[3]uint32{row + x + 1, nextRow + x, nextRow + x + 1},
+			)
+		}
+	}
+}
+
+// gridBoundaryRing 网格外边界顶点下标，顺序为上边 → 右边 → 下边 → 左边
+func gridBoundaryRing(gridW, gridH int) []uint32 {
+	ring := make([]uint32, 0, max(0, 2*(gridW+gridH)-4))
+	for x := 0; x < gridW; x++ {
+		ring = append(ring, uint32(x))
+	}
+	for y := 1; y < gridH; y++ {
+		ring = append(ring, uint32(y*gridW+gridW-1))
+	}
+	for x := gridW - 2; x >= 0; x-- {
+		ring = append(ring, uint32((gridH-1)*gridW+x))
+	}
+	for y := gridH - 2; y > 0; y-- {
+		ring = append(ring, uint32(y*gridW))
+	}
+	return ring
+}
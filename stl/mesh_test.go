@@ -0,0 +1,143 @@
+package stl
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func rampDepthMap(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Pix[y*img.Stride+x] = uint8((x + y) * 255 / (w + h - 2))
+		}
+	}
+	return img
+}
+
+func TestBuildReliefMesh(t *testing.T) {
+	mesh, err := BuildReliefMesh(rampDepthMap(20, 10), ReliefOptions{
+		ModelWidth:     40,
+		ModelThickness: 3,
+		BaseThickness:  1,
+		DetailLevel:    1,
+	})
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+
+	if want := faceCountByStep(20, 10, 1); mesh.TriangleCount() != want {
+		t.Fatalf("unexpected triangle count %d, want %d", mesh.TriangleCount(), want)
+	}
+	for i, tri := range mesh.Triangles {
+		for _, v := range tri {
+			if int(v) >= len(mesh.Vertices) {
+				t.Fatalf("triangle %d references vertex %d out of range", i, v)
+			}
+		}
+	}
+
+	minV, maxV := mesh.Bounds()
+	if minV[0] != 0 || maxV[0] != 38 {
+		t.Fatalf("unexpected x range [%v, %v]", minV[0], maxV[0])
+	}
+	if minV[2] != -1 || maxV[2] != 3 {
+		t.Fatalf("unexpected z range [%v, %v]", minV[2], maxV[2])
+	}
+}
+
+func TestBuildReliefMeshTooSmall(t *testing.T) {
+	if _, err := BuildReliefMesh(image.NewGray(image.Rect(0, 0, 1, 5)), ReliefOptions{ModelWidth: 10}); err == nil {
+		t.Fatal("expected error for 1px wide depth map")
+	}
+}
+
+func TestBuildReliefMeshGray16(t *testing.T) {
+	// 两个像素之间只差 1/65535，8 位深度图无法表示
+	img := image.NewGray16(image.Rect(0, 0, 2, 2))
+	img.SetGray16(0, 0, color.Gray16{Y: 65535})
+	img.SetGray16(1, 0, color.Gray16{Y: 65534})
+	img.SetGray16(0, 1, color.Gray16{Y: 65535})
+	img.SetGray16(1, 1, color.Gray16{Y: 65535})
+
+	mesh, err := BuildReliefMesh(img, ReliefOptions{ModelWidth: 2, ModelThickness: 65535, BaseThickness: 1, DetailLevel: 1})
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+
+	var heights []float32
+	for _, v := range mesh.Vertices[:4] {
+		heights = append(heights, v[2])
+	}
+	if heights[0] != 65535 || heights[1] >= heights[0] || heights[1] < 65534 {
+		t.Fatalf("16-bit precision lost: %v", heights)
+	}
+
+	// 非灰度图按 16 位灰度读取
+	rgba := image.NewRGBA64(image.Rect(0, 0, 2, 2))
+	for i := range rgba.Pix {
+		rgba.Pix[i] = 0xff
+	}
+	if _, err = BuildReliefMesh(rgba, ReliefOptions{ModelWidth: 2, ModelThickness: 1, DetailLevel: 1}); err != nil {
+		t.Fatalf("build mesh from RGBA64: %v", err)
+	}
+}
+
+func TestBuildHeightFieldMesh(t *testing.T) {
+	// 高度直接以毫米给出，不做 gamma 映射和归一化
+	field := []float64{0, 1.5, 0, 1.5, 12.25, 1.5, 0, 1.5, 0}
+	mesh, err := BuildHeightFieldMesh(field, 3, 3, ReliefOptions{ModelWidth: 30, BaseThickness: 2, DetailLevel: 1})
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+	minV, maxV := mesh.Bounds()
+	if minV[2] != -2 || maxV[2] != 12.25 || maxV[0] != 20 {
+		t.Fatalf("unexpected bounds %v %v", minV, maxV)
+	}
+	if report := Validate(mesh); !report.OK() {
+		t.Fatalf("invalid mesh: %s", report)
+	}
+
+	if _, err = BuildHeightFieldMesh(field, 2, 2, ReliefOptions{ModelWidth: 30}); err == nil {
+		t.Fatal("expected error for mismatched field size")
+	}
+}
+
+func TestGridResolution(t *testing.T) {
+	for level := 1; level <= 3; level++ {
+		for _, aspect := range []float64{0.25, 1, 3} {
+			w, h := GridResolution(aspect, level)
+			budget := triangleBudgetByDetailLevel(level)
+			if faces := faceCountByStep(w, h, 1); faces > budget {
+				t.Fatalf("level %d aspect %v: %dx%d has %d faces, budget %d", level, aspect, w, h, faces, budget)
+			}
+			if faces := faceCountByStep(w+1, int(math.Round(float64(w+1)*aspect)), 1); faces <= budget {
+				t.Fatalf("level %d aspect %v: %dx%d leaves budget unused", level, aspect, w, h)
+			}
+			if got := float64(h) / float64(w); math.Abs(got-aspect) > 0.01*aspect {
+				t.Fatalf("level %d: aspect %v, got %v", level, aspect, got)
+			}
+		}
+	}
+}
+
+func TestMeshTransform(t *testing.T) {
+	mesh := &Mesh{
+		Vertices:  [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Triangles: [][3]uint32{{0, 1, 2}},
+	}
+	mesh.Transform(func(v [3]float32) [3]float32 {
+		return [3]float32{v[0]*2 + 1, v[1] * 2, v[2] + 5}
+	})
+	minV, maxV := mesh.Bounds()
+	if minV != [3]float32{1, 0, 5} || maxV != [3]float32{3, 2, 5} {
+		t.Fatalf("unexpected bounds %v %v", minV, maxV)
+	}
+
+	mesh.FlipWinding()
+	if mesh.Triangles[0] != [3]uint32{0, 2, 1} {
+		t.Fatalf("unexpected winding %v", mesh.Triangles[0])
+	}
+}
@@ -0,0 +1,202 @@
+package stl
+
+import "fmt"
+
+// 模块网格（二维码、条码）：每个模块是边长 ModelWidth/w 的方块，凸起模块与底板之间的侧壁严格竖直。
+// 高度场网格的顶点都在不同的 xy 上，相邻采样点之间只能是斜坡，所以这里不经过高度场采样，直接拼方块。
+
+// 顶点高度层：底面、底板顶面、凸起模块顶面
+const (
+	levelBase = iota
+	levelPlate
+	levelRaised
+)
+
+// moduleVertexKey 角点 (i, j) 在某一高度层上的顶点。对角相接的两个凸起模块（棋盘格角点）
+// 各用一份顶面顶点（owner 为模块下标），否则四面侧壁共用一条竖边会成为非流形边
+type moduleVertexKey struct {
+	i, j, level, owner int
+}
+
+type moduleMeshBuilder struct {
+	mesh     *Mesh
+	raised   []bool
+	w, h     int
+	size     float64
+	z        [3]float32
+	vertices map[moduleVertexKey]uint32
+}
+
+// BuildModuleMesh 由 w×h 的模块矩阵构建网格，raised 为 true 的模块凸起 opts.ModelThickness，
+// 其余为底板顶面（z = 0），底板厚 opts.BaseThickness。opts 中只有 ModelWidth、ModelThickness、BaseThickness 生效
+func BuildModuleMesh(raised []bool, w, h int, opts ReliefOptions) (*Mesh, error) {
+	switch {
+	case w < 1 || h < 1 || len(raised) != w*h:
+		return nil, fmt.Errorf("module matrix has %d values, want %dx%d", len(raised), w, h)
+	case opts.ModelWidth <= 0:
+		return nil, fmt.Errorf("model width must be positive, got %v", opts.ModelWidth)
+	case opts.ModelThickness <= 0:
+		return nil, fmt.Errorf("module height must be positive, got %v", opts.ModelThickness)
+	case opts.BaseThickness <= 0:
+		return nil, fmt.Errorf("base thickness must be positive, got %v", opts.BaseThickness)
+	}
+
+	b := &moduleMeshBuilder{
+		mesh:     &Mesh{},
+		raised:   raised,
+		w:        w,
+		h:        h,
+		size:     opts.ModelWidth / float64(w),
+		z:        [3]float32{float32(-opts.BaseThickness), 0, float32(opts.ModelThickness)},
+		vertices: make(map[moduleVertexKey]uint32, (w+1)*(h+1)*2),
+	}
+	b.addTops()
+	b.addInnerWalls()
+	b.addOuterWalls()
+	b.addBottom()
+	return b.mesh, nil
+}
+
+// isRaised 模块 (x, y) 是否凸起，矩阵外视为不凸起
+func (b *moduleMeshBuilder) isRaised(x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.w && y < b.h && b.raised[y*b.w+x]
+}
+
+// checker 内部角点 (i, j) 周围四个模块是否只在对角线上凸起
+func (b *moduleMeshBuilder) checker(i, j int) bool {
+	if i <= 0 || j <= 0 || i >= b.w || j >= b.h {
+		return false
+	}
+	tl, tr := b.isRaised(i-1, j-1), b.isRaised(i, j-1)
+	bl, br := b.isRaised(i-1, j), b.isRaised(i, j)
+	return tl == br && tr == bl && tl != tr
+}
+
+func (b *moduleMeshBuilder) vertex(i, j, level, owner int) uint32 {
+	key := moduleVertexKey{i, j, level, owner}
+	if v, ok := b.vertices[key]; ok {
+		return v
+	}
+	v := uint32(len(b.mesh.Vertices))
+	// 第 0 行在模型上方（y 最大），与深度图的方向一致
+	b.mesh.Vertices = append(b.mesh.Vertices, [3]float32{
+		float32(float64(i) * b.size),
+		float32(float64(b.h-j) * b.size),
+		b.z[level],
+	})
+	b.vertices[key] = v
+	return v
+}
+
+// top 模块 (x, y) 在角点 (i, j) 处的顶面顶点
+func (b *moduleMeshBuilder) top(x, y, i, j int) uint32 {
+	if !b.isRaised(x, y) {
+		return b.vertex(i, j, levelPlate, -1)
+	}
+	owner := -1
+	if b.checker(i, j) {
+		owner = y*b.w + x
+	}
+	return b.vertex(i, j, levelRaised, owner)
+}
+
+// addQuad 添加四边形 p1-p2-p3-p4（沿边界依次排列），按 normal 调整环绕方向使法线朝外
+func (b *moduleMeshBuilder) addQuad(p1, p2, p3, p4 uint32, normal [3]float64) {
+	b.addTriangle(p1, p2, p3, normal)
+	b.addTriangle(p1, p3, p4, normal)
+}
+
+func (b *moduleMeshBuilder) addTriangle(p1, p2, p3 uint32, normal [3]float64) {
+	v1, v2, v3 := b.mesh.Vertices[p1], b.mesh.Vertices[p2], b.mesh.Vertices[p3]
+	e1 := [3]float64{float64(v2[0] - v1[0]), float64(v2[1] - v1[1]), float64(v2[2] - v1[2])}
+	e2 := [3]float64{float64(v3[0] - v1[0]), float64(v3[1] - v1[1]), float64(v3[2] - v1[2])}
+	if dot3(cross3(e1, e2), normal) < 0 {
+		p2, p3 = p3, p2
+	}
+	b.mesh.Triangles = append(b.mesh.Triangles, [3]uint32{p1, p2, p3})
+}
+
+// addTops 每个模块的顶面：凸起模块在 levelRaised，其余在 levelPlate
+func (b *moduleMeshBuilder) addTops() {
+	up := [3]float64{0, 0, 1}
+	for y := 0; y < b.h; y++ {
+		for x := 0; x < b.w; x++ {
+			b.addQuad(b.top(x, y, x, y), b.top(x, y, x+1, y), b.top(x, y, x+1, y+1), b.top(x, y, x, y+1), up)
+		}
+	}
+}
+
+// addInnerWalls 相邻的凸起、不凸起模块之间的竖直侧壁，法线朝向不凸起的一侧
+func (b *moduleMeshBuilder) addInnerWalls() {
+	for y := 0; y < b.h; y++ {
+		for x := 0; x < b.w; x++ {
+			// 与右侧模块之间的竖边 i = x+1
+			if x+1 < b.w && b.isRaised(x, y) != b.isRaised(x+1, y) {
+				high, normal := x, [3]float64{1, 0, 0}
+				if b.isRaised(x+1, y) {
+					high, normal = x+1, [3]float64{-1, 0, 0}
+				}
+				b.addQuad(b.vertex(x+1, y, levelPlate, -1), b.vertex(x+1, y+1, levelPlate, -1),
+					b.top(high, y, x+1, y+1), b.top(high, y, x+1, y), normal)
+			}
+			// 与下方模块之间的横边 j = y+1，模型中 y 向上，下方模块在 -y 方向
+			if y+1 < b.h && b.isRaised(x, y) != b.isRaised(x, y+1) {
+				high, normal := y, [3]float64{0, -1, 0}
+				if b.isRaised(x, y+1) {
+					high, normal = y+1, [3]float64{0, 1, 0}
+				}
+				b.addQuad(b.vertex(x, y+1, levelPlate, -1), b.vertex(x+1, y+1, levelPlate, -1),
+					b.top(x, high, x+1, y+1), b.top(x, high, x, y+1), normal)
+			}
+		}
+	}
+}
+
+// addOuterWalls 四周侧壁：底面到底板顶面一段，凸起模块再加底板顶面到模块顶面一段，
+// 这样相邻模块高度不同时竖边上没有 T 形接头
+func (b *moduleMeshBuilder) addOuterWalls() {
+	side := func(x, y, i1, j1, i2, j2 int, normal [3]float64) {
+		b.addQuad(b.vertex(i1, j1, levelBase, -1), b.vertex(i2, j2, levelBase, -1),
+			b.vertex(i2, j2, levelPlate, -1), b.vertex(i1, j1, levelPlate, -1), normal)
+		if b.isRaised(x, y) {
+			b.addQuad(b.vertex(i1, j1, levelPlate, -1), b.vertex(i2, j2, levelPlate, -1),
+				b.top(x, y, i2, j2), b.top(x, y, i1, j1), normal)
+		}
+	}
+	for x := 0; x < b.w; x++ {
+		side(x, 0, x, 0, x+1, 0, [3]float64{0, 1, 0})
+		side(x, b.h-1, x, b.h, x+1, b.h, [3]float64{0, -1, 0})
+	}
+	for y := 0; y < b.h; y++ {
+		side(0, y, 0, y, 0, y+1, [3]float64{-1, 0, 0})
+		side(b.w-1, y, b.w, y, b.w, y+1, [3]float64{1, 0, 0})
+	}
+}
+
+// addBottom 底面：外边界上所有角点与中心点组成的扇形
+func (b *moduleMeshBuilder) addBottom() {
+	ring := make([]uint32, 0, 2*(b.w+b.h))
+	for i := 0; i < b.w; i++ {
+		ring = append(ring, b.vertex(i, 0, levelBase, -1))
+	}
+	for j := 0; j < b.h; j++ {
+		ring = append(ring, b.vertex(b.w, j, levelBase, -1))
+	}
+	for i := b.w; i > 0; i-- {
+		ring = append(ring, b.vertex(i, b.h, levelBase, -1))
+	}
+	for j := b.h; j > 0; j-- {
+		ring = append(ring, b.vertex(0, j, levelBase, -1))
+	}
+
+	center := uint32(len(b.mesh.Vertices))
+	b.mesh.Vertices = append(b.mesh.Vertices, [3]float32{
+		float32(float64(b.w) * b.size / 2),
+		float32(float64(b.h) * b.size / 2),
+		b.z[levelBase],
+	})
+	down := [3]float64{0, 0, -1}
+	for k := range ring {
+		b.addTriangle(center, ring[k], ring[(k+1)%len(ring)], down)
+	}
+}
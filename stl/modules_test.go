@@ -0,0 +1,75 @@
+package stl
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBuildModuleMesh(t *testing.T) {
+	// 棋盘格：四角和中心凸起，凸起模块只在对角相接，且贴着外边界
+	raised := []bool{
+		true, false, true,
+		false, true, false,
+		true, false, true,
+	}
+	opts := ReliefOptions{ModelWidth: 6, ModelThickness: 1, BaseThickness: 2}
+	mesh, err := BuildModuleMesh(raised, 3, 3, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Validate(mesh)
+	if !report.OK() {
+		t.Fatalf("invalid mesh: %s", report)
+	}
+	// 底板 6×6×2 加 5 个 2×2×1 的模块
+	if want := 6.0*6*2 + 5*2*2*1; math.Abs(report.Volume-want) > 1e-3 {
+		t.Fatalf("volume %v, want %v", report.Volume, want)
+	}
+	minV, maxV := mesh.Bounds()
+	if minV != [3]float32{0, 0, -2} || maxV != [3]float32{6, 6, 1} {
+		t.Fatalf("unexpected bounds %v %v", minV, maxV)
+	}
+
+	// 非水平的三角形都是竖直的侧壁：三个顶点 x 相同或 y 相同
+	for i := range mesh.Triangles {
+		v1, v2, v3 := mesh.Triangle(i)
+		if v1[2] == v2[2] && v2[2] == v3[2] {
+			continue
+		}
+		if (v1[0] != v2[0] || v2[0] != v3[0]) && (v1[1] != v2[1] || v2[1] != v3[1]) {
+			t.Fatalf("triangle %d is not vertical: %v %v %v", i, v1, v2, v3)
+		}
+	}
+
+	// 随机图案同样闭合、流形
+	rng := rand.New(rand.NewSource(1))
+	random := make([]bool, 24*16)
+	for i := range random {
+		random[i] = rng.Intn(2) == 0
+	}
+	if mesh, err = BuildModuleMesh(random, 24, 16, opts); err != nil {
+		t.Fatal(err)
+	}
+	if report = Validate(mesh); !report.OK() {
+		t.Fatalf("invalid random mesh: %s", report)
+	}
+
+	// 第 0 行在模型上方
+	mesh, err = BuildModuleMesh([]bool{true, false, false, false}, 2, 2, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range mesh.Vertices {
+		if v[2] == 1 && (v[0] > 3 || v[1] < 3) {
+			t.Fatalf("raised vertex %v outside the top-left module", v)
+		}
+	}
+
+	if _, err = BuildModuleMesh(raised, 2, 3, opts); err == nil {
+		t.Fatal("expected error for mismatched matrix size")
+	}
+	if _, err = BuildModuleMesh(raised, 3, 3, ReliefOptions{ModelWidth: 6, ModelThickness: 1}); err == nil {
+		t.Fatal("expected error without base")
+	}
+}
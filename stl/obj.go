@@ -0,0 +1,106 @@
+package stl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ReadOBJ 解析 Wavefront OBJ 的顶点（v）和面（f），多边形面按扇形拆成三角形
+// 纹理坐标、法线、材质、分组等其它指令会被忽略
+func ReadOBJ(r io.Reader) (*Mesh, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	mesh := &Mesh{}
+	var (
+		line    int
+		polygon []uint32
+	)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("obj: line %d: vertex needs 3 coordinates", line)
+			}
+			var v [3]float32
+			for c := 0; c < 3; c++ {
+				f, err := strconv.ParseFloat(fields[c+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("obj: line %d: %w", line, err)
+				}
+				v[c] = float32(f)
+			}
+			mesh.Vertices = append(mesh.Vertices, v)
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("obj: line %d: face needs at least 3 vertices", line)
+			}
+			polygon = polygon[:0]
+			for _, ref := range fields[1:] {
+				i, err := objVertexIndex(ref, len(mesh.Vertices))
+				if err != nil {
+					return nil, fmt.Errorf("obj: line %d: %w", line, err)
+				}
+				polygon = append(polygon, i)
+			}
+			for k := 1; k+1 < len(polygon); k++ {
+				mesh.Triangles = append(mesh.Triangles, [3]uint32{polygon[0], polygon[k], polygon[k+1]})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(mesh.Triangles) == 0 {
+		return nil, fmt.Errorf("obj: no faces found")
+	}
+	return mesh, nil
+}
+
+// objVertexIndex 解析 "v"、"v/vt"、"v//vn"、"v/vt/vn" 中的顶点下标，支持负数（相对末尾）
+func objVertexIndex(ref string, count int) (uint32, error) {
+	if slash := strings.IndexByte(ref, '/'); slash >= 0 {
+		ref = ref[:slash]
+	}
+	i, err := strconv.Atoi(ref)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		i += count + 1
+	}
+	if i < 1 || i > count {
+		return 0, fmt.Errorf("vertex index %s out of range", ref)
+	}
+	return uint32(i - 1), nil
+}
+
+// ReadModelFile 按扩展名读取 .stl 或 .obj 模型
+func ReadModelFile(path string) (*Mesh, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".stl":
+		return ReadFile(path)
+	case ".obj":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		return ReadOBJ(f)
+	default:
+		return nil, fmt.Errorf("unsupported model file: %s", filepath.Base(path))
+	}
+}
@@ -0,0 +1,190 @@
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	binaryHeaderSize = 84 // 80 字节头 + uint32 三角形数量
+	binaryFacetSize  = 50 // 法线 + 3 个顶点（12 个 float32）+ 2 字节属性
+)
+
+// ReadFile 读取 STL 文件（二进制或文本），相同坐标的顶点会被合并
+func ReadFile(path string) (*Mesh, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return Read(f)
+}
+
+// Read 解析二进制或文本 STL，并按坐标完全相同合并顶点
+//
+// 常见的不规范文件也能读取：
+//   - 以 "solid" 开头的二进制文件（按文件大小识别为二进制）
+//   - 头部三角形数量与文件大小不符（以文件中完整的三角形记录为准）
+func Read(r io.Reader) (*Mesh, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if isBinarySTL(data) {
+		return readBinary(data)
+	}
+	if looksLikeASCII(data) {
+		mesh, err := readASCII(data)
+		if err == nil {
+			return mesh, nil
+		}
+		// 头部以 "solid" 开头、但数量字段不对的二进制文件
+		if len(data) < binaryHeaderSize {
+			return nil, err
+		}
+	}
+	if len(data) < binaryHeaderSize {
+		return nil, fmt.Errorf("stl: file too short (%d bytes)", len(data))
+	}
+	return readBinary(data)
+}
+
+// isBinarySTL 三角形数量与文件大小完全吻合
+func isBinarySTL(data []byte) bool {
+	if len(data) < binaryHeaderSize {
+		return false
+	}
+	n := binary.LittleEndian.Uint32(data[80:84])
+	return uint64(len(data)) == binaryHeaderSize+uint64(n)*binaryFacetSize
+}
+
+func looksLikeASCII(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid"))
+}
+
+func readBinary(data []byte) (*Mesh, error) {
+	declared := int(binary.LittleEndian.Uint32(data[80:84]))
+	n := (len(data) - binaryHeaderSize) / binaryFacetSize
+	if declared > 0 && declared < n {
+		// 数量之后还有附加数据，以头部数量为准
+		n = declared
+	}
+
+	w := newWelder(n)
+	for i := 0; i < n; i++ {
+		record := data[binaryHeaderSize+i*binaryFacetSize:]
+		var t [3][3]float32
+		for k := 0; k < 3; k++ {
+			for c := 0; c < 3; c++ {
+				off := 12 + k*12 + c*4
+				t[k][c] = math.Float32frombits(binary.LittleEndian.Uint32(record[off : off+4]))
+			}
+		}
+		w.addTriangle(t)
+	}
+	return w.mesh, nil
+}
+
+func readASCII(data []byte) (*Mesh, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	w := newWelder(0)
+	var (
+		facet   [3][3]float32
+		count   int
+		inFacet bool
+		line    int
+	)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case "facet":
+			if inFacet {
+				return nil, fmt.Errorf("stl: line %d: facet not closed", line)
+			}
+			inFacet, count = true, 0
+		case "vertex":
+			if !inFacet || count >= 3 || len(fields) < 4 {
+				return nil, fmt.Errorf("stl: line %d: unexpected vertex", line)
+			}
+			for c := 0; c < 3; c++ {
+				v, err := strconv.ParseFloat(fields[c+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("stl: line %d: %w", line, err)
+				}
+				facet[count][c] = float32(v)
+			}
+			count++
+		case "endfacet":
+			if !inFacet || count != 3 {
+				return nil, fmt.Errorf("stl: line %d: facet has %d vertices", line, count)
+			}
+			w.addTriangle(facet)
+			inFacet = false
+		case "solid", "outer", "endloop", "endsolid":
+		default:
+			return nil, fmt.Errorf("stl: line %d: unexpected keyword %q", line, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inFacet {
+		return nil, fmt.Errorf("stl: unexpected end of file inside facet")
+	}
+	if len(w.mesh.Triangles) == 0 {
+		return nil, fmt.Errorf("stl: no facets found")
+	}
+	return w.mesh, nil
+}
+
+// welder 按坐标合并重复顶点，构建共享顶点的网格
+type welder struct {
+	mesh  *Mesh
+	index map[[3]float32]uint32
+}
+
+func newWelder(triangles int) *welder {
+	return &welder{
+		mesh: &Mesh{
+			Vertices:  make([][3]float32, 0, triangles/2+3),
+			Triangles: make([][3]uint32, 0, triangles),
+		},
+		index: make(map[[3]float32]uint32, triangles/2+3),
+	}
+}
+
+func (w *welder) vertex(v [3]float32) uint32 {
+	for c := range v {
+		if v[c] == 0 {
+			v[c] = 0 // -0 与 0 视为同一坐标
+		}
+	}
+	if i, ok := w.index[v]; ok {
+		return i
+	}
+	i := uint32(len(w.mesh.Vertices))
+	w.index[v] = i
+	w.mesh.Vertices = append(w.mesh.Vertices, v)
+	return i
+}
+
+func (w *welder) addTriangle(t [3][3]float32) {
+	w.mesh.Triangles = append(w.mesh.Triangles, [3]uint32{w.vertex(t[0]), w.vertex(t[1]), w.vertex(t[2])})
+}
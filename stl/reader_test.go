@@ -0,0 +1,77 @@
+package stl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestReadRoundTrip(t *testing.T) {
+	mesh, err := BuildReliefMesh(bumpDepthMap(30, 20), ReliefOptions{
+		ModelWidth:     30,
+		ModelThickness: 3,
+		BaseThickness:  1,
+		DetailLevel:    1,
+	})
+	if err != nil {
+		t.Fatalf("build mesh: %v", err)
+	}
+
+	encoders := map[string]Encoder{
+		"binary": BinaryEncoder{Header: "solid but actually binary"},
+		"ascii":  ASCIIEncoder{},
+	}
+	for name, enc := range encoders {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, mesh); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			got, err := Read(&buf)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if len(got.Vertices) != len(mesh.Vertices) || len(got.Triangles) != len(mesh.Triangles) {
+				t.Fatalf("expected %d vertices / %d triangles, got %d / %d",
+					len(mesh.Vertices), len(mesh.Triangles), len(got.Vertices), len(got.Triangles))
+			}
+			if report := Validate(got); !report.OK() {
+				t.Fatalf("welded mesh is not closed: %s", report)
+			}
+		})
+	}
+}
+
+func TestReadBinaryCountMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (BinaryEncoder{}).Encode(&buf, unitTriangleMesh()); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	for _, count := range []uint32{0, 99} {
+		data := bytes.Clone(buf.Bytes())
+		binary.LittleEndian.PutUint32(data[80:84], count)
+
+		mesh, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("count %d: read: %v", count, err)
+		}
+		if len(mesh.Triangles) != 4 || len(mesh.Vertices) != 4 {
+			t.Fatalf("count %d: unexpected mesh %d vertices / %d triangles", count, len(mesh.Vertices), len(mesh.Triangles))
+		}
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	inputs := map[string]string{
+		"empty":      "",
+		"short":      "solid x\nfacet normal 0 0 1\n",
+		"bad number": "solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 zero\n",
+	}
+	for name, input := range inputs {
+		if _, err := Read(bytes.NewBufferString(input)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package stl
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// 正交投影的观察方向（模型按 Z 轴朝上摆放）
+const (
+	ViewFront  = "front"  // 从 -Y 看向 +Y
+	ViewBack   = "back"   // 从 +Y 看向 -Y
+	ViewLeft   = "left"   // 从 -X 看向 +X
+	ViewRight  = "right"  // 从 +X 看向 -X
+	ViewTop    = "top"    // 从 +Z 俯视
+	ViewBottom = "bottom" // 从 -Z 仰视
+)
+
+// viewBasis 图像向右、向上以及指向观察者的单位向量，三者构成右手系
+type viewBasis struct {
+	right, up, toward [3]float64
+}
+
+var viewBases = map[string]viewBasis{
+	ViewFront:  {right: [3]float64{1, 0, 0}, up: [3]float64{0, 0, 1}, toward: [3]float64{0, -1, 0}},
+	ViewBack:   {right: [3]float64{-1, 0, 0}, up: [3]float64{0, 0, 1}, toward: [3]float64{0, 1, 0}},
+	ViewLeft:   {right: [3]float64{0, -1, 0}, up: [3]float64{0, 0, 1}, toward: [3]float64{-1, 0, 0}},
+	ViewRight:  {right: [3]float64{0, 1, 0}, up: [3]float64{0, 0, 1}, toward: [3]float64{1, 0, 0}},
+	ViewTop:    {right: [3]float64{1, 0, 0}, up: [3]float64{0, 1, 0}, toward: [3]float64{0, 0, 1}},
+	ViewBottom: {right: [3]float64{1, 0, 0}, up: [3]float64{0, -1, 0}, toward: [3]float64{0, 0, -1}},
+}
+
+// ValidView 是否为支持的观察方向
+func ValidView(view string) bool {
+	_, ok := viewBases[view]
+	return ok
+}
+
+// RenderOptions 模型渲染为高度图的参数
+type RenderOptions struct {
+	View       string // 观察方向，默认 front
+	Resolution int    // 图像长边像素数，默认 1024
+}
+
+// RenderHeightMap 正交投影 + Z-buffer，把模型离观察者最近的表面渲染为 16 位高度图
+// 模型表面线性映射到 1..65535（越近越亮），未被覆盖的背景为 0；图像保持模型的长宽比
+func RenderHeightMap(m *Mesh, opts RenderOptions) (*image.Gray16, error) {
+	view := opts.View
+	if view == "" {
+		view = ViewFront
+	}
+	basis, ok := viewBases[view]
+	if !ok {
+		return nil, fmt.Errorf("unknown view %q", opts.View)
+	}
+	resolution := opts.Resolution
+	if resolution <= 0 {
+		resolution = 1024
+	}
+	if len(m.Triangles) == 0 {
+		return nil, fmt.Errorf("model has no triangles")
+	}
+
+	// 投影到观察坐标：u 向右、v 向上、d 指向观察者
+	proj := make([][3]float64, len(m.Vertices))
+	minP := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	maxP := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for i, v := range m.Vertices {
+		p := [3]float64{float64(v[0]), float64(v[1]), float64(v[2])}
+		q := [3]float64{dot3(p, basis.right), dot3(p, basis.up), dot3(p, basis.toward)}
+		proj[i] = q
+		for c := 0; c < 3; c++ {
+			minP[c] = min(minP[c], q[c])
+			maxP[c] = max(maxP[c], q[c])
+		}
+	}
+
+	spanU, spanV := maxP[0]-minP[0], maxP[1]-minP[1]
+	if spanU <= 0 || spanV <= 0 {
+		return nil, fmt.Errorf("model is flat in the %s view", view)
+	}
+	scale := float64(resolution) / max(spanU, spanV)
+	w := max(1, int(math.Round(spanU*scale)))
+	h := max(1, int(math.Round(spanV*scale)))
+
+	// 像素中心 (x+0.5, y+0.5)，图像 y 向下
+	toPixel := func(q [3]float64) [3]float64 {
+		return [3]float64{(q[0] - minP[0]) * scale, (maxP[1] - q[1]) * scale, q[2]}
+	}
+
+	zbuf := make([]float64, w*h)
+	for i := range zbuf {
+		zbuf[i] = math.Inf(-1)
+	}
+	for _, t := range m.Triangles {
+		rasterizeDepth(zbuf, w, h, toPixel(proj[t[0]]), toPixel(proj[t[1]]), toPixel(proj[t[2]]))
+	}
+
+	depthMin, depthMax := math.Inf(1), math.Inf(-1)
+	for _, d := range zbuf {
+		if !math.IsInf(d, -1) {
+			depthMin = min(depthMin, d)
+			depthMax = max(depthMax, d)
+		}
+	}
+	if math.IsInf(depthMin, 1) {
+		return nil, fmt.Errorf("model covers no pixels in the %s view", view)
+	}
+	depthRange := depthMax - depthMin
+
+	img := image.NewGray16(image.Rect(0, 0, w, h))
+	for i, d := range zbuf {
+		if math.IsInf(d, -1) {
+			continue
+		}
+		level := 65535.0
+		if depthRange > 0 {
+			level = 1 + (d-depthMin)/depthRange*65534
+		}
+		img.SetGray16(i%w, i/w, color.Gray16{Y: uint16(math.Round(level))})
+	}
+	return img, nil
+}
+
+// rasterizeDepth 对像素中心做重心坐标插值，保留离观察者最近（d 最大）的深度
+func rasterizeDepth(zbuf []float64, w, h int, a, b, c [3]float64) {
+	area := (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	if area == 0 {
+		return
+	}
+
+	x0 := max(0, int(math.Floor(min(a[0], b[0], c[0]))))
+	x1 := min(w-1, int(math.Ceil(max(a[0], b[0], c[0]))))
+	y0 := max(0, int(math.Floor(min(a[1], b[1], c[1]))))
+	y1 := min(h-1, int(math.Ceil(max(a[1], b[1], c[1]))))
+
+	for y := y0; y <= y1; y++ {
+		py := float64(y) + 0.5
+		for x := x0; x <= x1; x++ {
+			px := float64(x) + 0.5
+			// 两种环绕方向都接受：背面同样参与深度比较
+			w0 := ((b[0]-px)*(c[1]-py) - (b[1]-py)*(c[0]-px)) / area
+			w1 := ((c[0]-px)*(a[1]-py) - (c[1]-py)*(a[0]-px)) / area
+			w2 := 1 - w0 - w1
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			d := w0*a[2] + w1*b[2] + w2*c[2]
+			i := y*w + x
+			if d > zbuf[i] {
+				zbuf[i] = d
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package stl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHeightMap(t *testing.T) {
+	img, err := RenderHeightMap(unitTriangleMesh(), RenderOptions{View: ViewTop, Resolution: 100})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 100 || b.Dy() != 100 {
+		t.Fatalf("unexpected size %v", b)
+	}
+
+	// 俯视：原点在图像左下角，顶点 (0,0,1) 最高；(1,1) 附近没有模型
+	if apex := img.Gray16At(1, 98).Y; apex < 60000 {
+		t.Fatalf("expected apex near max height, got %d", apex)
+	}
+	if far := img.Gray16At(50, 80).Y; far == 0 || far > 40000 {
+		t.Fatalf("expected mid height inside footprint, got %d", far)
+	}
+	if bg := img.Gray16At(95, 5).Y; bg != 0 {
+		t.Fatalf("expected background 0, got %d", bg)
+	}
+
+	// 正视：宽 1（X）高 1（Z），离观察者最近的是 y=0 的面
+	img, err = RenderHeightMap(unitTriangleMesh(), RenderOptions{View: ViewFront, Resolution: 64})
+	if err != nil {
+		t.Fatalf("render front: %v", err)
+	}
+	if v := img.Gray16At(10, 50).Y; v != 65535 {
+		t.Fatalf("expected front face at max height, got %d", v)
+	}
+
+	if _, err = RenderHeightMap(unitTriangleMesh(), RenderOptions{View: "diagonal"}); err == nil {
+		t.Fatal("expected error for unknown view")
+	}
+}
+
+func TestReadOBJ(t *testing.T) {
+	src := `# unit square pyramid
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0.5 0.5 1
+vt 0 0
+f 1/1 4 3 2
+f 1//1 2 5
+f 2 3 5
+f -3 -2 -1
+f 4 1 5
+`
+	mesh, err := ReadOBJ(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("read obj: %v", err)
+	}
+	if len(mesh.Vertices) != 5 || len(mesh.Triangles) != 6 {
+		t.Fatalf("unexpected mesh %d vertices / %d triangles", len(mesh.Vertices), len(mesh.Triangles))
+	}
+	if report := Validate(mesh); !report.OK() {
+		t.Fatalf("pyramid should be closed: %s", report)
+	}
+
+	if _, err = ReadOBJ(strings.NewReader("v 0 0 0\nf 1 2 3\n")); err == nil {
+		t.Fatal("expected error for out of range index")
+	}
+}
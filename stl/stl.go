@@ -2,9 +2,9 @@ package stl
 
 import (
 	"bufio"
-	"encoding/binary"
 	"fmt"
 	"image"
+	"image/color"
 	"io"
 	"math"
 	"os"
@@ -95,6 +95,27 @@ func findStepForTriangleBudget(w, h int, preferredStep float64, budget int) floa
 	return high
 }
 
+// maxGridResolution GridResolution 单边的上限，避免极端长宽比时分配过大的图
+const maxGridResolution = 16384
+
+// GridResolution 高宽比为 aspect 的高度图在 detailLevel 下按 1 像素步长建网格、
+// 刚好不超过三角面预算的尺寸。矢量输入按这个尺寸光栅化，每个像素正好对应一个网格点
+func GridResolution(aspect float64, detailLevel int) (w, h int) {
+	budget := triangleBudgetByDetailLevel(detailLevel)
+	height := func(w int) int {
+		return min(max(2, int(math.Round(float64(w)*aspect))), maxGridResolution)
+	}
+	// 顶面约 2·w·h 个三角面，先估计再逐步调整到刚好不超过预算
+	w = min(max(2, int(math.Sqrt(float64(budget)/(2*aspect)))), maxGridResolution)
+	for w < maxGridResolution && faceCountByStep(w+1, height(w+1), 1) <= budget {
+		w++
+	}
+	for w > 2 && faceCountByStep(w, height(w), 1) > budget {
+		w--
+	}
+	return w, height(w)
+}
+
 func buildAxisSamples(length int, step float64) []float64 {
 	if length < 2 {
 		return nil
@@ -112,15 +133,26 @@ func buildAxisSamples(length int, step float64) []float64 {
 	return samples
 }
 
-func buildHeightField(depthMap *image.Gray, xSamples, ySamples []float64, modelThickness float64) []float64 {
-	w, h := len(xSamples), len(ySamples)
-	height := make([]float64, w*h)
-	imgW := depthMap.Bounds().Dx()
-	imgH := depthMap.Bounds().Dy()
+// defaultHeightGamma 深度到高度的默认非线性映射，提升暗部细节
+const defaultHeightGamma = 0.7
 
-	get := func(x, y int) float64 {
-		return float64(depthMap.Pix[y*depthMap.Stride+x]) / 255.0
+func buildHeightField(depthMap image.Image, xSamples, ySamples []float64, modelThickness, heightGamma float64) []float64 {
+	height := sampleDepthField(depthMap, xSamples, ySamples)
+	for i, z := range height {
+		height[i] = math.Pow(z, heightGamma) * modelThickness
 	}
+	return height
+}
+
+// sampleDepthField 在采样点上双线性插值深度图，结果归一化到 0..1
+func sampleDepthField(depthMap image.Image, xSamples, ySamples []float64) []float64 {
+	return sampleField(depthReader(depthMap), depthMap.Bounds().Dx(), depthMap.Bounds().Dy(), xSamples, ySamples)
+}
+
+// sampleField 在采样点上双线性插值 imgW×imgH 的栅格
+func sampleField(get func(x, y int) float64, imgW, imgH int, xSamples, ySamples []float64) []float64 {
+	w, h := len(xSamples), len(ySamples)
+	field := make([]float64, w*h)
 
 	for gy, y := range ySamples {
 		y0 := int(math.Floor(y))
@@ -138,13 +170,33 @@ func buildHeightField(depthMap *image.Gray, xSamples, ySamples []float64, modelT
 
 			z0 := z00*(1-fx) + z10*fx
 			z1 := z01*(1-fx) + z11*fx
-			z := z0*(1-fy) + z1*fy
-			z = math.Pow(z, 0.7)
-			height[gy*w+gx] = z * modelThickness
+			field[gy*w+gx] = z0*(1-fy) + z1*fy
 		}
 	}
 
-	return height
+	return field
+}
+
+// depthReader 读取深度图像素（相对 Bounds().Min 的坐标），归一化到 0..1
+// 8 位和 16 位灰度图直接读 Pix，其它图像按 16 位灰度转换
+func depthReader(depthMap image.Image) func(x, y int) float64 {
+	switch img := depthMap.(type) {
+	case *image.Gray:
+		return func(x, y int) float64 {
+			return float64(img.Pix[y*img.Stride+x]) / 255.0
+		}
+	case *image.Gray16:
+		return func(x, y int) float64 {
+			i := y*img.Stride + x*2
+			return float64(uint16(img.Pix[i])<<8|uint16(img.Pix[i+1])) / 65535.0
+		}
+	default:
+		minP := depthMap.Bounds().Min
+		return func(x, y int) float64 {
+			c := color.Gray16Model.Convert(depthMap.At(minP.X+x, minP.Y+y)).(color.Gray16)
+			return float64(c.Y) / 65535.0
+		}
+	}
 }
 
 func buildModelCoordinates(xSamples, ySamples []float64, pixel float64, h int) ([]float32, []float32) {
@@ -162,187 +214,6 @@ func buildModelCoordinates(xSamples, ySamples []float64, pixel float64, h int) (
 	return xModel, yModel
 }
 
-type binarySTLWriter struct {
-	w      *bufio.Writer
-	record [50]byte
-}
-
-func newBinarySTLWriter(w *bufio.Writer) *binarySTLWriter {
-	return &binarySTLWriter{w: w}
-}
-
-func (bw *binarySTLWriter) writeHeader(totalFaces int) error {
-	header := make([]byte, 80)
-	copy(header, []byte("Relief STL Binary (GenerateSTL5)"))
-	if _, err := bw.w.Write(header); err != nil {
-		return err
-	}
-	return binary.Write(bw.w, binary.LittleEndian, uint32(totalFaces))
-}
-
-func (bw *binarySTLWriter) writeTri(v1, v2, v3 [3]float32) error {
-	nx, ny, nz := calcNormal(v1, v2, v3)
-	off := 0
-	putF32 := func(v float32) {
-		binary.LittleEndian.PutUint32(bw.record[off:off+4], math.Float32bits(v))
-		off += 4
-	}
-	putF32(nx)
-	putF32(ny)
-	putF32(nz)
-	putF32(v1[0])
-	putF32(v1[1])
-	putF32(v1[2])
-	putF32(v2[0])
-	putF32(v2[1])
-	putF32(v2[2])
-	putF32(v3[0])
-	putF32(v3[1])
-	putF32(v3[2])
-	binary.LittleEndian.PutUint16(bw.record[48:50], 0)
-	_, err := bw.w.Write(bw.record[:])
-	return err
-}
-
-type point2 struct {
-	x float32
-	y float32
-}
-
-func buildBottomBoundary(xModel, yModel []float32) []point2 {
-	gridW, gridH := len(xModel), len(yModel)
-	boundary := make([]point2, 0, max(0, 2*(gridW+gridH)-4))
-
-	for x := 0; x < gridW; x++ {
-		boundary = append(boundary, point2{x: xModel[x], y: yModel[0]})
-	}
-	for y := 1; y < gridH; y++ {
-		boundary = append(boundary, point2{x: xModel[gridW-1], y: yModel[y]})
-	}
-	for x := gridW - 2; x >= 0; x-- {
-		boundary = append(boundary, point2{x: xModel[x], y: yModel[gridH-1]})
-	}
-	for y := gridH - 2; y > 0; y-- {
-		boundary = append(boundary, point2{x: xModel[0], y: yModel[y]})
-	}
-
-	return boundary
-}
-
-func writeTopSurface(w *binarySTLWriter, xModel, yModel []float32, height []float64, gridW, gridH int) error {
-	for y := 0; y < gridH-1; y++ {
-		row := y * gridW
-		nextRow := (y + 1) * gridW
-		y0 := yModel[y]
-		y1 := yModel[y+1]
-		for x := 0; x < gridW-1; x++ {
-			x0 := xModel[x]
-			x1 := xModel[x+1]
-			z00 := float32(height[row+x])
-			z10 := float32(height[row+x+1])
-			z01 := float32(height[nextRow+x])
-			z11 := float32(height[nextRow+x+1])
-
-			if err := w.writeTri(
-				[3]float32{x0, y0, z00},
-				[3]float32{x1, y0, z10},
-				[3]float32{x0, y1, z01},
-			); err != nil {
-				return err
-			}
-			if err := w.writeTri(
-				[3]float32{x1, y0, z10},
-				[3]float32{x1, y1, z11},
-				[3]float32{x0, y1, z01},
-			); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
-}
-
-func writeBottomSurfaceFan(w *binarySTLWriter, xModel, yModel []float32, zBase32 float32) error {
-	boundary := buildBottomBoundary(xModel, yModel)
-	center := [3]float32{
-		(xModel[0] + xModel[len(xModel)-1]) * 0.5,
-		(yModel[0] + yModel[len(yModel)-1]) * 0.5,
-		zBase32,
-	}
-	for i := 0; i < len(boundary); i++ {
-		j := (i + 1) % len(boundary)
-		v1 := [3]float32{boundary[i].x, boundary[i].y, zBase32}
-		v2 := [3]float32{boundary[j].x, boundary[j].y, zBase32}
-		if err := w.writeTri(center, v2, v1); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func writeSideWalls(w *binarySTLWriter, xModel, yModel []float32, height []float64, gridW, gridH int, zBase32 float32) error {
-	for x := 0; x < gridW-1; x++ {
-		x0 := xModel[x]
-		x1 := xModel[x+1]
-		z1 := float32(height[(gridH-1)*gridW+x])
-		z2 := float32(height[(gridH-1)*gridW+x+1])
-		y := yModel[gridH-1]
-
-		if err := w.writeTri([3]float32{x0, y, zBase32}, [3]float32{x1, y, zBase32}, [3]float32{x0, y, z1}); err != nil {
-			return err
-		}
-		if err := w.writeTri([3]float32{x1, y, zBase32}, [3]float32{x1, y, z2}, [3]float32{x0, y, z1}); err != nil {
-			return err
-		}
-	}
-
-	yMax := yModel[0]
-	for x := 0; x < gridW-1; x++ {
-		x0 := xModel[x]
-		x1 := xModel[x+1]
-		z1 := float32(height[x])
-		z2 := float32(height[x+1])
-
-		if err := w.writeTri([3]float32{x0, yMax, zBase32}, [3]float32{x0, yMax, z1}, [3]float32{x1, yMax, zBase32}); err != nil {
-			return err
-		}
-		if err := w.writeTri([3]float32{x1, yMax, zBase32}, [3]float32{x0, yMax, z1}, [3]float32{x1, yMax, z2}); err != nil {
-			return err
-		}
-	}
-
-	for y := 0; y < gridH-1; y++ {
-		y0 := yModel[y]
-		y1 := yModel[y+1]
-		z1 := float32(height[y*gridW])
-		z2 := float32(height[(y+1)*gridW])
-
-		if err := w.writeTri([3]float32{0, y0, zBase32}, [3]float32{0, y0, z1}, [3]float32{0, y1, zBase32}); err != nil {
-			return err
-		}
-		if err := w.writeTri([3]float32{0, y1, zBase32}, [3]float32{0, y0, z1}, [3]float32{0, y1, z2}); err != nil {
-			return err
-		}
-	}
-
-	xMax := xModel[gridW-1]
-	for y := 0; y < gridH-1; y++ {
-		y0 := yModel[y]
-		y1 := yModel[y+1]
-		z1 := float32(height[y*gridW+(gridW-1)])
-		z2 := float32(height[(y+1)*gridW+(gridW-1)])
-
-		if err := w.writeTri([3]float32{xMax, y0, zBase32}, [3]float32{xMax, y1, zBase32}, [3]float32{xMax, y0, z1}); err != nil {
-			return err
-		}
-		if err := w.writeTri([3]float32{xMax, y1, zBase32}, [3]float32{xMax, y1, z2}, [3]float32{xMax, y0, z1}); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func GenerateSTL(depthMap *image.Gray, outputPath string, modelWidth, modelThickness, baseThickness float64) error {
 	height := depthMap.Bounds().Dy()
 	width := depthMap.Bounds().Dx()
@@ -737,60 +608,21 @@ func GenerateSTL4(depthMap *image.Gray, outputPath string, modelWidth, modelThic
 
 // GenerateSTL5
 // 1. depthMap → heightField（缓存）
-// 2. heightField → mesh（避免重复计算）
-// 3. mesh → Binary STL（高速输出）
-func GenerateSTL5(depthMap *image.Gray, outputPath string, modelWidth, modelThickness, baseThickness float64, detailLevel int) error {
-	b := depthMap.Bounds()
-	w, h := b.Dx(), b.Dy()
-	if w < 2 || h < 2 {
-		return fmt.Errorf("depth map too small")
-	}
-
-	if detailLevel < 1 {
-		detailLevel = 1
-	}
-
-	preferredStep := 1.0 / float64(detailLevel)
-	maxTriangles := triangleBudgetByDetailLevel(detailLevel)
-	step := findStepForTriangleBudget(w, h, preferredStep, maxTriangles)
-	pixel := modelWidth / float64(w)
-	xSamples := buildAxisSamples(w, step)
-	ySamples := buildAxisSamples(h, step)
-	gridW, gridH := len(xSamples), len(ySamples)
-	height := buildHeightField(depthMap, xSamples, ySamples, modelThickness)
-
-	totalFaces := faceCountByStep(w, h, step)
-	if totalFaces <= 0 {
-		return fmt.Errorf("invalid face count")
-	}
-
-	f, err := os.Create(outputPath)
+// 2. heightField → Mesh（顶点共享，避免重复计算）
+// 3. Mesh → Binary STL（高速输出）
+// depthMap 可以是 8 位或 16 位灰度图，16 位时高度有 65536 级
+func GenerateSTL5(depthMap image.Image, outputPath string, modelWidth, modelThickness, baseThickness float64, detailLevel int) error {
+	mesh, err := BuildReliefMesh(depthMap, ReliefOptions{
+		ModelWidth:     modelWidth,
+		ModelThickness: modelThickness,
+		BaseThickness:  baseThickness,
+		DetailLevel:    detailLevel,
+	})
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	buffered := bufio.NewWriterSize(f, 1<<20)
-	defer buffered.Flush()
-	stlWriter := newBinarySTLWriter(buffered)
-	if err := stlWriter.writeHeader(totalFaces); err != nil {
-		return err
-	}
-
-	xModel, yModel := buildModelCoordinates(xSamples, ySamples, pixel, h)
-	zBase32 := float32(-baseThickness)
-
-	if err := writeTopSurface(stlWriter, xModel, yModel, height, gridW, gridH); err != nil {
-		return err
-	}
-	if err := writeBottomSurfaceFan(stlWriter, xModel, yModel, zBase32); err != nil {
-		return err
-	}
-	if err := writeSideWalls(stlWriter, xModel, yModel, height, gridW, gridH, zBase32); err != nil {
-		return err
-	}
-
-	return nil
+	return WriteFile(outputPath, mesh, BinaryEncoder{Header: "Relief STL Binary (GenerateSTL5)"})
 }
 
 func calcNormal(v1, v2, v3 [3]float32) (float32, float32, float32) {
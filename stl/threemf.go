@@ -0,0 +1,146 @@
+package stl
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"strconv"
+)
+
+const (
+	threeMFCoreNamespace = "http://schemas.microsoft.com/3dmanufacturing/core/2015/02"
+	threeMFMetaNamespace = "https://github.com/chaos-io/depth2STL"
+	threeMFModelPath     = "3D/3dmodel.model"
+)
+
+const threeMFContentTypes = `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>
+`
+
+const threeMFRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Target="/` + threeMFModelPath + `" Id="rel0" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+</Relationships>
+`
+
+// ThreeMFEncoder 3MF：zip 压缩的 XML，顶点共享，单位毫米
+// Metadata 写入模型的 <metadata>，其中 Title 等 3MF 标准字段原样写入，其余字段加 depth2stl 命名空间前缀
+type ThreeMFEncoder struct {
+	Metadata map[string]string
+}
+
+func (e ThreeMFEncoder) Encode(w io.Writer, m *Mesh) error {
+	zw := zip.NewWriter(w)
+	if err := writeZipEntry(zw, "[Content_Types].xml", threeMFContentTypes); err != nil {
+		return err
+	}
+	if err := writeZipEntry(zw, "_rels/.rels", threeMFRels); err != nil {
+		return err
+	}
+
+	entry, err := zw.Create(threeMFModelPath)
+	if err != nil {
+		return err
+	}
+	if err := write3MFModel(entry, m, e.Metadata); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Generate3MF 与 GenerateSTL5 相同的浮雕网格，输出为 3MF
+func Generate3MF(depthMap image.Image, outputPath string, modelWidth, modelThickness, baseThickness float64, detailLevel int, metadata map[string]string) error {
+	mesh, err := BuildReliefMesh(depthMap, ReliefOptions{
+		ModelWidth:     modelWidth,
+		ModelThickness: modelThickness,
+		BaseThickness:  baseThickness,
+		DetailLevel:    detailLevel,
+	})
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(outputPath, mesh, ThreeMFEncoder{Metadata: metadata})
+}
+
+func writeZipEntry(zw *zip.Writer, name, content string) error {
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(entry, content)
+	return err
+}
+
+// 3MF 规范中无需命名空间的标准 metadata 名称
+var threeMFStandardMetadata = map[string]bool{
+	"Title":            true,
+	"Designer":         true,
+	"Description":      true,
+	"Copyright":        true,
+	"LicenseTerms":     true,
+	"Rating":           true,
+	"CreationDate":     true,
+	"ModificationDate": true,
+	"Application":      true,
+}
+
+func write3MFModel(w io.Writer, mesh *Mesh, metadata map[string]string) error {
+	bw := bufio.NewWriterSize(w, 1<<20)
+
+	_, _ = fmt.Fprintf(bw, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	_, _ = fmt.Fprintf(bw, "<model unit=\"millimeter\" xml:lang=\"en-US\" xmlns=\"%s\" xmlns:depth2stl=\"%s\">\n", threeMFCoreNamespace, threeMFMetaNamespace)
+
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := k
+		if !threeMFStandardMetadata[k] {
+			name = "depth2stl:" + k
+		}
+		_, _ = fmt.Fprintf(bw, "  <metadata name=\"%s\">", name)
+		if err := xml.EscapeText(bw, []byte(metadata[k])); err != nil {
+			return err
+		}
+		_, _ = bw.WriteString("</metadata>\n")
+	}
+
+	_, _ = bw.WriteString("  <resources>\n    <object id=\"1\" type=\"model\">\n      <mesh>\n        <vertices>\n")
+	buf := make([]byte, 0, 128)
+	for _, v := range mesh.Vertices {
+		buf = append(buf[:0], "          <vertex x=\""...)
+		buf = strconv.AppendFloat(buf, float64(v[0]), 'f', -1, 32)
+		buf = append(buf, "\" y=\""...)
+		buf = strconv.AppendFloat(buf, float64(v[1]), 'f', -1, 32)
+		buf = append(buf, "\" z=\""...)
+		buf = strconv.AppendFloat(buf, float64(v[2]), 'f', -1, 32)
+		buf = append(buf, "\"/>\n"...)
+		_, _ = bw.Write(buf)
+	}
+	_, _ = bw.WriteString("        </vertices>\n        <triangles>\n")
+	for _, t := range mesh.Triangles {
+		buf = append(buf[:0], "          <triangle v1=\""...)
+		buf = strconv.AppendUint(buf, uint64(t[0]), 10)
+		buf = append(buf, "\" v2=\""...)
+		buf = strconv.AppendUint(buf, uint64(t[1]), 10)
+		buf = append(buf, "\" v3=\""...)
+		buf = strconv.AppendUint(buf, uint64(t[2]), 10)
+		buf = append(buf, "\"/>\n"...)
+		_, _ = bw.Write(buf)
+	}
+	_, _ = bw.WriteString("        </triangles>\n      </mesh>\n    </object>\n  </resources>\n")
+	_, _ = bw.WriteString("  <build>\n    <item objectid=\"1\"/>\n  </build>\n</model>\n")
+
+	return bw.Flush()
+}
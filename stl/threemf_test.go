@@ -0,0 +1,92 @@
+package stl
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate3MF(t *testing.T) {
+	depthMap := rampDepthMap(16, 12)
+	outPath := filepath.Join(t.TempDir(), "relief.3mf")
+
+	err := Generate3MF(depthMap, outPath, 30, 2, 1, 1, map[string]string{
+		"Title":      "ramp & <test>",
+		"modelWidth": "30",
+	})
+	if err != nil {
+		t.Fatalf("generate 3mf: %v", err)
+	}
+
+	zr, err := zip.OpenReader(outPath)
+	if err != nil {
+		t.Fatalf("open 3mf: %v", err)
+	}
+	defer zr.Close()
+
+	var model struct {
+		Unit     string `xml:"unit,attr"`
+		Metadata []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"metadata"`
+		Vertices  []struct{} `xml:"resources>object>mesh>vertices>vertex"`
+		Triangles []struct {
+			V1 int `xml:"v1,attr"`
+			V2 int `xml:"v2,attr"`
+			V3 int `xml:"v3,attr"`
+		} `xml:"resources>object>mesh>triangles>triangle"`
+	}
+
+	found := map[string]bool{}
+	for _, f := range zr.File {
+		found[f.Name] = true
+		if f.Name != threeMFModelPath {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open model entry: %v", err)
+		}
+		err = xml.NewDecoder(rc).Decode(&model)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("decode model: %v", err)
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", threeMFModelPath} {
+		if !found[name] {
+			t.Fatalf("missing entry %s", name)
+		}
+	}
+
+	if model.Unit != "millimeter" {
+		t.Fatalf("unexpected unit %q", model.Unit)
+	}
+	wantVertices := 16*12 + 2*(16+12) - 4 + 1
+	if len(model.Vertices) != wantVertices {
+		t.Fatalf("unexpected vertex count %d, want %d", len(model.Vertices), wantVertices)
+	}
+	if want := faceCountByStep(16, 12, 1); len(model.Triangles) != want {
+		t.Fatalf("unexpected triangle count %d, want %d", len(model.Triangles), want)
+	}
+	for _, tri := range model.Triangles {
+		for _, v := range []int{tri.V1, tri.V2, tri.V3} {
+			if v < 0 || v >= wantVertices {
+				t.Fatalf("triangle references vertex %d out of range", v)
+			}
+		}
+	}
+
+	meta := map[string]string{}
+	for _, m := range model.Metadata {
+		meta[m.Name] = m.Value
+	}
+	if meta["Title"] != "ramp & <test>" {
+		t.Fatalf("unexpected title %q", meta["Title"])
+	}
+	if meta["depth2stl:modelWidth"] != "30" {
+		t.Fatalf("unexpected modelWidth metadata %q", meta["depth2stl:modelWidth"])
+	}
+}
@@ -0,0 +1,391 @@
+package stl
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ValidationReport 网格闭合性 / 流形 / 朝向检查结果
+type ValidationReport struct {
+	Vertices            int     `json:"vertices"`
+	Triangles           int     `json:"triangles"`
+	OpenEdges           int     `json:"openEdges"`           // 只属于一个三角形的边
+	NonManifoldEdges    int     `json:"nonManifoldEdges"`    // 属于三个及以上三角形的边
+	FlippedEdges        int     `json:"flippedEdges"`        // 两侧三角形环绕方向不一致的边
+	DegenerateTriangles int     `json:"degenerateTriangles"` // 面积为 0 或顶点重复的三角形
+	SelfIntersections   int     `json:"selfIntersections"`   // 互相穿插的（不共享顶点的）三角形对
+	Volume              float64 `json:"volume"`              // 带符号体积（立方毫米），为负说明法线整体朝内
+	InsideOut           bool    `json:"insideOut"`
+}
+
+// OK 网格闭合、流形、朝向一致且无自相交
+func (r *ValidationReport) OK() bool {
+	return r.OpenEdges == 0 &&
+		r.NonManifoldEdges == 0 &&
+		r.FlippedEdges == 0 &&
+		r.DegenerateTriangles == 0 &&
+		r.SelfIntersections == 0 &&
+		!r.InsideOut
+}
+
+// Problems 可读的问题列表
+func (r *ValidationReport) Problems() []string {
+	var problems []string
+	if r.OpenEdges > 0 {
+		problems = append(problems, fmt.Sprintf("%d open edges", r.OpenEdges))
+	}
+	if r.NonManifoldEdges > 0 {
+		problems = append(problems, fmt.Sprintf("%d non-manifold edges", r.NonManifoldEdges))
+	}
+	if r.FlippedEdges > 0 {
+		problems = append(problems, fmt.Sprintf("%d edges with flipped neighbours", r.FlippedEdges))
+	}
+	if r.DegenerateTriangles > 0 {
+		problems = append(problems, fmt.Sprintf("%d degenerate triangles", r.DegenerateTriangles))
+	}
+	if r.SelfIntersections > 0 {
+		problems = append(problems, fmt.Sprintf("%d self-intersecting triangle pairs", r.SelfIntersections))
+	}
+	if r.InsideOut {
+		problems = append(problems, "normals point inwards")
+	}
+	return problems
+}
+
+func (r *ValidationReport) String() string {
+	if r.OK() {
+		return "ok"
+	}
+	return strings.Join(r.Problems(), ", ")
+}
+
+// degenerateArea 小于该面积（平方毫米）的三角形视为退化
+const degenerateArea = 1e-10
+
+// Validate 检查网格：开放边、非流形边、相邻面朝向、退化三角形、自相交和整体朝向
+func Validate(m *Mesh) *ValidationReport {
+	report := &ValidationReport{
+		Vertices:  len(m.Vertices),
+		Triangles: len(m.Triangles),
+	}
+
+	// 无向边 → 正向 / 反向使用次数
+	type edgeUse struct{ forward, backward int }
+	edges := make(map[[2]uint32]*edgeUse, len(m.Triangles)*3/2)
+	var volume float64
+	for i, t := range m.Triangles {
+		if t[0] == t[1] || t[1] == t[2] || t[0] == t[2] || triangleArea(m, i) < degenerateArea {
+			report.DegenerateTriangles++
+		}
+		for k := 0; k < 3; k++ {
+			a, b := t[k], t[(k+1)%3]
+			key := edgeKey(a, b)
+			use := edges[key]
+			if use == nil {
+				use = &edgeUse{}
+				edges[key] = use
+			}
+			if a < b {
+				use.forward++
+			} else {
+				use.backward++
+			}
+		}
+
+		v1, v2, v3 := m.Triangle(i)
+		p1 := [3]float64{float64(v1[0]), float64(v1[1]), float64(v1[2])}
+		p2 := [3]float64{float64(v2[0]), float64(v2[1]), float64(v2[2])}
+		p3 := [3]float64{float64(v3[0]), float64(v3[1]), float64(v3[2])}
+		volume += dot3(p1, cross3(p2, p3)) / 6
+	}
+
+	for _, use := range edges {
+		switch total := use.forward + use.backward; {
+		case total == 1:
+			report.OpenEdges++
+		case total > 2:
+			report.NonManifoldEdges++
+		case use.forward != 1:
+			report.FlippedEdges++
+		}
+	}
+
+	report.Volume = volume
+	report.InsideOut = volume < 0
+	report.SelfIntersections = countSelfIntersections(m)
+	return report
+}
+
+func triangleArea(m *Mesh, i int) float64 {
+	v1, v2, v3 := m.Triangle(i)
+	a := [3]float64{float64(v2[0] - v1[0]), float64(v2[1] - v1[1]), float64(v2[2] - v1[2])}
+	b := [3]float64{float64(v3[0] - v1[0]), float64(v3[1] - v1[1]), float64(v3[2] - v1[2])}
+	c := cross3(a, b)
+	return math.Sqrt(dot3(c, c)) / 2
+}
+
+// spatialGrid 三角形的均匀空间网格（CSR 存储），用于自相交检测的粗筛
+type spatialGrid struct {
+	origin [3]float64
+	cell   float64
+	dims   [3]int
+	start  []int32 // 每个格子在 items 中的起始位置
+	items  []int32
+}
+
+func (g *spatialGrid) cellIndex(x, y, z int) int {
+	return (z*g.dims[1]+y)*g.dims[0] + x
+}
+
+func (g *spatialGrid) coord(v float64, axis int) int {
+	c := int((v - g.origin[axis]) / g.cell)
+	return min(max(c, 0), g.dims[axis]-1)
+}
+
+// visitCells 遍历与三角形相交的格子：XY 按行扫描三角形在该行条带内的 x 范围，Z 取包围盒范围
+func (g *spatialGrid) visitCells(tri [3][3]float64, fn func(cell int)) {
+	minV, maxV := tri[0], tri[0]
+	for _, p := range tri[1:] {
+		for a := 0; a < 3; a++ {
+			minV[a] = math.Min(minV[a], p[a])
+			maxV[a] = math.Max(maxV[a], p[a])
+		}
+	}
+	y0, y1 := g.coord(minV[1], 1), g.coord(maxV[1], 1)
+	z0, z1 := g.coord(minV[2], 2), g.coord(maxV[2], 2)
+
+	for cy := y0; cy <= y1; cy++ {
+		slabLo := g.origin[1] + float64(cy)*g.cell
+		slabHi := slabLo + g.cell
+		if cy == y0 {
+			slabLo = math.Inf(-1)
+		}
+		if cy == y1 {
+			slabHi = math.Inf(1)
+		}
+
+		xLo, xHi := math.Inf(1), math.Inf(-1)
+		for k := 0; k < 3; k++ {
+			p, q := tri[k], tri[(k+1)%3]
+			lo, hi, ok := clipSegmentToSlab(p, q, slabLo, slabHi)
+			if ok {
+				xLo = math.Min(xLo, lo)
+				xHi = math.Max(xHi, hi)
+			}
+		}
+		if xLo > xHi {
+			continue
+		}
+
+		x0, x1 := g.coord(xLo, 0), g.coord(xHi, 0)
+		for cz := z0; cz <= z1; cz++ {
+			for cx := x0; cx <= x1; cx++ {
+				fn(g.cellIndex(cx, cy, cz))
+			}
+		}
+	}
+}
+
+// clipSegmentToSlab 线段落在 y ∈ [lo, hi] 内部分的 x 范围
+func clipSegmentToSlab(p, q [3]float64, lo, hi float64) (float64, float64, bool) {
+	t0, t1 := 0.0, 1.0
+	dy := q[1] - p[1]
+	if dy == 0 {
+		if p[1] < lo || p[1] > hi {
+			return 0, 0, false
+		}
+	} else {
+		ta, tb := (lo-p[1])/dy, (hi-p[1])/dy
+		if ta > tb {
+			ta, tb = tb, ta
+		}
+		t0, t1 = math.Max(t0, ta), math.Min(t1, tb)
+		if t0 > t1 {
+			return 0, 0, false
+		}
+	}
+	xa := p[0] + t0*(q[0]-p[0])
+	xb := p[0] + t1*(q[0]-p[0])
+	return math.Min(xa, xb), math.Max(xa, xb), true
+}
+
+func newSpatialGrid(m *Mesh, tris [][3][3]float64) *spatialGrid {
+	minV, maxV := m.Bounds()
+	g := &spatialGrid{}
+	var extent [3]float64
+	volume := 1.0
+	for a := 0; a < 3; a++ {
+		g.origin[a] = float64(minV[a])
+		extent[a] = math.Max(float64(maxV[a]-minV[a]), 1e-6)
+		volume *= extent[a]
+	}
+	// 格子总数约等于三角形数
+	g.cell = math.Cbrt(volume / float64(max(1, len(tris))))
+	for a := 0; a < 3; a++ {
+		g.dims[a] = max(1, min(1024, int(math.Ceil(extent[a]/g.cell))))
+	}
+	cells := g.dims[0] * g.dims[1] * g.dims[2]
+
+	counts := make([]int32, cells+1)
+	for _, tri := range tris {
+		g.visitCells(tri, func(c int) { counts[c+1]++ })
+	}
+	for i := 1; i <= cells; i++ {
+		counts[i] += counts[i-1]
+	}
+	g.start = counts
+	g.items = make([]int32, counts[cells])
+	fill := make([]int32, cells)
+	for i, tri := range tris {
+		g.visitCells(tri, func(c int) {
+			g.items[g.start[c]+fill[c]] = int32(i)
+			fill[c]++
+		})
+	}
+	return g
+}
+
+// countSelfIntersections 统计互相穿插的三角形对；共享顶点的相邻三角形不参与检测
+func countSelfIntersections(m *Mesh) int {
+	if len(m.Triangles) < 2 {
+		return 0
+	}
+
+	tris := make([][3][3]float64, len(m.Triangles))
+	boxes := make([][2][3]float64, len(m.Triangles))
+	for i := range m.Triangles {
+		v1, v2, v3 := m.Triangle(i)
+		for k, v := range [3][3]float32{v1, v2, v3} {
+			tris[i][k] = [3]float64{float64(v[0]), float64(v[1]), float64(v[2])}
+		}
+		boxes[i] = [2][3]float64{tris[i][0], tris[i][0]}
+		for _, p := range tris[i][1:] {
+			for a := 0; a < 3; a++ {
+				boxes[i][0][a] = math.Min(boxes[i][0][a], p[a])
+				boxes[i][1][a] = math.Max(boxes[i][1][a], p[a])
+			}
+		}
+	}
+
+	g := newSpatialGrid(m, tris)
+	// 同一对三角形可能同时落在多个格子里，用集合去重
+	intersecting := map[[2]int32]struct{}{}
+	for c := 0; c+1 < len(g.start); c++ {
+		items := g.items[g.start[c]:g.start[c+1]]
+		for i := 0; i < len(items); i++ {
+			a := items[i]
+			for j := i + 1; j < len(items); j++ {
+				b := items[j]
+				if sharesVertex(m.Triangles[a], m.Triangles[b]) || !boxesOverlap(boxes[a], boxes[b]) {
+					continue
+				}
+				key := [2]int32{min(a, b), max(a, b)}
+				if _, ok := intersecting[key]; ok {
+					continue
+				}
+				if trianglesIntersect(tris[a], tris[b]) {
+					intersecting[key] = struct{}{}
+				}
+			}
+		}
+	}
+	return len(intersecting)
+}
+
+func sharesVertex(a, b [3]uint32) bool {
+	for _, x := range a {
+		if x == b[0] || x == b[1] || x == b[2] {
+			return true
+		}
+	}
+	return false
+}
+
+func boxesOverlap(a, b [2][3]float64) bool {
+	for k := 0; k < 3; k++ {
+		if a[0][k] > b[1][k] || b[0][k] > a[1][k] {
+			return false
+		}
+	}
+	return true
+}
+
+// trianglesIntersect 两个不共享顶点的三角形是否相交（Möller 区间重叠法）
+// 仅接触（距离在容差内）不算相交
+func trianglesIntersect(t1, t2 [3][3]float64) bool {
+	n2 := cross3(sub3(t2[1], t2[0]), sub3(t2[2], t2[0]))
+	n1 := cross3(sub3(t1[1], t1[0]), sub3(t1[2], t1[0]))
+	len1, len2 := math.Sqrt(dot3(n1, n1)), math.Sqrt(dot3(n2, n2))
+	if len1 == 0 || len2 == 0 {
+		return false
+	}
+	n1, n2 = scale3(n1, 1/len1), scale3(n2, 1/len2)
+
+	scale := 0.0
+	for _, p := range [6][3]float64{t1[0], t1[1], t1[2], t2[0], t2[1], t2[2]} {
+		scale = math.Max(scale, math.Max(math.Abs(p[0]), math.Max(math.Abs(p[1]), math.Abs(p[2]))))
+	}
+	eps := 1e-7 * math.Max(scale, 1)
+
+	d1 := planeDistances(t1, n2, dot3(n2, t2[0]), eps)
+	if sameSide(d1) {
+		return false
+	}
+	d2 := planeDistances(t2, n1, dot3(n1, t1[0]), eps)
+	if sameSide(d2) {
+		return false
+	}
+	if d1 == [3]float64{} {
+		// 共面：相邻网格中的共面三角形只可能接触，不计为相交
+		return false
+	}
+
+	dir := cross3(n1, n2)
+	lo1, hi1 := intersectionInterval(t1, d1, dir)
+	lo2, hi2 := intersectionInterval(t2, d2, dir)
+	return math.Min(hi1, hi2)-math.Max(lo1, lo2) > eps
+}
+
+func planeDistances(t [3][3]float64, n [3]float64, d float64, eps float64) [3]float64 {
+	var out [3]float64
+	for k := 0; k < 3; k++ {
+		v := dot3(n, t[k]) - d
+		if math.Abs(v) < eps {
+			v = 0
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// sameSide 三个顶点都在平面同一侧（或有顶点仅接触平面），不可能穿插
+func sameSide(d [3]float64) bool {
+	pos, neg := 0, 0
+	for _, v := range d {
+		if v > 0 {
+			pos++
+		} else if v < 0 {
+			neg++
+		}
+	}
+	return (pos == 0 && neg > 0) || (neg == 0 && pos > 0)
+}
+
+// intersectionInterval 三角形与另一平面交线在 dir 方向上的投影区间
+func intersectionInterval(t [3][3]float64, d [3]float64, dir [3]float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for k := 0; k < 3; k++ {
+		if d[k] == 0 {
+			p := dot3(dir, t[k])
+			lo, hi = math.Min(lo, p), math.Max(hi, p)
+		}
+		j := (k + 1) % 3
+		if d[k]*d[j] < 0 {
+			s := d[k] / (d[k] - d[j])
+			p := dot3(dir, add3(t[k], scale3(sub3(t[j], t[k]), s)))
+			lo, hi = math.Min(lo, p), math.Max(hi, p)
+		}
+	}
+	return lo, hi
+}
@@ -0,0 +1,111 @@
+package stl
+
+import (
+	"testing"
+)
+
+func TestValidateReliefMeshes(t *testing.T) {
+	for _, maxError := range []float64{0, 0.05} {
+		mesh, err := BuildReliefMesh(bumpDepthMap(40, 30), ReliefOptions{
+			ModelWidth:     40,
+			ModelThickness: 4,
+			BaseThickness:  1,
+			DetailLevel:    1,
+			MaxError:       maxError,
+		})
+		if err != nil {
+			t.Fatalf("build mesh: %v", err)
+		}
+
+		report := Validate(mesh)
+		if !report.OK() {
+			t.Fatalf("maxError %v: unexpected problems: %s", maxError, report)
+		}
+		if report.Volume <= 0 {
+			t.Fatalf("maxError %v: expected positive volume, got %v", maxError, report.Volume)
+		}
+	}
+}
+
+// 底板厚度为 0 且深度图边缘为 0 时，边缘处的侧壁面积为 0，不能生成退化三角形
+func TestValidateZeroBaseThickness(t *testing.T) {
+	for _, maxError := range []float64{0, 0.05} {
+		mesh, err := BuildReliefMesh(bumpDepthMap(40, 30), ReliefOptions{
+			ModelWidth:     40,
+			ModelThickness: 4,
+			DetailLevel:    1,
+			MaxError:       maxError,
+		})
+		if err != nil {
+			t.Fatalf("build mesh: %v", err)
+		}
+
+		if report := Validate(mesh); !report.OK() {
+			t.Fatalf("maxError %v: unexpected problems: %s", maxError, report)
+		}
+		directedEdgeBalance(t, mesh)
+	}
+}
+
+func TestValidateDetectsProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(m *Mesh)
+		check  func(r *ValidationReport) bool
+	}{
+		{
+			name:   "open edges",
+			mutate: func(m *Mesh) { m.Triangles = m.Triangles[1:] },
+			check:  func(r *ValidationReport) bool { return r.OpenEdges == 3 },
+		},
+		{
+			name: "flipped triangle",
+			mutate: func(m *Mesh) {
+				t := m.Triangles[0]
+				m.Triangles[0] = [3]uint32{t[0], t[2], t[1]}
+			},
+			check: func(r *ValidationReport) bool { return r.FlippedEdges == 3 },
+		},
+		{
+			name:   "inside out",
+			mutate: func(m *Mesh) { m.FlipWinding() },
+			check:  func(r *ValidationReport) bool { return r.InsideOut && r.FlippedEdges == 0 },
+		},
+		{
+			name: "non-manifold and degenerate",
+			mutate: func(m *Mesh) {
+				m.Triangles = append(m.Triangles, [3]uint32{0, 1, 1})
+			},
+			check: func(r *ValidationReport) bool { return r.DegenerateTriangles == 1 && r.NonManifoldEdges == 1 },
+		},
+		{
+			name: "self intersection",
+			mutate: func(m *Mesh) {
+				// 第二个四面体穿过第一个
+				base := uint32(len(m.Vertices))
+				for _, v := range unitTriangleMesh().Vertices {
+					m.Vertices = append(m.Vertices, [3]float32{v[0] + 0.2, v[1] + 0.2, v[2] + 0.2})
+				}
+				for _, tri := range unitTriangleMesh().Triangles {
+					m.Triangles = append(m.Triangles, [3]uint32{tri[0] + base, tri[1] + base, tri[2] + base})
+				}
+			},
+			check: func(r *ValidationReport) bool { return r.SelfIntersections > 0 },
+		},
+	}
+
+	if report := Validate(unitTriangleMesh()); !report.OK() {
+		t.Fatalf("tetrahedron should be valid: %s", report)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mesh := unitTriangleMesh()
+			tt.mutate(mesh)
+			report := Validate(mesh)
+			if report.OK() || !tt.check(report) {
+				t.Fatalf("unexpected report: %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Op 路径段类型
+type Op uint8
+
+const (
+	MoveTo Op = iota
+	LineTo
+	QuadTo
+	CubeTo
+	Close
+)
+
+// Point 用户坐标中的点
+type Point struct {
+	X, Y float64
+}
+
+// Segment 路径段，Pts 中有效的点数：MoveTo/LineTo 1 个，QuadTo 2 个，CubeTo 3 个，Close 0 个
+type Segment struct {
+	Op  Op
+	Pts [3]Point
+}
+
+// Path 由若干子路径组成的路径
+type Path []Segment
+
+// kappa 四分之一圆弧的三次贝塞尔控制点系数
+const kappa = 0.5522847498307936
+
+// pathBuilder 记录当前点、子路径起点和上一个控制点（S/T 命令的反射）
+type pathBuilder struct {
+	path        Path
+	cur, start  Point
+	ctrl        Point
+	ctrlOp      Op // 上一段的类型，用于判断 ctrl 是否有效
+	hasSubpaths bool
+}
+
+func (b *pathBuilder) moveTo(p Point) {
+	b.path = append(b.path, Segment{Op: MoveTo, Pts: [3]Point{p}})
+	b.cur, b.start, b.ctrlOp = p, p, MoveTo
+	b.hasSubpaths = true
+}
+
+// ensureStarted 没有 MoveTo 时从当前点开始子路径
+func (b *pathBuilder) ensureStarted() {
+	if !b.hasSubpaths || b.path[len(b.path)-1].Op == Close {
+		b.moveTo(b.cur)
+	}
+}
+
+func (b *pathBuilder) lineTo(p Point) {
+	b.ensureStarted()
+	b.path = append(b.path, Segment{Op: LineTo, Pts: [3]Point{p}})
+	b.cur, b.ctrlOp = p, LineTo
+}
+
+func (b *pathBuilder) quadTo(c, p Point) {
+	b.ensureStarted()
+	b.path = append(b.path, Segment{Op: QuadTo, Pts: [3]Point{c, p}})
+	b.cur, b.ctrl, b.ctrlOp = p, c, QuadTo
+}
+
+func (b *pathBuilder) cubeTo(c1, c2, p Point) {
+	b.ensureStarted()
+	b.path = append(b.path, Segment{Op: CubeTo, Pts: [3]Point{c1, c2, p}})
+	b.cur, b.ctrl, b.ctrlOp = p, c2, CubeTo
+}
+
+func (b *pathBuilder) close() {
+	if !b.hasSubpaths || b.path[len(b.path)-1].Op == Close {
+		return
+	}
+	b.path = append(b.path, Segment{Op: Close})
+	b.cur, b.ctrlOp = b.start, Close
+}
+
+// reflect 上一段同类曲线控制点关于当前点的反射，没有时为当前点
+func (b *pathBuilder) reflect(op Op) Point {
+	if b.ctrlOp != op {
+		return b.cur
+	}
+	return Point{2*b.cur.X - b.ctrl.X, 2*b.cur.Y - b.ctrl.Y}
+}
+
+// arcTo 椭圆弧（SVG A 命令的端点参数化）转为不超过 90° 的三次贝塞尔段，算法见 SVG 规范附录 F.6
+func (b *pathBuilder) arcTo(rx, ry, rotation float64, large, sweep bool, p Point) {
+	p0 := b.cur
+	if p0 == p {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		b.lineTo(p)
+		return
+	}
+
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (p0.X-p.X)/2, (p0.Y-p.Y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	// 半径不够连接两个端点时等比放大
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		s := math.Sqrt(lambda)
+		rx, ry = rx*s, ry*s
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (p0.X+p.X)/2
+	cy := sin*cx1 + cos*cy1 + (p0.Y+p.Y)/2
+
+	theta := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	delta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta
+	switch {
+	case sweep && delta < 0:
+		delta += 2 * math.Pi
+	case !sweep && delta > 0:
+		delta -= 2 * math.Pi
+	}
+
+	// 单位圆上的点映射回用户坐标
+	at := func(u, v float64) Point {
+		return Point{cx + rx*u*cos - ry*v*sin, cy + rx*u*sin + ry*v*cos}
+	}
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(n)
+	t := 4.0 / 3 * math.Tan(step/4)
+	for i := 0; i < n; i++ {
+		a1 := theta + float64(i)*step
+		a2 := a1 + step
+		s1, c1 := math.Sincos(a1)
+		s2, c2 := math.Sincos(a2)
+		end := at(c2, s2)
+		if i == n-1 {
+			end = p // 消除累计误差
+		}
+		b.cubeTo(at(c1-t*s1, s1+t*c1), at(c2+t*s2, s2-t*c2), end)
+	}
+}
+
+// ellipse 以 (cx, cy) 为中心的椭圆，顺时针四段三次贝塞尔
+func (b *pathBuilder) ellipse(cx, cy, rx, ry float64) {
+	kx, ky := rx*kappa, ry*kappa
+	b.moveTo(Point{cx + rx, cy})
+	b.cubeTo(Point{cx + rx, cy + ky}, Point{cx + kx, cy + ry}, Point{cx, cy + ry})
+	b.cubeTo(Point{cx - kx, cy + ry}, Point{cx - rx, cy + ky}, Point{cx - rx, cy})
+	b.cubeTo(Point{cx - rx, cy - ky}, Point{cx - kx, cy - ry}, Point{cx, cy - ry})
+	b.cubeTo(Point{cx + kx, cy - ry}, Point{cx + rx, cy - ky}, Point{cx + rx, cy})
+	b.close()
+}
+
+// rect 矩形，rx、ry 大于 0 时为圆角矩形
+func (b *pathBuilder) rect(x, y, w, h, rx, ry float64) {
+	if rx <= 0 || ry <= 0 {
+		b.moveTo(Point{x, y})
+		b.lineTo(Point{x + w, y})
+		b.lineTo(Point{x + w, y + h})
+		b.lineTo(Point{x, y + h})
+		b.close()
+		return
+	}
+	rx, ry = min(rx, w/2), min(ry, h/2)
+	b.moveTo(Point{x + rx, y})
+	b.lineTo(Point{x + w - rx, y})
+	b.arcTo(rx, ry, 0, false, true, Point{x + w, y + ry})
+	b.lineTo(Point{x + w, y + h - ry})
+	b.arcTo(rx, ry, 0, false, true, Point{x + w - rx, y + h})
+	b.lineTo(Point{x + rx, y + h})
+	b.arcTo(rx, ry, 0, false, true, Point{x, y + h - ry})
+	b.lineTo(Point{x, y + ry})
+	b.arcTo(rx, ry, 0, false, true, Point{x + rx, y})
+	b.close()
+}
+
+// pathScanner 路径数据的词法分析：数字之间的逗号、空白都可省略（如 "M10-20.5.5"）
+type pathScanner struct {
+	s string
+	i int
+}
+
+func (p *pathScanner) skipSpace() {
+	for p.i < len(p.s) {
+		switch p.s[p.i] {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			p.i++
+		default:
+			return
+		}
+	}
+}
+
+// command 下一个字符为命令字母时返回它
+func (p *pathScanner) command() (byte, bool) {
+	p.skipSpace()
+	if p.i >= len(p.s) {
+		return 0, false
+	}
+	c := p.s[p.i]
+	if (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') && c != 'e' && c != 'E' {
+		p.i++
+		return c, true
+	}
+	return 0, false
+}
+
+// hasNumber 下一个记号是否为数字（命令参数的隐式重复）
+func (p *pathScanner) hasNumber() bool {
+	p.skipSpace()
+	if p.i >= len(p.s) {
+		return false
+	}
+	c := p.s[p.i]
+	return c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.'
+}
+
+func (p *pathScanner) number() (float64, error) {
+	p.skipSpace()
+	start := p.i
+	if p.i < len(p.s) && (p.s[p.i] == '-' || p.s[p.i] == '+') {
+		p.i++
+	}
+	digits := func() int {
+		n := 0
+		for p.i < len(p.s) && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+			p.i++
+			n++
+		}
+		return n
+	}
+	n := digits()
+	if p.i < len(p.s) && p.s[p.i] == '.' {
+		p.i++
+		n += digits()
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("svg: expected number at offset %d in path data", start)
+	}
+	if p.i < len(p.s) && (p.s[p.i] == 'e' || p.s[p.i] == 'E') {
+		mark := p.i
+		p.i++
+		if p.i < len(p.s) && (p.s[p.i] == '-' || p.s[p.i] == '+') {
+			p.i++
+		}
+		if digits() == 0 {
+			p.i = mark
+		}
+	}
+	return strconv.ParseFloat(p.s[start:p.i], 64)
+}
+
+// flag 弧线的 large-arc / sweep 标志，只占一个字符（"a1 1 0 11 5 5" 中的 "11" 是两个标志）
+func (p *pathScanner) flag() (bool, error) {
+	p.skipSpace()
+	if p.i < len(p.s) && (p.s[p.i] == '0' || p.s[p.i] == '1') {
+		p.i++
+		return p.s[p.i-1] == '1', nil
+	}
+	return false, fmt.Errorf("svg: expected arc flag at offset %d in path data", p.i)
+}
+
+// numbers 读取 n 个数字
+func (p *pathScanner) numbers(n int) ([]float64, error) {
+	v := make([]float64, n)
+	for i := range v {
+		var err error
+		if v[i], err = p.number(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// parsePathData 解析 path 元素的 d 属性，返回用户坐标（未应用 transform）的路径。
+// 出错时按规范保留出错位置之前的部分
+func parsePathData(d string) (Path, error) {
+	var b pathBuilder
+	p := &pathScanner{s: d}
+	for {
+		cmd, ok := p.command()
+		if !ok {
+			if p.i < len(p.s) {
+				return b.path, fmt.Errorf("svg: unexpected %q in path data", p.s[p.i])
+			}
+			return b.path, nil
+		}
+		if err := b.command(p, cmd); err != nil {
+			return b.path, err
+		}
+	}
+}
+
+// command 执行一个命令及其隐式重复的参数组
+func (b *pathBuilder) command(p *pathScanner, cmd byte) error {
+	rel := cmd >= 'a'
+	abs := func(x, y float64) Point {
+		if rel {
+			return Point{b.cur.X + x, b.cur.Y + y}
+		}
+		return Point{x, y}
+	}
+
+	if cmd == 'Z' || cmd == 'z' {
+		b.close()
+		return nil
+	}
+	if !b.hasSubpaths && cmd != 'M' && cmd != 'm' {
+		return fmt.Errorf("svg: path data must start with a moveto, got %q", cmd)
+	}
+	for first := true; first || p.hasNumber(); first = false {
+		switch cmd {
+		case 'M', 'm':
+			v, err := p.numbers(2)
+			if err != nil {
+				return err
+			}
+			if first {
+				b.moveTo(abs(v[0], v[1])) // 路径开头的 m 相对于原点，等同于 M
+			} else {
+				b.lineTo(abs(v[0], v[1])) // MoveTo 之后的坐标对是 LineTo
+			}
+		case 'L', 'l':
+			v, err := p.numbers(2)
+			if err != nil {
+				return err
+			}
+			b.lineTo(abs(v[0], v[1]))
+		case 'H', 'h':
+			v, err := p.number()
+			if err != nil {
+				return err
+			}
+			if rel {
+				v += b.cur.X
+			}
+			b.lineTo(Point{v, b.cur.Y})
+		case 'V', 'v':
+			v, err := p.number()
+			if err != nil {
+				return err
+			}
+			if rel {
+				v += b.cur.Y
+			}
+			b.lineTo(Point{b.cur.X, v})
+		case 'C', 'c':
+			v, err := p.numbers(6)
+			if err != nil {
+				return err
+			}
+			b.cubeTo(abs(v[0], v[1]), abs(v[2], v[3]), abs(v[4], v[5]))
+		case 'S', 's':
+			v, err := p.numbers(4)
+			if err != nil {
+				return err
+			}
+			b.cubeTo(b.reflect(CubeTo), abs(v[0], v[1]), abs(v[2], v[3]))
+		case 'Q', 'q':
+			v, err := p.numbers(4)
+			if err != nil {
+				return err
+			}
+			b.quadTo(abs(v[0], v[1]), abs(v[2], v[3]))
+		case 'T', 't':
+			v, err := p.numbers(2)
+			if err != nil {
+				return err
+			}
+			b.quadTo(b.reflect(QuadTo), abs(v[0], v[1]))
+		case 'A', 'a':
+			v, err := p.numbers(3)
+			if err != nil {
+				return err
+			}
+			large, err := p.flag()
+			if err != nil {
+				return err
+			}
+			sweep, err := p.flag()
+			if err != nil {
+				return err
+			}
+			end, err := p.numbers(2)
+			if err != nil {
+				return err
+			}
+			b.arcTo(v[0], v[1], v[2], large, sweep, abs(end[0], end[1]))
+		default:
+			return fmt.Errorf("svg: unknown path command %q", cmd)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package svg
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// Rasterize 把文档等比缩放到 w×h 像素（居中），按绘制顺序对每个形状调用 paint。
+// mask 为该形状的抗锯齿覆盖率，只有 r 内的像素有效，r 外的像素不属于该形状
+func (d *Document) Rasterize(w, h int, paint func(s *Shape, mask *image.Alpha, r image.Rectangle)) {
+	vb := d.ViewBox
+	scale := min(float64(w)/vb.Width, float64(h)/vb.Height)
+	toPixel := matrix{scale, 0, 0, scale, (float64(w)-vb.Width*scale)/2 - vb.X*scale, (float64(h)-vb.Height*scale)/2 - vb.Y*scale}
+
+	z := &vector.Rasterizer{}
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	var sub *image.Alpha
+	for i := range d.Shapes {
+		s := &d.Shapes[i]
+		path := toPixel.transform(s.Path)
+		r := pixelBounds(path).Intersect(mask.Rect)
+		if r.Empty() {
+			continue
+		}
+
+		// 光栅器的原点对应 r.Min，只在形状的包围盒内累加
+		local := matrix{1, 0, 0, 1, -float64(r.Min.X), -float64(r.Min.Y)}.transform(path)
+		subpaths := splitSubpaths(local)
+		if !s.EvenOdd || len(subpaths) == 1 {
+			// 非零环绕：vector 按有向面积累加，反向的内轮廓抵消为洞
+			z.Reset(r.Dx(), r.Dy())
+			z.DrawOp = draw.Src
+			addPath(z, local)
+			z.Draw(mask, r, image.Opaque, image.Point{})
+		} else {
+			// 奇偶规则：每个子路径单独光栅化后按覆盖率异或
+			if sub == nil {
+				sub = image.NewAlpha(mask.Rect)
+			}
+			clearRect(mask, r)
+			for _, sp := range subpaths {
+				z.Reset(r.Dx(), r.Dy())
+				z.DrawOp = draw.Src
+				addPath(z, sp)
+				z.Draw(sub, r, image.Opaque, image.Point{})
+				for y := r.Min.Y; y < r.Max.Y; y++ {
+					for x := r.Min.X; x < r.Max.X; x++ {
+						i := mask.PixOffset(x, y)
+						a, b := int(mask.Pix[i]), int(sub.Pix[i])
+						mask.Pix[i] = uint8(a + b - (2*a*b+127)/255)
+					}
+				}
+			}
+		}
+		paint(s, mask, r)
+	}
+}
+
+// addPath 把路径送入光栅器，未闭合的子路径按填充规则隐式闭合
+func addPath(z *vector.Rasterizer, path Path) {
+	open := false
+	for _, s := range path {
+		p := s.Pts
+		switch s.Op {
+		case MoveTo:
+			if open {
+				z.ClosePath()
+			}
+			z.MoveTo(float32(p[0].X), float32(p[0].Y))
+			open = true
+		case LineTo:
+			z.LineTo(float32(p[0].X), float32(p[0].Y))
+		case QuadTo:
+			z.QuadTo(float32(p[0].X), float32(p[0].Y), float32(p[1].X), float32(p[1].Y))
+		case CubeTo:
+			z.CubeTo(float32(p[0].X), float32(p[0].Y), float32(p[1].X), float32(p[1].Y), float32(p[2].X), float32(p[2].Y))
+		case Close:
+			z.ClosePath()
+			open = false
+		}
+	}
+	if open {
+		z.ClosePath()
+	}
+}
+
+// splitSubpaths 按 MoveTo 拆分子路径
+func splitSubpaths(path Path) []Path {
+	var out []Path
+	for i, s := range path {
+		if s.Op == MoveTo || i == 0 {
+			out = append(out, nil)
+		}
+		out[len(out)-1] = append(out[len(out)-1], s)
+	}
+	return out
+}
+
+// pixelBounds 控制点的包围盒（贝塞尔曲线在控制点的凸包内），向外取整
+func pixelBounds(path Path) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, s := range path {
+		for _, p := range s.Pts[:s.Op.points()] {
+			minX, minY = min(minX, p.X), min(minY, p.Y)
+			maxX, maxY = max(maxX, p.X), max(maxY, p.Y)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return image.Rectangle{}
+	}
+	// 先裁到合理范围，避免超大坐标转 int 溢出
+	clamp := func(v float64) int {
+		return int(min(max(v, -1<<30), 1<<30))
+	}
+	return image.Rect(clamp(math.Floor(minX)), clamp(math.Floor(minY)), clamp(math.Ceil(maxX))+1, clamp(math.Ceil(maxY))+1)
+}
+
+func clearRect(img *image.Alpha, r image.Rectangle) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)]
+		clear(row)
+	}
+}
@@ -0,0 +1,588 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 最小的 SVG 读取器：只取填充区域，用于把 logo、图标做成浮雕。
+// 支持 path、rect、circle、ellipse、polygon、polyline，g 的 transform 和填充继承，
+// fill / fill-rule 可来自属性、style 属性或 <style> 中的简单选择器（.class、元素名）。
+// 描边、渐变（按黑色填充）、文字（需先转为路径）、clipPath、mask、use 不支持。
+
+// maxFileSize SVG 文件大小上限
+const maxFileSize = 32 << 20
+
+// Box 用户坐标中的矩形
+type Box struct {
+	X, Y, Width, Height float64
+}
+
+// Shape 一个填充区域
+type Shape struct {
+	Fill    color.NRGBA // 填充色，不含透明度
+	EvenOdd bool        // fill-rule 为 evenodd
+	Path    Path        // 已应用 transform 的用户坐标
+}
+
+// Document 解析后的 SVG
+type Document struct {
+	ViewBox Box     // 可见区域，光栅化时整体缩放到输出尺寸
+	Shapes  []Shape // 按绘制顺序，后面的覆盖前面的
+}
+
+// Aspect 高宽比
+func (d *Document) Aspect() float64 {
+	return d.ViewBox.Height / d.ViewBox.Width
+}
+
+// matrix 仿射变换 [a b c d e f]：x' = a·x + c·y + e，y' = b·x + d·y + f
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul 先做 n 再做 m
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m matrix) apply(p Point) Point {
+	return Point{m[0]*p.X + m[2]*p.Y + m[4], m[1]*p.X + m[3]*p.Y + m[5]}
+}
+
+// transform 对路径中所有点做仿射变换（贝塞尔曲线在仿射变换下保持不变）
+func (m matrix) transform(path Path) Path {
+	out := make(Path, len(path))
+	for i, s := range path {
+		for k := range s.Pts[:s.Op.points()] {
+			s.Pts[k] = m.apply(s.Pts[k])
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// parseTransform 解析 transform 属性，如 "translate(10,20) rotate(45 5 5) scale(2)"
+func parseTransform(s string) (matrix, error) {
+	m := identity
+	s = strings.TrimSpace(s)
+	for s != "" {
+		open := strings.IndexByte(s, '(')
+		end := strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return m, fmt.Errorf("svg: invalid transform %q", s)
+		}
+		name := strings.TrimSpace(s[:open])
+		args, err := parseNumberList(s[open+1 : end])
+		if err != nil {
+			return m, err
+		}
+		s = strings.TrimLeft(s[end+1:], " \t\r\n,")
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		var t matrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return m, fmt.Errorf("svg: matrix needs 6 values, got %d", len(args))
+			}
+			copy(t[:], args)
+		case "translate":
+			t = matrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = matrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			sin, cos := math.Sincos(arg(0, 0) * math.Pi / 180)
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = matrix{1, 0, 0, 1, cx, cy}.mul(matrix{cos, sin, -sin, cos, 0, 0}).mul(matrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = matrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = matrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("svg: unknown transform %q", name)
+		}
+		m = m.mul(t)
+	}
+	return m, nil
+}
+
+// parseNumberList 解析逗号或空白分隔的数字
+func parseNumberList(s string) ([]float64, error) {
+	p := &pathScanner{s: s}
+	var v []float64
+	for p.hasNumber() {
+		n, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, n)
+	}
+	if p.skipSpace(); p.i < len(p.s) {
+		return nil, fmt.Errorf("svg: invalid number list %q", s)
+	}
+	return v, nil
+}
+
+// parseLength 长度属性，px 等单位按用户单位处理，百分比相对 ref
+func parseLength(s string, ref float64) float64 {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "%") {
+		v, _ := strconv.ParseFloat(strings.TrimSpace(s[:len(s)-1]), 64)
+		return v / 100 * ref
+	}
+	s = strings.TrimRight(s, "abcdefghijklmnopqrstuvwxyz")
+	v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v
+}
+
+// namedColors 常用颜色名
+var namedColors = map[string]color.NRGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"red":     {255, 0, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"lime":    {0, 255, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"cyan":    {0, 255, 255, 255},
+	"aqua":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"fuchsia": {255, 0, 255, 255},
+	"gray":    {128, 128, 128, 255},
+	"grey":    {128, 128, 128, 255},
+	"silver":  {192, 192, 192, 255},
+	"maroon":  {128, 0, 0, 255},
+	"olive":   {128, 128, 0, 255},
+	"navy":    {0, 0, 128, 255},
+	"purple":  {128, 0, 128, 255},
+	"teal":    {0, 128, 128, 255},
+	"orange":  {255, 165, 0, 255},
+	"gold":    {255, 215, 0, 255},
+	"pink":    {255, 192, 203, 255},
+	"brown":   {165, 42, 42, 255},
+}
+
+// parseColor 解析填充色，ok 为 false 表示不填充（none、transparent）
+func parseColor(s string, current color.NRGBA) (c color.NRGBA, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	black := color.NRGBA{A: 255}
+	switch {
+	case s == "none" || s == "transparent":
+		return c, false
+	case s == "currentcolor":
+		return current, true
+	case strings.HasPrefix(s, "url("):
+		// 渐变、图案：有后备颜色时用后备颜色，否则按黑色
+		if end := strings.IndexByte(s, ')'); end >= 0 && strings.TrimSpace(s[end+1:]) != "" {
+			return parseColor(s[end+1:], current)
+		}
+		return black, true
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		switch len(hex) {
+		case 3, 4:
+			v, err := strconv.ParseUint(hex[:3], 16, 16)
+			if err != nil {
+				return black, true
+			}
+			return color.NRGBA{uint8(v>>8) * 17, uint8(v>>4&0xf) * 17, uint8(v&0xf) * 17, 255}, true
+		case 6, 8:
+			v, err := strconv.ParseUint(hex[:6], 16, 32)
+			if err != nil {
+				return black, true
+			}
+			return color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, true
+		}
+		return black, true
+	case strings.HasPrefix(s, "rgb"):
+		open, end := strings.IndexByte(s, '('), strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return black, true
+		}
+		parts := strings.FieldsFunc(s[open+1:end], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 {
+			return black, true
+		}
+		var rgb [3]uint8
+		for i := range rgb {
+			v := parseLength(parts[i], 255)
+			rgb[i] = uint8(min(max(v, 0), 255) + 0.5)
+		}
+		return color.NRGBA{rgb[0], rgb[1], rgb[2], 255}, true
+	}
+	if named, found := namedColors[s]; found {
+		return named, true
+	}
+	return black, true
+}
+
+// style 可继承的填充属性
+type style struct {
+	m       matrix
+	fill    string
+	rule    string
+	color   string
+	opacity string
+	hidden  bool
+}
+
+// skipped 整个子树都不绘制的元素
+var skipped = map[string]bool{
+	"defs":           true,
+	"clipPath":       true,
+	"mask":           true,
+	"symbol":         true,
+	"pattern":        true,
+	"marker":         true,
+	"linearGradient": true,
+	"radialGradient": true,
+	"filter":         true,
+	"style":          true,
+	"script":         true,
+	"title":          true,
+	"desc":           true,
+	"metadata":       true,
+	"text":           true,
+	"foreignObject":  true,
+}
+
+// Parse 读取 SVG
+func Parse(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFileSize {
+		return nil, fmt.Errorf("svg: file larger than %d bytes", maxFileSize)
+	}
+
+	sheet, err := parseStyleSheets(data)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	stack := []style{{m: identity, fill: "black", rule: "nonzero", color: "black", opacity: "1"}}
+	skip, root := 0, true
+	dec := newDecoder(data)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svg: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || skipped[t.Name.Local] {
+				skip++
+				continue
+			}
+			attrs := attributes(t, sheet)
+			st, err := stack[len(stack)-1].inherit(attrs)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, st)
+
+			if t.Name.Local == "svg" && root {
+				doc.ViewBox, err = viewBox(attrs)
+				if err != nil {
+					return nil, err
+				}
+				root = false
+				continue
+			}
+			if root {
+				return nil, errors.New("svg: root element is not <svg>")
+			}
+			if err = doc.addShape(t.Name.Local, attrs, st); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	if root {
+		return nil, errors.New("svg: no <svg> element")
+	}
+	if doc.ViewBox.Width <= 0 || doc.ViewBox.Height <= 0 {
+		doc.ViewBox = doc.bounds()
+		if doc.ViewBox.Width <= 0 || doc.ViewBox.Height <= 0 {
+			return nil, errors.New("svg: document has no size, set viewBox or width/height")
+		}
+	}
+	return doc, nil
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	return dec
+}
+
+// attributes 合并元素属性：表现属性 < <style> 选择器 < style 属性
+func attributes(t xml.StartElement, sheet map[string]map[string]string) map[string]string {
+	attrs := make(map[string]string, len(t.Attr))
+	for _, a := range t.Attr {
+		if a.Name.Space == "" || a.Name.Space == "svg" {
+			attrs[a.Name.Local] = a.Value
+		}
+	}
+	apply := func(decls map[string]string) {
+		for k, v := range decls {
+			attrs[k] = v
+		}
+	}
+	apply(sheet[t.Name.Local])
+	for _, class := range strings.Fields(attrs["class"]) {
+		apply(sheet["."+class])
+	}
+	apply(parseDeclarations(attrs["style"]))
+	return attrs
+}
+
+// parseDeclarations 解析 "fill:#f00; fill-rule:evenodd"
+func parseDeclarations(s string) map[string]string {
+	decls := map[string]string{}
+	for _, decl := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important"))
+		decls[strings.TrimSpace(k)] = v
+	}
+	return decls
+}
+
+// parseStyleSheets 收集 <style> 中 .class 和元素名选择器的声明，其他选择器忽略
+func parseStyleSheets(data []byte) (map[string]map[string]string, error) {
+	sheet := map[string]map[string]string{}
+	dec := newDecoder(data)
+	inStyle := false
+	var css strings.Builder
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svg: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			inStyle = t.Name.Local == "style"
+		case xml.EndElement:
+			inStyle = false
+		case xml.CharData:
+			if inStyle {
+				css.Write(t)
+			}
+		}
+	}
+
+	rules := css.String()
+	for {
+		open := strings.IndexByte(rules, '{')
+		end := strings.IndexByte(rules, '}')
+		if open < 0 || end < open {
+			break
+		}
+		decls := parseDeclarations(rules[open+1 : end])
+		for _, sel := range strings.Split(rules[:open], ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " >+~:[*#") || strings.Count(sel, ".") > 1 || strings.Index(sel, ".") > 0 {
+				continue
+			}
+			if sheet[sel] == nil {
+				sheet[sel] = map[string]string{}
+			}
+			for k, v := range decls {
+				sheet[sel][k] = v
+			}
+		}
+		rules = rules[end+1:]
+	}
+	return sheet, nil
+}
+
+// inherit 子元素的样式：transform 叠加，填充属性未指定或为 inherit 时继承
+func (s style) inherit(attrs map[string]string) (style, error) {
+	child := s
+	if t, ok := attrs["transform"]; ok {
+		m, err := parseTransform(t)
+		if err != nil {
+			return child, err
+		}
+		child.m = s.m.mul(m)
+	}
+	set := func(dst *string, key string) {
+		if v, ok := attrs[key]; ok && v != "" && v != "inherit" {
+			*dst = v
+		}
+	}
+	set(&child.fill, "fill")
+	set(&child.rule, "fill-rule")
+	set(&child.color, "color")
+	set(&child.opacity, "fill-opacity")
+	if attrs["display"] == "none" || attrs["visibility"] == "hidden" {
+		child.hidden = true
+	}
+	// opacity 不继承，但为 0 时整个子树都不可见
+	if v, ok := attrs["opacity"]; ok && parseLength(v, 1) <= 0 {
+		child.hidden = true
+	}
+	return child, nil
+}
+
+// viewBox 根元素的可见区域，没有 viewBox 时用 width、height
+func viewBox(attrs map[string]string) (Box, error) {
+	if v, ok := attrs["viewBox"]; ok {
+		n, err := parseNumberList(v)
+		if err != nil || len(n) != 4 {
+			return Box{}, fmt.Errorf("svg: invalid viewBox %q", v)
+		}
+		return Box{n[0], n[1], n[2], n[3]}, nil
+	}
+	return Box{Width: parseLength(attrs["width"], 0), Height: parseLength(attrs["height"], 0)}, nil
+}
+
+// addShape 把基本图形转为路径，应用变换后加入文档
+func (d *Document) addShape(name string, attrs map[string]string, st style) error {
+	if st.hidden || parseLength(st.opacity, 1) <= 0 {
+		return nil
+	}
+	current, _ := parseColor(st.color, color.NRGBA{A: 255})
+	fill, ok := parseColor(st.fill, current)
+	if !ok {
+		return nil
+	}
+
+	num := func(key string, ref float64) float64 {
+		return parseLength(attrs[key], ref)
+	}
+	vw, vh := d.ViewBox.Width, d.ViewBox.Height
+	var b pathBuilder
+	switch name {
+	case "path":
+		path, err := parsePathData(attrs["d"])
+		if err != nil && len(path) == 0 {
+			return err
+		}
+		b.path = path // 出错时保留已解析的部分
+	case "rect":
+		w, h := num("width", vw), num("height", vh)
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		rx, hasRX := attrs["rx"]
+		ry, hasRY := attrs["ry"]
+		if !hasRX {
+			rx = ry
+		}
+		if !hasRY {
+			ry = rx
+		}
+		b.rect(num("x", vw), num("y", vh), w, h, parseLength(rx, vw), parseLength(ry, vh))
+	case "circle":
+		r := num("r", math.Hypot(vw, vh)/math.Sqrt2)
+		if r <= 0 {
+			return nil
+		}
+		b.ellipse(num("cx", vw), num("cy", vh), r, r)
+	case "ellipse":
+		rx, ry := num("rx", vw), num("ry", vh)
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		b.ellipse(num("cx", vw), num("cy", vh), rx, ry)
+	case "polygon", "polyline":
+		pts, err := parseNumberList(attrs["points"])
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(pts); i += 2 {
+			if i == 0 {
+				b.moveTo(Point{pts[0], pts[1]})
+			} else {
+				b.lineTo(Point{pts[i], pts[i+1]})
+			}
+		}
+		b.close() // 填充时折线也按闭合处理
+	default:
+		return nil
+	}
+	if len(b.path) == 0 {
+		return nil
+	}
+
+	d.Shapes = append(d.Shapes, Shape{
+		Fill:    fill,
+		EvenOdd: st.rule == "evenodd",
+		Path:    st.m.transform(b.path),
+	})
+	return nil
+}
+
+// bounds 所有路径控制点的包围盒（没有 viewBox 和 width/height 时使用）
+func (d *Document) bounds() Box {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, s := range d.Shapes {
+		for _, seg := range s.Path {
+			for _, p := range seg.Pts[:seg.Op.points()] {
+				minX, minY = min(minX, p.X), min(minY, p.Y)
+				maxX, maxY = max(maxX, p.X), max(maxY, p.Y)
+			}
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return Box{}
+	}
+	return Box{minX, minY, maxX - minX, maxY - minY}
+}
+
+// points 路径段中有效的点数
+func (op Op) points() int {
+	switch op {
+	case MoveTo, LineTo:
+		return 1
+	case QuadTo:
+		return 2
+	case CubeTo:
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,199 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func near(a, b Point) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+func TestParsePathData(t *testing.T) {
+	// 紧凑写法：省略分隔符、隐式重复、MoveTo 后的坐标对为 LineTo
+	path, err := parsePathData("m10-20l.5.5 5 0 0 5h-5zM0,0 1e1,0V10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		op Op
+		p  Point
+	}{
+		{MoveTo, Point{10, -20}}, {LineTo, Point{10.5, -19.5}}, {LineTo, Point{15.5, -19.5}},
+		{LineTo, Point{15.5, -14.5}}, {LineTo, Point{10.5, -14.5}}, {Close, Point{}},
+		{MoveTo, Point{0, 0}}, {LineTo, Point{10, 0}}, {LineTo, Point{10, 10}},
+	}
+	if len(path) != len(want) {
+		t.Fatalf("got %d segments, want %d: %v", len(path), len(want), path)
+	}
+	for i, w := range want {
+		if path[i].Op != w.op || (w.op != Close && !near(path[i].Pts[0], w.p)) {
+			t.Fatalf("segment %d: got %v, want %v", i, path[i], w)
+		}
+	}
+
+	// 半圆弧：两段三次贝塞尔，终点精确，中点在圆上；"01" 是两个标志
+	path, err = parsePathData("M0 0a5 5 0 01 10 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 3 || path[2].Op != CubeTo || path[2].Pts[2] != (Point{10, 0}) {
+		t.Fatalf("unexpected arc %v", path)
+	}
+	if mid := path[1].Pts[2]; !near(mid, Point{5, -5}) {
+		t.Fatalf("arc midpoint %v, want (5,-5)", mid)
+	}
+
+	// 反射控制点
+	path, err = parsePathData("M0 0C0 1 1 1 1 0S2 -1 2 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path[2].Pts[0] != (Point{1, -1}) {
+		t.Fatalf("reflected control point %v, want (1,-1)", path[2].Pts[0])
+	}
+
+	for _, bad := range []string{"L10 10", "M10", "M0 0 A1 1 0 2 0 1 1", "M0 0 X"} {
+		if _, err = parsePathData(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	m, err := parseTransform("translate(10,20) rotate(90) scale(2 3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (1,1) → scale (2,3) → rotate (-3,2) → translate (7,22)
+	if p := m.apply(Point{1, 1}); !near(p, Point{7, 22}) {
+		t.Fatalf("got %v, want (7,22)", p)
+	}
+
+	m, err = parseTransform("rotate(180, 5 5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := m.apply(Point{0, 0}); !near(p, Point{10, 10}) {
+		t.Fatalf("got %v, want (10,10)", p)
+	}
+
+	for _, bad := range []string{"translate(1", "spin(3)", "matrix(1 0 0 1)"} {
+		if _, err = parseTransform(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+const testSVG = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="200mm" height="100mm" viewBox="0 0 20 10">
+  <defs>
+    <style>.cls-1{fill:#d52b1e}.cls-2, rect.other { fill: none }</style>
+    <linearGradient id="g"><stop offset="0" stop-color="#fff"/></linearGradient>
+  </defs>
+  <title>logo</title>
+  <rect class="cls-1" width="20" height="10"/>
+  <g fill="white" transform="translate(10 5)">
+    <circle r="3" style="fill-rule:evenodd"/>
+    <path d="M-1-1h2v2h-2z" fill="inherit"/>
+    <rect class="cls-2" width="1" height="1"/>
+    <ellipse rx="1" ry="2" display="none"/>
+    <polygon points="0,0 1,0 1,1" fill="url(#g) rgb(0, 50%, 100%)"/>
+  </g>
+  <text x="0" y="0">ignored</text>
+</svg>`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(testSVG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ViewBox != (Box{0, 0, 20, 10}) || doc.Aspect() != 0.5 {
+		t.Fatalf("unexpected viewBox %v", doc.ViewBox)
+	}
+
+	fills := []color.NRGBA{{0xd5, 0x2b, 0x1e, 255}, {255, 255, 255, 255}, {255, 255, 255, 255}, {0, 128, 255, 255}}
+	if len(doc.Shapes) != len(fills) {
+		t.Fatalf("got %d shapes, want %d", len(doc.Shapes), len(fills))
+	}
+	for i, want := range fills {
+		if doc.Shapes[i].Fill != want {
+			t.Fatalf("shape %d: fill %v, want %v", i, doc.Shapes[i].Fill, want)
+		}
+	}
+	if !doc.Shapes[1].EvenOdd || doc.Shapes[2].EvenOdd {
+		t.Fatal("unexpected fill rules")
+	}
+	// g 的 translate 作用到子元素
+	if p := doc.Shapes[2].Path[0].Pts[0]; p != (Point{9, 4}) {
+		t.Fatalf("transformed start point %v, want (9,4)", p)
+	}
+
+	// 没有 viewBox 时用 width/height
+	doc, err = Parse(strings.NewReader(`<svg width="30px" height="15"><circle cx="5" cy="5" r="5"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ViewBox != (Box{0, 0, 30, 15}) {
+		t.Fatalf("unexpected viewBox %v", doc.ViewBox)
+	}
+
+	for _, bad := range []string{
+		`<html></html>`,
+		`<svg viewBox="0 0 10"></svg>`,
+		`<svg><path d="M0 0"/></svg>`,
+		`<svg viewBox="0 0 10 10"><g transform="wobble(1)"/></svg>`,
+	} {
+		if _, err = Parse(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestRasterize(t *testing.T) {
+	// 外框 0~10，内框 3~7 同方向绘制：nonzero 填满，evenodd 留洞
+	const ring = `M0 0H10V10H0Z M3 3H7V7H3Z`
+	for _, tc := range []struct {
+		rule string
+		hole bool
+	}{{"nonzero", false}, {"evenodd", true}} {
+		doc, err := Parse(strings.NewReader(`<svg viewBox="0 0 10 10"><path fill-rule="` + tc.rule + `" d="` + ring + `"/></svg>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		coverage := image.NewAlpha(image.Rect(0, 0, 20, 20))
+		doc.Rasterize(20, 20, func(s *Shape, mask *image.Alpha, r image.Rectangle) {
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					coverage.SetAlpha(x, y, mask.AlphaAt(x, y))
+				}
+			}
+		})
+		if got := coverage.AlphaAt(1, 1).A; got != 255 {
+			t.Fatalf("%s: outer ring coverage %d", tc.rule, got)
+		}
+		if center := coverage.AlphaAt(10, 10).A; (center == 0) != tc.hole {
+			t.Fatalf("%s: center coverage %d", tc.rule, center)
+		}
+	}
+
+	// 非正方形输出时等比缩放并居中，半像素边缘为抗锯齿的覆盖率
+	doc, err := Parse(strings.NewReader(`<svg viewBox="0 0 10 10"><rect x="0.25" width="5" height="10"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []uint8
+	doc.Rasterize(40, 20, func(s *Shape, mask *image.Alpha, r image.Rectangle) {
+		for x := 9; x <= 21; x++ {
+			got = append(got, mask.AlphaAt(x, 10).A)
+		}
+	})
+	// 图在 x = 10~30，矩形覆盖 10.5~20.5
+	if got[0] != 0 || got[1] < 120 || got[1] > 135 || got[2] != 255 || got[10] != 255 || got[11] < 120 || got[11] > 135 || got[12] != 0 {
+		t.Fatalf("unexpected coverage %v", got)
+	}
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// exifOrientationTag EXIF 中 Orientation 的标签号
+const exifOrientationTag = 0x0112
+
+// Orientation 读取图片文件中 EXIF 的方向值（1~8），没有 EXIF 或无法解析时返回 1
+func Orientation(data []byte) int {
+	var exif []byte
+	switch ImageFormat(data) {
+	case FormatJPEG:
+		exif = jpegExif(data)
+	case FormatTIFF:
+		exif = data
+	case FormatWebP:
+		exif = riffChunk(data, "EXIF")
+	case FormatPNG:
+		exif = pngChunk(data, "eXIf")
+	}
+	return tiffOrientation(bytes.TrimPrefix(exif, []byte("Exif\x00\x00")))
+}
+
+// jpegExif 查找 APP1 段中的 EXIF 数据，到图像数据（SOS）为止
+func jpegExif(data []byte) []byte {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return nil
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff: // 填充字节
+			i++
+			continue
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd7: // 没有长度的标记
+			i += 2
+			continue
+		case marker == 0xda:
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return nil
+		}
+		payload := data[i+4 : i+2+size]
+		if marker == 0xe1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return payload
+		}
+		i += 2 + size
+	}
+	return nil
+}
+
+// riffChunk 查找 RIFF（WebP）文件中的指定块
+func riffChunk(data []byte, fourCC string) []byte {
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if size < 0 || i+8+size > len(data) {
+			return nil
+		}
+		if string(data[i:i+4]) == fourCC {
+			return data[i+8 : i+8+size]
+		}
+		i += 8 + size + size&1 // 块按偶数字节对齐
+	}
+	return nil
+}
+
+// pngChunk 查找 PNG 文件中的指定块
+func pngChunk(data []byte, kind string) []byte {
+	for i := 8; i+12 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[i:]))
+		if size < 0 || i+12+size > len(data) {
+			return nil
+		}
+		if string(data[i+4:i+8]) == kind {
+			return data[i+8 : i+8+size]
+		}
+		i += 12 + size
+	}
+	return nil
+}
+
+// tiffOrientation 在 TIFF 结构（EXIF 数据或 TIFF 文件本身）的第一个 IFD 中查找方向标签
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < count; k++ {
+		entry := ifd + 2 + 12*k
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		// 类型 3 为 SHORT，值直接存放在条目的前两个字节
+		if order.Uint16(tiff[entry:]) == exifOrientationTag && order.Uint16(tiff[entry+2:]) == 3 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
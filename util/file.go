@@ -1,9 +1,7 @@
 package util
 
 import (
-	"bytes"
 	"image"
-	"io"
 	"net/http"
 	"os"
 )
@@ -18,12 +16,7 @@ func DownloadImage(url string) (image.Image, error) {
 		_ = resp.Body.Close()
 	}()
 
-	imgData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	img, _, err := image.Decode(bytes.NewReader(imgData))
+	img, _, err := DecodeImage(resp.Body)
 	return img, err
 }
 
@@ -37,6 +30,6 @@ func OpenImage(path string) (image.Image, error) {
 		_ = file.Close()
 	}()
 
-	img, _, err := image.Decode(file)
+	img, _, err := DecodeImage(file)
 	return img, err
 }
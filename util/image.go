@@ -0,0 +1,129 @@
+package util
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// 图片格式，与 image.Decode 返回的 format 一致
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatBMP  = "bmp"
+	FormatTIFF = "tiff"
+	FormatWebP = "webp"
+)
+
+// ImageFormat 按文件头的魔数判断图片格式，不支持的格式返回空字符串。header 至少需要 12 字节才能识别 BMP、WebP
+func ImageFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("\xff\xd8\xff")):
+		return FormatJPEG
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return FormatPNG
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return FormatGIF
+	case len(header) >= 10 && bytes.HasPrefix(header, []byte("BM")) && string(header[6:10]) == "\x00\x00\x00\x00":
+		return FormatBMP
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return FormatTIFF
+	case len(header) >= 12 && bytes.HasPrefix(header, []byte("RIFF")) && string(header[8:12]) == "WEBP":
+		return FormatWebP
+	}
+	return ""
+}
+
+// DecodeImage 解码图片，并按 EXIF 方向（JPEG、TIFF、WebP、PNG 中的 Orientation）摆正
+func DecodeImage(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, err
+	}
+	return ApplyOrientation(img, Orientation(data)), format, nil
+}
+
+// ApplyOrientation 按 EXIF 方向值（1~8）旋转、翻转图片，1 和无效值原样返回。
+// 灰度、RGBA、NRGBA 及其 16 位图保持原类型，其他类型（如 JPEG 的 YCbCr）转为 RGBA
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w // 5~8 宽高互换
+	}
+	rect := image.Rect(0, 0, dw, dh)
+
+	var (
+		src, dst             []byte
+		srcStride, dstStride int
+		bpp                  int
+		out                  image.Image
+	)
+	switch m := img.(type) {
+	case *image.Gray:
+		d := image.NewGray(rect)
+		src, srcStride, dst, dstStride, bpp, out = m.Pix[m.PixOffset(b.Min.X, b.Min.Y):], m.Stride, d.Pix, d.Stride, 1, d
+	case *image.Gray16:
+		d := image.NewGray16(rect)
+		src, srcStride, dst, dstStride, bpp, out = m.Pix[m.PixOffset(b.Min.X, b.Min.Y):], m.Stride, d.Pix, d.Stride, 2, d
+	case *image.NRGBA:
+		d := image.NewNRGBA(rect)
+		src, srcStride, dst, dstStride, bpp, out = m.Pix[m.PixOffset(b.Min.X, b.Min.Y):], m.Stride, d.Pix, d.Stride, 4, d
+	case *image.RGBA64:
+		d := image.NewRGBA64(rect)
+		src, srcStride, dst, dstStride, bpp, out = m.Pix[m.PixOffset(b.Min.X, b.Min.Y):], m.Stride, d.Pix, d.Stride, 8, d
+	case *image.NRGBA64:
+		d := image.NewNRGBA64(rect)
+		src, srcStride, dst, dstStride, bpp, out = m.Pix[m.PixOffset(b.Min.X, b.Min.Y):], m.Stride, d.Pix, d.Stride, 8, d
+	default:
+		rgba, ok := img.(*image.RGBA)
+		if !ok {
+			rgba = image.NewRGBA(image.Rect(0, 0, w, h))
+			draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+		}
+		d := image.NewRGBA(rect)
+		src, srcStride, dst, dstStride, bpp, out = rgba.Pix[rgba.PixOffset(rgba.Rect.Min.X, rgba.Rect.Min.Y):], rgba.Stride, d.Pix, d.Stride, 4, d
+	}
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-x, y
+			case 3: // 旋转 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, h-1-y
+			case 5: // 沿主对角线翻转
+				sx, sy = y, x
+			case 6: // 顺时针旋转 90°
+				sx, sy = y, h-1-x
+			case 7: // 沿副对角线翻转
+				sx, sy = w-1-y, h-1-x
+			case 8: // 逆时针旋转 90°
+				sx, sy = w-1-y, x
+			}
+			copy(dst[y*dstStride+x*bpp:y*dstStride+(x+1)*bpp], src[sy*srcStride+sx*bpp:])
+		}
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// orientationImage 3×2 的图，每个像素颜色不同
+func orientationImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 100), G: uint8(y * 100), A: 255})
+		}
+	}
+	return img
+}
+
+// exifSegment 只含 Orientation 一个标签的大端 EXIF 数据
+func exifSegment(orientation uint16) []byte {
+	var b bytes.Buffer
+	b.WriteString("Exif\x00\x00MM\x00\x2a")
+	_ = binary.Write(&b, binary.BigEndian, uint32(8))      // IFD0 偏移
+	_ = binary.Write(&b, binary.BigEndian, uint16(1))      // 条目数
+	_ = binary.Write(&b, binary.BigEndian, uint16(0x0112)) // Orientation
+	_ = binary.Write(&b, binary.BigEndian, uint16(3))      // SHORT
+	_ = binary.Write(&b, binary.BigEndian, uint32(1))
+	_ = binary.Write(&b, binary.BigEndian, orientation)
+	b.Write([]byte{0, 0, 0, 0, 0, 0}) // 值的填充和下一个 IFD 偏移
+	return b.Bytes()
+}
+
+func TestImageFormat(t *testing.T) {
+	img := orientationImage()
+	encoders := map[string]func(*bytes.Buffer) error{
+		FormatJPEG: func(b *bytes.Buffer) error { return jpeg.Encode(b, img, nil) },
+		FormatPNG:  func(b *bytes.Buffer) error { return png.Encode(b, img) },
+		FormatGIF:  func(b *bytes.Buffer) error { return gif.Encode(b, img, nil) },
+		FormatBMP:  func(b *bytes.Buffer) error { return bmp.Encode(b, img) },
+		FormatTIFF: func(b *bytes.Buffer) error { return tiff.Encode(b, img, nil) },
+	}
+	for want, encode := range encoders {
+		var b bytes.Buffer
+		if err := encode(&b); err != nil {
+			t.Fatalf("encode %s: %v", want, err)
+		}
+		if got := ImageFormat(b.Bytes()); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+		decoded, format, err := DecodeImage(&b)
+		if err != nil || format != want || decoded.Bounds().Dx() != 3 {
+			t.Fatalf("decode %s: format %q, err %v", want, format, err)
+		}
+	}
+
+	if got := ImageFormat([]byte("RIFF\x10\x00\x00\x00WEBPVP8 ")); got != FormatWebP {
+		t.Fatalf("got %q, want webp", got)
+	}
+	for _, bad := range []string{"", "hello world!", "RIFF\x10\x00\x00\x00WAVEfmt ", "BM"} {
+		if got := ImageFormat([]byte(bad)); got != "" {
+			t.Fatalf("%q detected as %q", bad, got)
+		}
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	src := orientationImage()
+	// 原图左上角 (0,0) 在各方向下摆正后的位置
+	corners := map[int]image.Point{1: {0, 0}, 2: {2, 0}, 3: {2, 1}, 4: {0, 1}, 5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2}}
+	for o, p := range corners {
+		out := ApplyOrientation(src, o)
+		if o >= 5 && out.Bounds().Dx() != 2 {
+			t.Fatalf("orientation %d: size %v", o, out.Bounds())
+		}
+		if got := color.NRGBAModel.Convert(out.At(p.X, p.Y)).(color.NRGBA); got != src.NRGBAAt(0, 0) {
+			t.Fatalf("orientation %d: pixel at %v is %v", o, p, got)
+		}
+	}
+
+	// 顺时针旋转 90°：原图右上角到右下角；16 位灰度保持类型
+	gray := image.NewGray16(image.Rect(0, 0, 3, 2))
+	gray.SetGray16(2, 0, color.Gray16{Y: 1234})
+	out, ok := ApplyOrientation(gray, 6).(*image.Gray16)
+	if !ok || out.Gray16At(1, 2).Y != 1234 {
+		t.Fatalf("unexpected rotated gray16: %T", out)
+	}
+}
+
+func TestOrientation(t *testing.T) {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, orientationImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	exif := exifSegment(6)
+	app1 := append([]byte{0xff, 0xe1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}, exif...)
+	data := append(append([]byte{0xff, 0xd8}, app1...), b.Bytes()[2:]...)
+	if got := Orientation(data); got != 6 {
+		t.Fatalf("jpeg orientation %d, want 6", got)
+	}
+	img, _, err := DecodeImage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 2 || img.Bounds().Dy() != 3 {
+		t.Fatalf("jpeg not rotated: %v", img.Bounds())
+	}
+
+	// PNG 的 eXIf 块
+	b.Reset()
+	if err = png.Encode(&b, orientationImage()); err != nil {
+		t.Fatal(err)
+	}
+	payload := exif[6:]
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, "eXIf"...)
+	chunk = append(chunk, payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	header := 8 + 25 // 签名和 IHDR 块
+	data = append(append(append([]byte{}, b.Bytes()[:header]...), chunk...), b.Bytes()[header:]...)
+	if got := Orientation(data); got != 6 {
+		t.Fatalf("png orientation %d, want 6", got)
+	}
+	if img, _, err = DecodeImage(bytes.NewReader(data)); err != nil || img.Bounds().Dx() != 2 {
+		t.Fatalf("png not rotated: %v %v", img, err)
+	}
+
+	if got := Orientation(b.Bytes()); got != 1 {
+		t.Fatalf("orientation without exif %d, want 1", got)
+	}
+}
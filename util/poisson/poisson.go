@@ -0,0 +1,248 @@
+// Package poisson 用 DCT 求解 Neumann 边界的泊松方程 ∇²u = f，
+// 以及从梯度场重建高度（最小二乘积分）。
+//
+// DCT-II 的基函数恰好是反射边界下离散拉普拉斯算子的特征向量，
+// 变换后每个频率分量独立相除即可。DCT 由长度 2n 的 FFT 计算，
+// 长度不是 2 的幂时用 Bluestein 算法转为 2 的幂长度的卷积。
+package poisson
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// radix2 长度为 2 的幂的迭代 FFT
+type radix2 struct {
+	n       int
+	twiddle []complex128 // e^{-2πik/n}，k < n/2
+	rev     []int
+}
+
+func newRadix2(n int) *radix2 {
+	r := &radix2{n: n, twiddle: make([]complex128, n/2), rev: make([]int, n)}
+	for k := range r.twiddle {
+		r.twiddle[k] = cmplx.Rect(1, -2*math.Pi*float64(k)/float64(n))
+	}
+	shift := bits.UintSize - bits.TrailingZeros(uint(n))
+	for i := range r.rev {
+		if n > 1 {
+			r.rev[i] = int(bits.Reverse(uint(i)) >> shift)
+		}
+	}
+	return r
+}
+
+// transform 原地变换（不归一化），inverse 为 true 时使用共轭旋转因子
+func (r *radix2) transform(a []complex128, inverse bool) {
+	for i, j := range r.rev {
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for size := 2; size <= r.n; size <<= 1 {
+		half := size / 2
+		step := r.n / size
+		for start := 0; start < r.n; start += size {
+			for k := 0; k < half; k++ {
+				w := r.twiddle[k*step]
+				if inverse {
+					w = cmplx.Conj(w)
+				}
+				u := a[start+k]
+				v := a[start+k+half] * w
+				a[start+k] = u + v
+				a[start+k+half] = u - v
+			}
+		}
+	}
+}
+
+// fftPlan 任意长度的 FFT
+type fftPlan struct {
+	n     int
+	pow2  *radix2
+	chirp []complex128 // Bluestein：e^{-iπk²/n}
+	bFFT  []complex128 // Bluestein：共轭 chirp 序列的 FFT
+	work  []complex128
+}
+
+func newFFTPlan(n int) *fftPlan {
+	p := &fftPlan{n: n}
+	if n&(n-1) == 0 {
+		p.pow2 = newRadix2(n)
+		return p
+	}
+
+	l := 1
+	for l < 2*n-1 {
+		l <<= 1
+	}
+	p.pow2 = newRadix2(l)
+	p.chirp = make([]complex128, n)
+	for k := range p.chirp {
+		// k² 对 2n 取模，避免大下标时角度的精度损失
+		k2 := (k * k) % (2 * n)
+		p.chirp[k] = cmplx.Rect(1, -math.Pi*float64(k2)/float64(n))
+	}
+	p.bFFT = make([]complex128, l)
+	p.bFFT[0] = cmplx.Conj(p.chirp[0])
+	for k := 1; k < n; k++ {
+		p.bFFT[k] = cmplx.Conj(p.chirp[k])
+		p.bFFT[l-k] = p.bFFT[k]
+	}
+	p.pow2.transform(p.bFFT, false)
+	p.work = make([]complex128, l)
+	return p
+}
+
+// forward 原地正变换：X_k = Σ x_n e^{-2πikn/N}
+func (p *fftPlan) forward(x []complex128) {
+	if p.chirp == nil {
+		p.pow2.transform(x, false)
+		return
+	}
+
+	l := len(p.work)
+	clear(p.work)
+	for k := 0; k < p.n; k++ {
+		p.work[k] = x[k] * p.chirp[k]
+	}
+	p.pow2.transform(p.work, false)
+	for k := range p.work {
+		p.work[k] *= p.bFFT[k]
+	}
+	p.pow2.transform(p.work, true)
+	scale := complex(1/float64(l), 0)
+	for k := 0; k < p.n; k++ {
+		x[k] = p.work[k] * scale * p.chirp[k]
+	}
+}
+
+// dctPlan 长度 n 的 DCT-II 及其逆变换
+type dctPlan struct {
+	n     int
+	fft   *fftPlan // 长度 2n
+	shift []complex128
+	buf   []complex128
+}
+
+func newDCTPlan(n int) *dctPlan {
+	p := &dctPlan{
+		n:     n,
+		fft:   newFFTPlan(2 * n),
+		shift: make([]complex128, n),
+		buf:   make([]complex128, 2*n),
+	}
+	for k := range p.shift {
+		p.shift[k] = cmplx.Rect(1, -math.Pi*float64(k)/float64(2*n))
+	}
+	return p
+}
+
+// forward DCT-II：C_k = Σ x_n cos(πk(n+½)/N)，通过偶对称延拓到 2N 后做 FFT
+func (p *dctPlan) forward(x []float64) {
+	n := p.n
+	for i := 0; i < n; i++ {
+		p.buf[i] = complex(x[i], 0)
+		p.buf[2*n-1-i] = complex(x[i], 0)
+	}
+	p.fft.forward(p.buf)
+	for k := 0; k < n; k++ {
+		x[k] = real(p.buf[k]*p.shift[k]) / 2
+	}
+}
+
+// inverse DCT-III：x_n = (C_0 + 2Σ C_k cos(πk(n+½)/N)) / N
+func (p *dctPlan) inverse(c []float64) {
+	n := p.n
+	clear(p.buf)
+	for k := 0; k < n; k++ {
+		weight := 2.0
+		if k == 0 {
+			weight = 1
+		}
+		// 逆 FFT 用共轭正变换实现：conj(FFT(conj(z)))
+		p.buf[k] = cmplx.Conj(complex(weight*c[k], 0) * cmplx.Conj(p.shift[k]))
+	}
+	p.fft.forward(p.buf)
+	for i := 0; i < n; i++ {
+		c[i] = real(p.buf[i]) / float64(n)
+	}
+}
+
+// SolveNeumann 求解 w×h 网格上的 ∇²u = f（反射边界），解的均值为 0
+// f 的总和应为 0（由散度构造时自动满足），否则常数分量被忽略
+func SolveNeumann(f []float64, w, h int) []float64 {
+	u := make([]float64, len(f))
+	copy(u, f)
+
+	rowPlan := newDCTPlan(w)
+	colPlan := newDCTPlan(h)
+	col := make([]float64, h)
+
+	transformColumns := func(inverse bool) {
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				col[y] = u[y*w+x]
+			}
+			if inverse {
+				colPlan.inverse(col)
+			} else {
+				colPlan.forward(col)
+			}
+			for y := 0; y < h; y++ {
+				u[y*w+x] = col[y]
+			}
+		}
+	}
+
+	for y := 0; y < h; y++ {
+		rowPlan.forward(u[y*w : (y+1)*w])
+	}
+	transformColumns(false)
+
+	lambdaX := make([]float64, w)
+	for k := range lambdaX {
+		lambdaX[k] = 2*math.Cos(math.Pi*float64(k)/float64(w)) - 2
+	}
+	for ky := 0; ky < h; ky++ {
+		lambdaY := 2*math.Cos(math.Pi*float64(ky)/float64(h)) - 2
+		for kx := 0; kx < w; kx++ {
+			i := ky*w + kx
+			if denom := lambdaX[kx] + lambdaY; denom != 0 {
+				u[i] /= denom
+			} else {
+				u[i] = 0
+			}
+		}
+	}
+
+	transformColumns(true)
+	for y := 0; y < h; y++ {
+		rowPlan.inverse(u[y*w : (y+1)*w])
+	}
+	return u
+}
+
+// Integrate 从梯度场最小二乘重建高度（DCT 版 Frankot–Chellappa）：
+// gx[i] 为 u(x+1, y) - u(x, y)，gy[i] 为 u(x, y+1) - u(x, y)，
+// 最后一列的 gx、最后一行的 gy 不使用（Neumann 边界）。解的均值为 0
+func Integrate(gx, gy []float64, w, h int) []float64 {
+	// 散度为后向差分
+	div := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if x < w-1 {
+				div[i] += gx[i]
+				div[i+1] -= gx[i]
+			}
+			if y < h-1 {
+				div[i] += gy[i]
+				div[i+w] -= gy[i]
+			}
+		}
+	}
+	return SolveNeumann(div, w, h)
+}
@@ -0,0 +1,104 @@
+package poisson
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDCTRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 5, 8, 37, 64, 100} {
+		plan := newDCTPlan(n)
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = rng.Float64()*2 - 1
+		}
+
+		got := append([]float64(nil), x...)
+		plan.forward(got)
+		for k := 0; k < n; k++ {
+			var want float64
+			for i, v := range x {
+				want += v * math.Cos(math.Pi*float64(k)*(float64(i)+0.5)/float64(n))
+			}
+			if math.Abs(got[k]-want) > 1e-9 {
+				t.Fatalf("n=%d: C[%d] = %v, want %v", n, k, got[k], want)
+			}
+		}
+
+		plan.inverse(got)
+		for i := range x {
+			if math.Abs(got[i]-x[i]) > 1e-9 {
+				t.Fatalf("n=%d: round trip x[%d] = %v, want %v", n, i, got[i], x[i])
+			}
+		}
+	}
+}
+
+func TestSolveNeumann(t *testing.T) {
+	const w, h = 23, 16
+	u := make([]float64, w*h)
+	var mean float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			u[y*w+x] = math.Sin(float64(x)*0.3) + math.Cos(float64(y)*0.5) + 0.01*float64(x*y)
+			mean += u[y*w+x]
+		}
+	}
+	mean /= w * h
+
+	// 反射边界的离散拉普拉斯
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), w-1)
+		y = min(max(y, 0), h-1)
+		return u[y*w+x]
+	}
+	f := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			f[y*w+x] = at(x-1, y) + at(x+1, y) + at(x, y-1) + at(x, y+1) - 4*at(x, y)
+		}
+	}
+
+	got := SolveNeumann(f, w, h)
+	for i := range u {
+		if math.Abs(got[i]-(u[i]-mean)) > 1e-8 {
+			t.Fatalf("u[%d] = %v, want %v", i, got[i], u[i]-mean)
+		}
+	}
+}
+
+func TestIntegrate(t *testing.T) {
+	const w, h = 31, 20
+	u := make([]float64, w*h)
+	var mean float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			u[y*w+x] = math.Exp(-float64((x-15)*(x-15)+(y-9)*(y-9))/40) + 0.02*float64(x)
+			mean += u[y*w+x]
+		}
+	}
+	mean /= w * h
+
+	gx := make([]float64, w*h)
+	gy := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if x < w-1 {
+				gx[i] = u[i+1] - u[i]
+			}
+			if y < h-1 {
+				gy[i] = u[i+w] - u[i]
+			}
+		}
+	}
+
+	got := Integrate(gx, gy, w, h)
+	for i := range u {
+		if math.Abs(got[i]-(u[i]-mean)) > 1e-8 {
+			t.Fatalf("u[%d] = %v, want %v", i, got[i], u[i]-mean)
+		}
+	}
+}